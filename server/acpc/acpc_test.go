@@ -0,0 +1,146 @@
+package acpc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// raiser 能加注时按最小金额加注，否则跟注的本地机器人
+var raiser = poker.BotFunc(func(ctx context.Context, v *poker.View) (poker.Decision, error) {
+	for _, a := range v.Legal {
+		if a.Type == poker.ActionBet || a.Type == poker.ActionRaise {
+			return poker.Decision{Type: a.Type, Amount: a.Min}, nil
+		}
+	}
+	return poker.Decision{Type: poker.ActionCall}, nil
+})
+
+// caller 总是跟注的本地机器人
+var caller = poker.BotFunc(func(ctx context.Context, v *poker.View) (poker.Decision, error) {
+	for _, a := range v.Legal {
+		if a.Type == poker.ActionCall {
+			return poker.Decision{Type: poker.ActionCall}, nil
+		}
+	}
+	return poker.Decision{Type: poker.ActionCheck}, nil
+})
+
+// connect 连接到发牌方，对收到的每个局面都回复跟注，轮不到自己时的回复会被发牌方忽略。
+// 连接关闭后返回收到的所有局面
+func connect(t *testing.T, addr string) <-chan []string {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	_, err = fmt.Fprintf(conn, "%s\r\n", Version)
+	require.NoError(t, err)
+
+	states := make(chan []string, 1)
+	go func() {
+		defer conn.Close()
+		var lines []string
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			lines = append(lines, line)
+			fmt.Fprintf(conn, "%s:c\r\n", line)
+		}
+		states <- lines
+	}()
+	return states
+}
+
+// serve 在本机的随机端口上主持比赛，返回结果和每个外部客户端收到的局面
+func serve(t *testing.T, cfg Config) (*Result, [][]string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var clients []<-chan []string
+	for _, p := range cfg.Players {
+		if p.Bot == nil {
+			clients = append(clients, connect(t, l.Addr().String()))
+		}
+	}
+	result, err := Serve(context.Background(), l, cfg)
+	require.NoError(t, err)
+
+	states := make([][]string, len(clients))
+	for i, c := range clients {
+		states[i] = <-c
+	}
+	return result, states
+}
+
+func TestMatchState(t *testing.T) {
+	s, action, err := ParseMatchState("MATCHSTATE:1:30:cr300/c:|9hQd/8dAs8s:r900\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, MatchState{Position: 1, Hand: 30, Betting: "cr300/c", Cards: "|9hQd/8dAs8s"}, s)
+	assert.Equal(t, "r900", action)
+	assert.Equal(t, "MATCHSTATE:1:30:cr300/c:|9hQd/8dAs8s", s.String())
+
+	_, _, err = ParseMatchState("MATCHSTATE:x:30::|")
+	assert.Error(t, err)
+}
+
+func TestLimitHeadsUp(t *testing.T) {
+	var log strings.Builder
+	result, states := serve(t, Config{
+		Players: []Player{{Name: "remote"}, {Name: "raiser", Bot: raiser}},
+		Hands:   10,
+		Limit:   true,
+		Log:     &log,
+	})
+	require.Equal(t, 10, result.Hands)
+	assert.Zero(t, result.Scores[0].Won+result.Scores[1].Won)
+
+	// 第一手牌外部客户端在位置 0 的大盲注，只能看到自己的底牌
+	assert.Regexp(t, `^MATCHSTATE:0:0::[2-9TJQKA][cdhs][2-9TJQKA][cdhs]\|$`, states[0][0])
+	assert.Regexp(t, `^MATCHSTATE:1:1::\|\w{4}$`, handStates(states[0], 1)[0])
+
+	// 小盲注加注一次后大盲注跟注，之后每条街大盲注过牌、小盲注下注、大盲注跟注
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	require.Len(t, lines, 10)
+	assert.Regexp(t, `^STATE:0:rc/crc/crc/crc:\w{4}\|\w{4}/\w{6}/\w{2}/\w{2}:(-70\|70|70\|-70|0\|0):remote\|raiser$`, lines[0])
+	assert.Regexp(t, `^STATE:1:`, lines[1])
+	assert.Contains(t, lines[1], ":raiser|remote")
+
+	// 摊牌时客户端可以看到对手的底牌
+	hand := handStates(states[0], 0)
+	assert.Regexp(t, `:rc/crc/crc/crc:\w{4}\|\w{4}/`, hand[len(hand)-1])
+}
+
+func TestNoLimitMultiway(t *testing.T) {
+	var log strings.Builder
+	result, _ := serve(t, Config{
+		Players: []Player{{Name: "remote"}, {Name: "caller", Bot: caller}, {Name: "raiser", Bot: raiser}},
+		Hands:   3,
+		Log:     &log,
+	})
+	require.Equal(t, 3, result.Hands)
+	assert.Zero(t, result.Scores[0].Won+result.Scores[1].Won+result.Scores[2].Won)
+
+	// 庄家首先加注到 200，之后每条街都由庄家下注一个大盲注，加注的金额为本手牌投入的总额
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "STATE:0:r200cc/ccr300cc/ccr400cc/ccr500cc:"), lines[0])
+	assert.True(t, strings.HasSuffix(lines[0], ":remote|caller|raiser"), lines[0])
+}
+
+// handStates 客户端收到的某一手牌的所有局面
+func handStates(states []string, hand int) []string {
+	prefix := regexp.MustCompile(fmt.Sprintf(`^MATCHSTATE:\d+:%d:`, hand))
+	var found []string
+	for _, s := range states {
+		if prefix.MatchString(s) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
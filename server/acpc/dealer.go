@@ -0,0 +1,342 @@
+package acpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Version 支持的协议版本，客户端连接后首先发送 VERSION:2.0.0
+const Version = "VERSION:2.0.0"
+
+// handshakeTimeout 等待客户端发送协议版本的时限
+const handshakeTimeout = 10 * time.Second
+
+// Player 比赛中的一名玩家
+type Player struct {
+	Name string    // 玩家名称，不能重复
+	Bot  poker.Bot // 本地的机器人，为 nil 时由外部的 ACPC 客户端控制
+}
+
+// Config 比赛的设置
+type Config struct {
+	Players    []Player      // 参加比赛的玩家，2 到 6 名，每手牌轮换一次位置
+	Hands      int           // 手牌数
+	Limit      bool          // 是否为固定限注
+	SmallBlind int           // 小盲注，为 0 时限注为 5，不限注为 50
+	Stack      int           // 每手牌开始时的筹码，为 0 时为 20000
+	Seed       int64         // 发牌的随机种子
+	Timeout    time.Duration // 外部客户端每次行动的时限，超时后代为过牌或弃牌，为 0 时不限制
+	Log        io.Writer     // 按 ACPC 的格式记录每手牌的结果，为 nil 时不记录
+}
+
+// Score 一名玩家的比赛结果
+type Score struct {
+	Name    string  `json:"name"`     // 玩家名称
+	Won     int     `json:"won"`      // 一共赢得的筹码
+	PerHand float64 `json:"per_hand"` // 平均每手牌赢得的筹码
+}
+
+// Result 比赛的结果
+type Result struct {
+	Hands  int     `json:"hands"`  // 完成的手牌数
+	Scores []Score `json:"scores"` // 按设置中的顺序排列的每名玩家的结果
+}
+
+// local 本地的机器人，行动前同样向所有客户端发送当前的局面
+type local struct {
+	m   *match
+	bot poker.Bot
+}
+
+// client 外部的 ACPC 客户端，作为机器人坐在牌桌上
+type client struct {
+	m      *match
+	conn   net.Conn
+	r      *bufio.Reader
+	player int    // 在设置中的序号
+	sent   string // 最近一次发送的局面
+}
+
+// match 进行中的比赛
+type match struct {
+	cfg     Config
+	clients []*client // 按设置中的顺序排列，本地机器人为 nil
+	hand    int       // 当前的手牌编号
+	g       *poker.Game
+	seats   []int // 当前这手牌每名玩家的座位号
+	err     error // 与客户端通信时出现的错误，出现后结束比赛
+}
+
+// Serve 在 l 上按 Players 中外部客户端的顺序接受连接，全部连接后开始比赛
+func Serve(ctx context.Context, l net.Listener, cfg Config) (*Result, error) {
+	remote := 0
+	for _, p := range cfg.Players {
+		if p.Bot == nil {
+			remote++
+		}
+	}
+
+	stop := context.AfterFunc(ctx, func() { l.Close() })
+	defer stop()
+
+	conns := make([]net.Conn, 0, remote)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	for len(conns) < remote {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		conns = append(conns, conn)
+	}
+	return Run(ctx, cfg, conns)
+}
+
+// Run 使用已经建立的连接进行比赛，conns 按顺序对应 Players 中的外部客户端。
+// ctx 结束或客户端断开时返回已经完成的部分和错误
+func Run(ctx context.Context, cfg Config, conns []net.Conn) (*Result, error) {
+	n := len(cfg.Players)
+	if n < 2 || n > 6 {
+		return nil, fmt.Errorf("a match needs 2 to 6 players, got %d", n)
+	}
+	if cfg.Hands <= 0 {
+		return nil, fmt.Errorf("the number of hands must be positive, got %d", cfg.Hands)
+	}
+	if cfg.SmallBlind <= 0 {
+		cfg.SmallBlind = 50
+		if cfg.Limit {
+			cfg.SmallBlind = 5
+		}
+	}
+	if cfg.Stack <= 0 {
+		cfg.Stack = 20000
+	}
+
+	m := &match{cfg: cfg, clients: make([]*client, n), seats: make([]int, n)}
+	names := make(map[string]bool)
+	next := 0
+	for i, p := range cfg.Players {
+		if p.Name == "" || names[p.Name] || strings.ContainsAny(p.Name, "|:") {
+			return nil, fmt.Errorf("invalid or duplicate player name %q", p.Name)
+		}
+		names[p.Name] = true
+		if p.Bot != nil {
+			continue
+		}
+		if next >= len(conns) {
+			return nil, fmt.Errorf("no connection for player %s", p.Name)
+		}
+		m.clients[i] = &client{m: m, conn: conns[next], r: bufio.NewReader(conns[next]), player: i}
+		next++
+	}
+
+	// ctx 结束时关闭连接，让等待中的读写立即返回
+	stop := context.AfterFunc(ctx, func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+	defer stop()
+
+	for _, c := range m.clients {
+		if c == nil {
+			continue
+		}
+		if err := c.handshake(); err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.Players[c.player].Name, err)
+		}
+	}
+
+	result := &Result{Scores: make([]Score, n)}
+	for i, p := range cfg.Players {
+		result.Scores[i].Name = p.Name
+	}
+	for ; m.hand < cfg.Hands; m.hand++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		won, err := m.play()
+		if err == nil {
+			err = m.err
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			return result, fmt.Errorf("hand %d: %w", m.hand, err)
+		}
+
+		result.Hands++
+		for i, w := range won {
+			result.Scores[i].Won += w
+			result.Scores[i].PerHand = float64(result.Scores[i].Won) / float64(result.Hands)
+		}
+	}
+	return result, nil
+}
+
+// play 在新的牌桌上打一手牌，玩家 i 坐在位置 (i+hand)%n 上，返回每名玩家的输赢
+func (m *match) play() ([]int, error) {
+	cfg := m.cfg
+	n := len(cfg.Players)
+	g := poker.NewGameWithSeats(n)
+	g.BotTimeout, g.HandEvents = -1, true
+	deck := poker.NewDeckWithRand(rand.New(rand.NewSource(cfg.Seed + int64(m.hand))))
+	g.DeckSource = func() *poker.Deck { return deck }
+	if err := g.SetBlinds(cfg.SmallBlind, 0); err != nil {
+		return nil, err
+	}
+	if err := g.SetLimit(cfg.Limit); err != nil {
+		return nil, err
+	}
+
+	// 第一手牌的庄家在座位 0，位置 0 在座位 1
+	ids := make([]string, n)
+	s := g.Table.Seats
+	for seat := 0; seat < n; seat++ {
+		i := ((seat-1-m.hand)%n + 2*n) % n
+		ids[i], m.seats[i] = s.Player.Id, seat
+		var bot poker.Bot = m.clients[i]
+		if m.clients[i] == nil {
+			bot = &local{m: m, bot: cfg.Players[i].Bot}
+		}
+		if err := g.AddBot(s.Player.Id, cfg.Players[i].Name, cfg.Stack, bot); err != nil {
+			return nil, err
+		}
+		s = s.Next()
+	}
+
+	m.g = g
+	if err := g.StartHand(); err != nil {
+		return nil, err
+	}
+	if g.IsPlayerStage() {
+		return nil, fmt.Errorf("the hand did not finish, waiting for %s", g.CurrentSeat.Player.Name)
+	}
+
+	// 发送这手牌最后的局面
+	r := replay(g)
+	if err := m.broadcast(r); err != nil {
+		return nil, err
+	}
+
+	won := make([]int, n)
+	for i, id := range ids {
+		won[i] = g.PlayerMap[id].Chips - cfg.Stack
+	}
+	if cfg.Log != nil {
+		m.log(r, won)
+	}
+	return won, nil
+}
+
+// broadcast 向局面有变化的客户端发送新的局面
+func (m *match) broadcast(r *record) error {
+	for _, c := range m.clients {
+		if c == nil {
+			continue
+		}
+		state := r.state(m.hand, r.position(m.seats[c.player]), false).String()
+		if state == c.sent {
+			continue
+		}
+		if _, err := io.WriteString(c.conn, state+"\r\n"); err != nil {
+			return fmt.Errorf("%s: %w", m.cfg.Players[c.player].Name, err)
+		}
+		c.sent = state
+	}
+	return nil
+}
+
+// log 按 ACPC 的格式记录一手牌：STATE:<手牌编号>:<下注>:<牌>:<输赢>:<玩家名称>，输赢和名称按位置排列
+func (m *match) log(r *record, won []int) {
+	n := len(won)
+	wins, names := make([]string, n), make([]string, n)
+	for i, w := range won {
+		pos := r.position(m.seats[i])
+		wins[pos] = fmt.Sprint(w)
+		names[pos] = m.cfg.Players[i].Name
+	}
+	s := r.state(m.hand, 0, true)
+	fmt.Fprintf(m.cfg.Log, "STATE:%d:%s:%s:%s:%s\n", m.hand, s.Betting, s.Cards, strings.Join(wins, "|"), strings.Join(names, "|"))
+}
+
+// handshake 读取客户端发送的协议版本
+func (c *client) handshake() error {
+	c.conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	defer c.conn.SetReadDeadline(time.Time{})
+
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if !strings.HasPrefix(line, "VERSION:2.") {
+		return fmt.Errorf("unsupported protocol version %q, expected %s", line, Version)
+	}
+	return nil
+}
+
+// Decide 向所有客户端发送当前的局面，然后等待这个客户端回复动作。
+// 与当前局面不符的回复会被忽略，通信出错时记录错误并结束比赛
+func (c *client) Decide(ctx context.Context, v *poker.View) (poker.Decision, error) {
+	m := c.m
+	if m.err != nil {
+		return poker.Decision{}, m.err
+	}
+	r := replay(m.g)
+	if err := m.broadcast(r); err != nil {
+		m.err = err
+		return poker.Decision{}, err
+	}
+
+	if m.cfg.Timeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(m.cfg.Timeout))
+		defer c.conn.SetReadDeadline(time.Time{})
+	}
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			// 超时后代为行动，迟到的回复会因为局面不符被忽略
+			var ne net.Error
+			if !errors.As(err, &ne) || !ne.Timeout() {
+				m.err = fmt.Errorf("%s: %w", m.cfg.Players[c.player].Name, err)
+			}
+			return poker.Decision{}, err
+		}
+
+		// 忽略注释、空行和之前局面的回复
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" || line[0] == '#' || line[0] == ';' || !strings.HasPrefix(line, c.sent+":") {
+			continue
+		}
+		return r.decision(line[len(c.sent)+1:], v)
+	}
+}
+
+// Decide 向所有客户端发送当前的局面，然后由本地的机器人决定
+func (l *local) Decide(ctx context.Context, v *poker.View) (poker.Decision, error) {
+	m := l.m
+	if m.err == nil {
+		m.err = m.broadcast(replay(m.g))
+	}
+	if m.err != nil {
+		return poker.Decision{}, m.err
+	}
+	return l.bot.Decide(ctx, v)
+}
@@ -0,0 +1,195 @@
+// Package acpc 实现年度计算机扑克大赛（ACPC）的发牌协议，让外部的研究机器人通过 TCP 与 poker.Game 对局。
+//
+// 每当局面变化时，发牌方向每名玩家发送一行 MATCHSTATE:<位置>:<手牌编号>:<下注>:<牌>，
+// 轮到玩家行动时，玩家原样返回这一行并在后面加上 :<动作>。动作为 f（弃牌）、c（过牌或跟注）或 r（下注或加注），
+// 不限注时 r 后面跟上加注后本手牌投入的筹码总额，例如：r300
+package acpc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// MatchState 一名玩家视角下的局面
+type MatchState struct {
+	Position int    // 玩家的位置，位置 0 为庄家的下一位，单挑时为大盲注
+	Hand     int    // 手牌编号，从 0 开始
+	Betting  string // 每条街上的动作，用 / 分隔
+	Cards    string // 按位置排列的底牌用 | 分隔，看不到的底牌为空，后面用 / 分隔每条街的公共牌
+}
+
+// String 返回协议中的局面，例如："MATCHSTATE:0:30:cc/r250:9s8h|/8c8d5c"
+func (s MatchState) String() string {
+	return fmt.Sprintf("MATCHSTATE:%d:%d:%s:%s", s.Position, s.Hand, s.Betting, s.Cards)
+}
+
+// ParseMatchState 解析一行局面，客户端的回复中跟在局面后面的动作一并返回，没有动作时为空
+func ParseMatchState(line string) (MatchState, string, error) {
+	parts := strings.SplitN(strings.TrimRight(line, "\r\n"), ":", 6)
+	if len(parts) < 5 || parts[0] != "MATCHSTATE" {
+		return MatchState{}, "", fmt.Errorf("invalid match state: %q", line)
+	}
+
+	var s MatchState
+	var err error
+	if s.Position, err = strconv.Atoi(parts[1]); err != nil {
+		return MatchState{}, "", fmt.Errorf("invalid position in match state: %q", line)
+	}
+	if s.Hand, err = strconv.Atoi(parts[2]); err != nil {
+		return MatchState{}, "", fmt.Errorf("invalid hand number in match state: %q", line)
+	}
+	s.Betting, s.Cards = parts[3], parts[4]
+
+	action := ""
+	if len(parts) == 6 {
+		action = parts[5]
+	}
+	return s, action, nil
+}
+
+// record 从一手牌的事件中整理出的协议需要的信息
+type record struct {
+	players   int
+	dealer    int            // 庄家的座位号
+	betting   string         // 每条街上的动作
+	holes     [][]poker.Card // 按座位排列的底牌
+	board     []string       // 每条街新发出的公共牌
+	folded    []bool         // 按座位排列，是否已经弃牌
+	committed []int          // 按座位排列，之前几条街投入的筹码
+	round     []int          // 按座位排列，本轮投入的筹码
+	showdown  bool           // 是否进行了摊牌
+}
+
+// replay 读取当前这手牌的事件
+func replay(g *poker.Game) *record {
+	n := g.Table.Seats.Len()
+	r := &record{
+		players:   n,
+		holes:     make([][]poker.Card, n),
+		folded:    make([]bool, n),
+		committed: make([]int, n),
+		round:     make([]int, n),
+	}
+
+	start := 0
+	for i, e := range g.Events {
+		if _, ok := e.(poker.HandStarted); ok {
+			start = i
+		}
+	}
+
+	var betting strings.Builder
+	for _, e := range g.Events[start:] {
+		switch e := e.(type) {
+		case poker.HandStarted:
+			r.dealer = e.Dealer
+		case poker.BlindPosted:
+			if e.Type == poker.ActionAnte {
+				r.committed[e.Seat] += e.Amount
+			} else {
+				r.round[e.Seat] += e.Amount
+			}
+		case poker.CardsDealt:
+			for _, h := range e.Hands {
+				r.holes[h.Seat] = []poker.Card{h.Cards[0], h.Cards[1]}
+			}
+		case poker.PlayerActed:
+			switch e.Type {
+			case poker.ActionFold:
+				betting.WriteByte('f')
+				r.folded[e.Seat] = true
+			case poker.ActionCheck, poker.ActionCall:
+				betting.WriteByte('c')
+			default:
+				betting.WriteByte('r')
+				if !g.Table.Limit {
+					betting.WriteString(strconv.Itoa(r.committed[e.Seat] + e.Total))
+				}
+			}
+			r.round[e.Seat] = e.Total
+		case poker.StreetDealt:
+			betting.WriteByte('/')
+			codes := make([]string, len(e.Cards))
+			for i := range e.Cards {
+				codes[i] = e.Cards[i].Code()
+			}
+			r.board = append(r.board, strings.Join(codes, ""))
+			for seat, bet := range r.round {
+				r.committed[seat] += bet
+				r.round[seat] = 0
+			}
+		case poker.HandEnded:
+			r.showdown = e.Showdown
+		}
+	}
+	r.betting = betting.String()
+	return r
+}
+
+// seat 位置对应的座位号
+func (r *record) seat(position int) int {
+	return (r.dealer + 1 + position) % r.players
+}
+
+// position 座位对应的位置
+func (r *record) position(seat int) int {
+	return (seat - r.dealer - 1 + 2*r.players) % r.players
+}
+
+// state 位置为 position 的玩家看到的局面，摊牌时可以看到所有没有弃牌的玩家的底牌。
+// all 为 true 时显示所有玩家的底牌，用于记录比赛
+func (r *record) state(hand, position int, all bool) MatchState {
+	holes := make([]string, r.players)
+	for pos := range holes {
+		seat := r.seat(pos)
+		if cards := r.holes[seat]; len(cards) == 2 && (all || pos == position || r.showdown && !r.folded[seat]) {
+			holes[pos] = cards[0].Code() + cards[1].Code()
+		}
+	}
+
+	cards := strings.Join(holes, "|")
+	for _, b := range r.board {
+		cards += "/" + b
+	}
+	return MatchState{Position: position, Hand: hand, Betting: r.betting, Cards: cards}
+}
+
+// decision 将客户端的动作转换为牌局的决定。
+// 不限注时加注的金额为本手牌投入的筹码总额，超出范围时取最接近的合法金额，没有金额时为最小加注
+func (r *record) decision(action string, v *poker.View) (poker.Decision, error) {
+	if action == "" {
+		return poker.Decision{}, fmt.Errorf("empty action")
+	}
+
+	switch action[0] {
+	case 'f':
+		return poker.Decision{Type: poker.ActionFold}, nil
+	case 'c', 'k':
+		for _, a := range v.Legal {
+			if a.Type == poker.ActionCall {
+				return poker.Decision{Type: poker.ActionCall}, nil
+			}
+		}
+		return poker.Decision{Type: poker.ActionCheck}, nil
+	case 'r', 'b':
+		for _, a := range v.Legal {
+			if a.Type != poker.ActionBet && a.Type != poker.ActionRaise {
+				continue
+			}
+			amount := a.Min
+			if len(action) > 1 {
+				total, err := strconv.Atoi(action[1:])
+				if err != nil {
+					return poker.Decision{}, fmt.Errorf("invalid raise: %q", action)
+				}
+				amount = min(max(total-r.committed[v.Seat], a.Min), a.Max)
+			}
+			return poker.Decision{Type: a.Type, Amount: amount}, nil
+		}
+		return poker.Decision{}, fmt.Errorf("cannot raise now: %q", action)
+	}
+	return poker.Decision{}, fmt.Errorf("invalid action: %q", action)
+}
@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"sort"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/lllllan02/pocker/stats"
+)
+
+const (
+	minStatsHands    = 20   // 对手的统计至少有多少手牌时才用来调整打法
+	minStatsSpots    = 5    // 某一项比例至少有多少次机会时才使用
+	minRangePercent  = 3.0  // 估算的范围最窄占所有起手牌的百分比
+	typicalVPIP      = 25.0 // 一般玩家翻牌前入池的百分比
+	typicalFoldToBet = 45.0 // 一般玩家面对持续下注弃牌的百分比
+	maxBluffFactor   = 2.0  // 诈唬的概率最多放大的倍数
+	maxStealFactor   = 4.0  // 偷盲的概率最多放大的倍数
+)
+
+// strengthOrder 按 Chen 公式分数从高到低排列的起手牌编号
+var strengthOrder = func() []int {
+	order := make([]int, NumStartingHands)
+	for i := range order {
+		order[i] = i
+	}
+	score := func(i int) float64 {
+		c := StartingHandAt(i).Combos()[0]
+		return Strength(c[0], c[1])
+	}
+	sort.SliceStable(order, func(a, b int) bool { return score(order[a]) > score(order[b]) })
+	return order
+}()
+
+// TopRange 按 Chen 公式分数取最好的起手牌，直到组合数达到所有起手牌的 percent 百分比
+func TopRange(percent float64) *HandRange {
+	r := &HandRange{}
+	combos := 0.0
+	for _, i := range strengthOrder {
+		if combos >= percent/100*1326 {
+			break
+		}
+		r[i] = 1
+		combos += float64(len(StartingHandAt(i).Combos()))
+	}
+	return r
+}
+
+// opponent 获取对手在牌桌共享的统计，不调整打法或者统计的手数不足时返回 false
+func (h *Heuristic) opponent(name string) (stats.Stats, bool) {
+	if !h.Config.Adapt || h.Stats == nil {
+		return stats.Stats{}, false
+	}
+	s := h.Stats.Stats(name)
+	return s, s.Hands >= minStatsHands
+}
+
+// modelRange 按对手的统计估算范围：翻牌前加注过的对手取最好的 PFR 比例的起手牌，跟注过的取 VPIP 比例，
+// 很少主动下注的对手在翻牌后下注或加注时范围再缩小一半
+func (h *Heuristic) modelRange(v *poker.View, p poker.PlayerView) (Range, bool) {
+	s, ok := h.opponent(p.Name)
+	if !ok {
+		return nil, false
+	}
+
+	width, strong := 100.0, false
+	for _, a := range v.Actions {
+		if a.Seat != p.Seat {
+			continue
+		}
+		raise := a.Type == poker.ActionBet || a.Type == poker.ActionRaise
+		switch {
+		case a.Stage == poker.GameStagePreflop && raise:
+			width = min(width, s.PFRRate())
+		case a.Stage == poker.GameStagePreflop && a.Type == poker.ActionCall:
+			width = min(width, s.VPIPRate())
+		case raise && s.Aggression() < 1:
+			strong = true
+		}
+	}
+	if strong {
+		width /= 2
+	}
+	return TopRange(max(width, minRangePercent)), true
+}
+
+// bluff 翻牌后诈唬的概率，按每名对手面对下注时弃牌的倾向放大或缩小，对跟注站减少诈唬
+func (h *Heuristic) bluff(v *poker.View) float64 {
+	factor := 1.0
+	for _, p := range h.opponents(v) {
+		s, ok := h.opponent(p.Name)
+		switch {
+		case !ok:
+		case s.FacedCBet >= minStatsSpots:
+			factor *= s.FoldToCBetRate() / typicalFoldToBet
+		default:
+			factor *= typicalVPIP / max(s.VPIPRate(), 1)
+		}
+	}
+	return h.Config.Bluff * min(factor, maxBluffFactor)
+}
+
+// steal 在后位偷盲的概率，还没有弃牌的对手越紧偷得越多
+func (h *Heuristic) steal(v *poker.View) float64 {
+	factor := 1.0
+	for _, p := range h.opponents(v) {
+		if s, ok := h.opponent(p.Name); ok {
+			factor *= typicalVPIP / max(s.VPIPRate(), 1)
+		}
+	}
+	return h.Config.Bluff * min(factor, maxStealFactor)
+}
+
+// opponents 还没有弃牌的对手
+func (h *Heuristic) opponents(v *poker.View) []poker.PlayerView {
+	players := make([]poker.PlayerView, 0, len(v.Players))
+	for _, p := range v.Players {
+		if p.Seat != v.Seat && !p.Folded && p.Status == poker.PlayerActive {
+			players = append(players, p)
+		}
+	}
+	return players
+}
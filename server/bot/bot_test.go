@@ -0,0 +1,259 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/lllllan02/pocker/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrength(t *testing.T) {
+	card := func(r poker.CardRank, s poker.CardSuit) poker.Card { return poker.Card{Rank: r, Suit: s} }
+
+	assert.Equal(t, 20.0, Strength(card(poker.Ace, poker.Spades), card(poker.Ace, poker.Hearts)))
+	assert.Equal(t, 5.0, Strength(card(poker.Two, poker.Spades), card(poker.Two, poker.Hearts)))
+	assert.Equal(t, 12.0, Strength(card(poker.Ace, poker.Spades), card(poker.King, poker.Spades)))
+	assert.Equal(t, 9.0, Strength(card(poker.Jack, poker.Hearts), card(poker.Ten, poker.Hearts)))
+	assert.Equal(t, -1.0, Strength(card(poker.Seven, poker.Spades), card(poker.Two, poker.Hearts)))
+}
+
+func TestEquity(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	aces := [2]poker.Card{{Rank: poker.Ace, Suit: poker.Spades}, {Rank: poker.Ace, Suit: poker.Hearts}}
+
+	// AA 对抗任意一手牌的胜率约为 85%
+	equity := Equity(context.Background(), aces, nil, []Range{ChenRange(0)}, 4000, r)
+	assert.InDelta(t, 0.85, equity, 0.03)
+
+	// 对手范围越窄，胜率越低
+	narrow := Equity(context.Background(), aces, nil, []Range{ChenRange(10)}, 4000, r)
+	assert.Less(t, narrow, equity)
+
+	// 公共牌上已经是皇家同花顺时平分奖池
+	board := []poker.Card{
+		{Rank: poker.Ten, Suit: poker.Clubs}, {Rank: poker.Jack, Suit: poker.Clubs}, {Rank: poker.Queen, Suit: poker.Clubs},
+		{Rank: poker.King, Suit: poker.Clubs}, {Rank: poker.Ace, Suit: poker.Clubs},
+	}
+	assert.InDelta(t, 0.5, Equity(context.Background(), aces, board, []Range{ChenRange(0)}, 200, r), 1e-9)
+}
+
+func TestHeuristicBots(t *testing.T) {
+	_, err := New("impossible", 1)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"adaptive", "easy", "hard", "medium"}, Levels())
+
+	// 六个不同难度的机器人互相对局，每一手牌都能正常结束
+	g := poker.NewGame()
+	g.Debug = true
+	s := g.Table.Seats
+	for i := 0; i < s.Len(); i++ {
+		level := Levels()[i%len(Presets)]
+		b, err := New(level, int64(i))
+		require.NoError(t, err)
+		require.NoError(t, g.AddBot(s.Player.Id, fmt.Sprintf("%s %d", level, i), 500, b))
+		s = s.Next()
+	}
+
+	for hand := 0; hand < 20; hand++ {
+		if len(g.Table.Seats.GetActivePlayers()) < 2 {
+			break
+		}
+		players := 0
+		for _, p := range g.Table.Seats.GetActivePlayers() {
+			if p.Chips > 0 {
+				players++
+			}
+		}
+		if players < 2 {
+			break
+		}
+		require.NoError(t, g.StartHand())
+		assert.Equal(t, poker.GameStageShowdown, g.Stage)
+	}
+	assert.Greater(t, g.HandId, 1)
+}
+
+func TestStartingHand(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < NumStartingHands; i++ {
+		h := StartingHandAt(i)
+		assert.Equal(t, i, h.Index())
+		parsed, err := ParseStartingHand(h.String())
+		require.NoError(t, err)
+		assert.Equal(t, h, parsed)
+		seen[h.String()] = true
+	}
+	assert.Len(t, seen, NumStartingHands)
+
+	h, err := ParseStartingHand("kas")
+	require.NoError(t, err)
+	assert.Equal(t, "AKs", h.String())
+	assert.Len(t, h.Combos(), 4)
+	for _, s := range []string{"AA", "T9o"} {
+		h, _ := ParseStartingHand(s)
+		assert.Len(t, h.Combos(), map[string]int{"AA": 6, "T9o": 12}[s])
+	}
+	for _, s := range []string{"", "AAs", "AK", "A1s", "AKx"} {
+		_, err := ParseStartingHand(s)
+		assert.Error(t, err, s)
+	}
+
+	full := HandRange{}
+	for i := range full {
+		full[i] = 1
+	}
+	assert.InDelta(t, 100, full.Percent(), 1e-9)
+}
+
+func TestSolvePushFold(t *testing.T) {
+	_, err := SolvePushFold(PushFoldOptions{Players: 4, Stack: 100, SmallBlind: 5})
+	assert.Error(t, err)
+	_, err = SolvePushFold(PushFoldOptions{Players: 2, Stack: 10, SmallBlind: 5})
+	assert.Error(t, err)
+
+	// 单挑 10 个大盲注时小盲注大约全下一半以上的起手牌，大盲注跟注的范围更窄
+	chart, err := SolvePushFold(PushFoldOptions{Players: 2, Stack: 100, SmallBlind: 5, Trials: 40, Iterations: 100, Seed: 1})
+	require.NoError(t, err)
+	push, call := chart.Ranges[SpotSmallBlindPush], chart.Ranges[SpotBigBlindCall]
+	assert.InDelta(t, 57, push.Percent(), 8)
+	assert.Less(t, call.Percent(), push.Percent())
+
+	hand := func(s string) int {
+		h, err := ParseStartingHand(s)
+		require.NoError(t, err)
+		return h.Index()
+	}
+	for _, s := range []string{"AA", "AKo", "22", "K2s"} {
+		assert.Equal(t, 1.0, math.Round(push[hand(s)]), s)
+	}
+	assert.Equal(t, 0.0, math.Round(call[hand("72o")]))
+	assert.Greater(t, chart.Gains[SpotBigBlindCall][hand("AA")], 0.0)
+
+	// 筹码越深，全下的范围越窄
+	deep, err := SolvePushFold(PushFoldOptions{Players: 2, Stack: 200, SmallBlind: 5, Trials: 40, Iterations: 100, Seed: 1})
+	require.NoError(t, err)
+	assert.Less(t, deep.Ranges[SpotSmallBlindPush].Percent(), push.Percent())
+	assert.Equal(t, 0.0, math.Round(deep.Ranges[SpotSmallBlindPush][hand("72o")]))
+
+	// 求解条件取整到大盲注，深度不超过 MaxPushFoldStack
+	opts, err := NormalizePushFold(2, 1234, 100, 10)
+	require.NoError(t, err)
+	assert.Equal(t, PushFoldOptions{Players: 2, Stack: 120, SmallBlind: 5, Ante: 1, Trials: botPushFoldTrials, Iterations: botPushFoldIterations}, opts)
+	opts, err = NormalizePushFold(2, 1_000_000, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, MaxPushFoldStack*10, opts.Stack)
+	_, err = NormalizePushFold(2, 100, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestSpotOf(t *testing.T) {
+	view := func(seat int, actions ...poker.ActionView) *poker.View {
+		v := &poker.View{Stage: poker.GameStagePreflop, Seat: seat, BigBlind: 10, Actions: actions}
+		for i, pos := range poker.Positions(3) {
+			v.Players = append(v.Players, poker.PlayerView{Seat: i, Position: pos, Chips: 100, Status: poker.PlayerActive})
+		}
+		return v
+	}
+	shove := func(seat int) poker.ActionView {
+		return poker.ActionView{Seat: seat, Stage: poker.GameStagePreflop, Type: poker.ActionRaise, Total: 100}
+	}
+	fold := func(seat int) poker.ActionView {
+		return poker.ActionView{Seat: seat, Stage: poker.GameStagePreflop, Type: poker.ActionFold}
+	}
+	call := func(seat int) poker.ActionView {
+		return poker.ActionView{Seat: seat, Stage: poker.GameStagePreflop, Type: poker.ActionCall}
+	}
+
+	// 庄家在 0 号座位，1 号是小盲注，2 号是大盲注
+	for _, c := range []struct {
+		view *poker.View
+		spot Spot
+		ok   bool
+	}{
+		{view(0), SpotButtonPush, true},
+		{view(1, fold(0)), SpotSmallBlindPush, true},
+		{view(1, shove(0)), SpotSmallBlindCall, true},
+		{view(2, fold(0), shove(1)), SpotBigBlindCall, true},
+		{view(2, shove(0), fold(1)), SpotBigBlindCallBTN, true},
+		{view(2, shove(0), call(1)), SpotBigBlindOvercall, true},
+		{view(1, call(0)), "", false},
+		{view(1, poker.ActionView{Seat: 0, Stage: poker.GameStagePreflop, Type: poker.ActionRaise, Total: 30}), "", false},
+	} {
+		spot, stack, ok := SpotOf(c.view)
+		assert.Equal(t, c.ok, ok)
+		assert.Equal(t, c.spot, spot)
+		if ok {
+			assert.Equal(t, 100, stack)
+		}
+	}
+
+	// 单挑时庄家即小盲注
+	v := view(0)
+	v.Players = v.Players[:2]
+	v.Players[1].Position = poker.PositionBigBlind
+	spot, _, ok := SpotOf(v)
+	assert.True(t, ok)
+	assert.Equal(t, SpotSmallBlindPush, spot)
+}
+
+func TestAdaptive(t *testing.T) {
+	assert.Equal(t, 1.0, TopRange(0.5)[strengthOrder[0]])
+	wide, narrow := 0, 0
+	for i := 0; i < NumStartingHands; i++ {
+		wide += int(TopRange(50)[i])
+		narrow += int(TopRange(5)[i])
+	}
+	assert.Greater(t, wide, narrow)
+
+	// 加注者每条街都先加注，跟注站总是跟注，紧手玩家不能过牌就弃牌
+	g := poker.NewGame()
+	tracker := stats.NewTracker()
+	g.Listeners = append(g.Listeners, tracker)
+	s := g.Table.Seats
+	for _, name := range []string{"raiser", "station", "nit"} {
+		require.NoError(t, g.TakeSeat(s.Player.Id, name, 100000, true))
+		s = s.Next()
+	}
+	for hand := 0; hand < minStatsHands; hand++ {
+		require.NoError(t, g.StartHand())
+		for g.IsPlayerStage() {
+			switch g.CurrentSeat.Player.Name {
+			case "raiser":
+				if g.Raise(60) == nil {
+					continue
+				}
+			case "nit":
+				if g.Check() != nil {
+					require.NoError(t, g.Fold())
+				}
+				continue
+			}
+			if g.Check() != nil {
+				require.NoError(t, g.Call())
+			}
+		}
+	}
+
+	h, err := New("adaptive", 1)
+	require.NoError(t, err)
+	h.Stats = tracker
+	against := func(name string) *poker.View {
+		return &poker.View{Seat: 0, Players: []poker.PlayerView{
+			{Seat: 0, Name: "me", Status: poker.PlayerActive},
+			{Seat: 1, Name: name, Status: poker.PlayerActive},
+		}}
+	}
+	assert.Less(t, h.bluff(against("station")), h.Config.Bluff)
+	assert.Greater(t, h.steal(against("nit")), h.Config.Bluff)
+	assert.Equal(t, h.Config.Bluff, h.bluff(against("stranger")))
+
+	// 没有打开 Adapt 时不使用统计
+	h.Config.Adapt = false
+	assert.Equal(t, h.Config.Bluff, h.steal(against("nit")))
+}
@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"math"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Position 玩家在一手牌中的位置
+type Position int
+
+const (
+	PositionEarly  Position = iota // 前位
+	PositionMiddle                 // 中位
+	PositionLate                   // 后位，即庄家和庄家右手边的玩家
+	PositionBlinds                 // 盲注位
+)
+
+// String 返回位置的英文名称
+func (p Position) String() string {
+	return [...]string{"early", "middle", "late", "blinds"}[p]
+}
+
+// ChartEntry 某个位置上起手牌的入池标准，分数使用 Chen 公式计算
+type ChartEntry struct {
+	Raise float64 // 达到此分数时加注入池
+	Call  float64 // 达到此分数时跟注入池
+}
+
+// Chart 按位置划分的翻牌前起手牌表，位置越靠后入池的标准越宽
+var Chart = map[Position]ChartEntry{
+	PositionEarly:  {Raise: 9, Call: 8},
+	PositionMiddle: {Raise: 8, Call: 7},
+	PositionLate:   {Raise: 7, Call: 5},
+	PositionBlinds: {Raise: 8, Call: 6},
+}
+
+// Strength 使用 Chen 公式计算起手牌的分数，AA 为 20 分，最差的起手牌约为 -1 分
+func Strength(a, b poker.Card) float64 {
+	high, low := a.Rank, b.Rank
+	if low > high {
+		high, low = low, high
+	}
+
+	score := cardScore(high)
+	if high == low {
+		return math.Max(score*2, 5)
+	}
+	if a.Suit == b.Suit {
+		score += 2
+	}
+
+	switch gap := int(high-low) - 1; {
+	case gap == 1:
+		score--
+	case gap == 2:
+		score -= 2
+	case gap == 3:
+		score -= 4
+	case gap >= 4:
+		score -= 5
+	}
+	// 两张小于 Q 的连牌或隔一张的牌更容易组成顺子
+	if high-low <= 2 && high < poker.Queen {
+		score++
+	}
+	return math.Ceil(score)
+}
+
+// cardScore Chen 公式中单张牌的分数
+func cardScore(r poker.CardRank) float64 {
+	switch r {
+	case poker.Ace:
+		return 10
+	case poker.King:
+		return 8
+	case poker.Queen:
+		return 7
+	case poker.Jack:
+		return 6
+	}
+	return float64(r+2) / 2
+}
+
+// PositionOf 根据玩家视角下的牌局确定玩家的位置，单挑时庄家算作后位
+func PositionOf(v *poker.View) Position {
+	for _, p := range v.Players {
+		if p.Seat != v.Seat {
+			continue
+		}
+		switch p.Position {
+		case poker.PositionButton, poker.PositionCO:
+			return PositionLate
+		case poker.PositionSmallBlind, poker.PositionBigBlind:
+			return PositionBlinds
+		case poker.PositionMP, poker.PositionHJ:
+			return PositionMiddle
+		}
+	}
+	return PositionEarly
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// maxRangeTries 为对手抽取符合范围的底牌时最多尝试的次数，超过后接受任意底牌
+const maxRangeTries = 50
+
+// Equity 使用蒙特卡洛模拟估算底牌对抗各名对手范围的胜率，平分的奖池按人数折算。
+// ctx 结束时提前停止，按已经完成的模拟次数计算
+func Equity(ctx context.Context, hole [2]poker.Card, board []poker.Card, opponents []Range, trials int, r *rand.Rand) float64 {
+	used := make(map[poker.Card]bool)
+	for _, c := range append(hole[:], board...) {
+		used[c] = true
+	}
+	deck := make([]poker.Card, 0, poker.DeckSize)
+	for s := poker.Clubs; s <= poker.Spades; s++ {
+		for rank := poker.Two; rank <= poker.Ace; rank++ {
+			if c := (poker.Card{Rank: rank, Suit: s}); !used[c] {
+				deck = append(deck, c)
+			}
+		}
+	}
+
+	won, done := 0.0, 0
+	cards := make([]poker.Card, 7)
+	for ; done < trials; done++ {
+		if done%64 == 0 && ctx.Err() != nil {
+			break
+		}
+
+		// 打乱剩余的牌，依次为对手抽取底牌，然后补齐公共牌
+		r.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+		next := 0
+		hands := make([][2]poker.Card, len(opponents))
+		for i, rng := range opponents {
+			hands[i] = drawRange(deck, &next, rng, r)
+		}
+		full := append(append(make([]poker.Card, 0, 5), board...), deck[next:next+5-len(board)]...)
+
+		copy(cards, full)
+		cards[5], cards[6] = hole[0], hole[1]
+		mine := poker.Evaluate(cards...)
+
+		best, ties := true, 1
+		for _, h := range hands {
+			cards[5], cards[6] = h[0], h[1]
+			switch v := poker.Evaluate(cards...); {
+			case v > mine:
+				best = false
+			case v == mine:
+				ties++
+			}
+			if !best {
+				break
+			}
+		}
+		if best {
+			won += 1 / float64(ties)
+		}
+	}
+
+	if done == 0 {
+		return 0
+	}
+	return won / float64(done)
+}
+
+// drawRange 从打乱的牌堆中为对手抽取一手符合范围的底牌，抽到的两张牌交换到 next 的位置上
+func drawRange(deck []poker.Card, next *int, rng Range, r *rand.Rand) [2]poker.Card {
+	n := *next
+	a, b := n, n+1
+	for try := 0; try < maxRangeTries; try++ {
+		i, j := n+r.Intn(len(deck)-n), n+r.Intn(len(deck)-n)
+		if i != j && rng.Contains(deck[i], deck[j]) {
+			a, b = i, j
+			break
+		}
+	}
+
+	deck[n], deck[a] = deck[a], deck[n]
+	if b == n {
+		b = a
+	}
+	deck[n+1], deck[b] = deck[b], deck[n+1]
+	*next = n + 2
+	return [2]poker.Card{deck[n], deck[n+1]}
+}
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// NumStartingHands 不考虑具体花色时起手牌的种类数：13 种对子、78 种同花和 78 种不同花
+const NumStartingHands = 169
+
+// rankChars 起手牌记法中使用的点数字符
+const rankChars = "23456789TJQKA"
+
+// StartingHand 不考虑具体花色的起手牌，如 AKs、T9o、77
+type StartingHand struct {
+	High   poker.CardRank // 较大的点数
+	Low    poker.CardRank // 较小的点数，对子时与较大的点数相同
+	Suited bool           // 是否同花
+}
+
+// StartingHandOf 获取两张底牌对应的起手牌
+func StartingHandOf(a, b poker.Card) StartingHand {
+	high, low := a.Rank, b.Rank
+	if low > high {
+		high, low = low, high
+	}
+	return StartingHand{High: high, Low: low, Suited: high != low && a.Suit == b.Suit}
+}
+
+// StartingHandAt 获取编号对应的起手牌，编号从 0 到 168
+func StartingHandAt(i int) StartingHand {
+	row, col := poker.CardRank(i/13), poker.CardRank(i%13)
+	switch {
+	case row > col:
+		return StartingHand{High: row, Low: col, Suited: true}
+	case row < col:
+		return StartingHand{High: col, Low: row}
+	}
+	return StartingHand{High: row, Low: row}
+}
+
+// ParseStartingHand 解析 AKs、T9o、77 这样的起手牌记法
+func ParseStartingHand(s string) (StartingHand, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 || len(s) > 3 {
+		return StartingHand{}, fmt.Errorf("invalid starting hand %q", s)
+	}
+	high, low := strings.IndexByte(rankChars, s[0]), strings.IndexByte(rankChars, s[1])
+	if high < 0 || low < 0 {
+		return StartingHand{}, fmt.Errorf("invalid starting hand %q", s)
+	}
+	if low > high {
+		high, low = low, high
+	}
+
+	h := StartingHand{High: poker.CardRank(high), Low: poker.CardRank(low)}
+	switch {
+	case len(s) == 2 && high == low:
+	case len(s) == 3 && high != low && s[2] == 'S':
+		h.Suited = true
+	case len(s) == 3 && high != low && s[2] == 'O':
+	default:
+		return StartingHand{}, fmt.Errorf("invalid starting hand %q", s)
+	}
+	return h, nil
+}
+
+// Index 获取起手牌的编号。
+// 编号按 13x13 的表格排列，对子在对角线上，同花在对角线下方，不同花在对角线上方
+func (h StartingHand) Index() int {
+	if h.Suited || h.High == h.Low {
+		return int(h.High)*13 + int(h.Low)
+	}
+	return int(h.Low)*13 + int(h.High)
+}
+
+// String 返回起手牌的记法
+func (h StartingHand) String() string {
+	s := string(rankChars[h.High]) + string(rankChars[h.Low])
+	switch {
+	case h.High == h.Low:
+		return s
+	case h.Suited:
+		return s + "s"
+	}
+	return s + "o"
+}
+
+// Combos 获取起手牌所有具体花色的组合，对子 6 种，同花 4 种，不同花 12 种
+func (h StartingHand) Combos() [][2]poker.Card {
+	combos := make([][2]poker.Card, 0, 12)
+	for s1 := poker.Clubs; s1 <= poker.Spades; s1++ {
+		for s2 := poker.Clubs; s2 <= poker.Spades; s2++ {
+			if h.High == h.Low && s2 <= s1 || h.Suited != (s1 == s2) {
+				continue
+			}
+			combos = append(combos, [2]poker.Card{{Rank: h.High, Suit: s1}, {Rank: h.Low, Suit: s2}})
+		}
+	}
+	return combos
+}
+
+// Range 对手可能持有的起手牌范围
+type Range interface {
+	Contains(a, b poker.Card) bool
+}
+
+// ChenRange 用 Chen 公式分数的下限表示的范围，0 及以下表示任意两张牌
+type ChenRange float64
+
+// Contains 起手牌是否在范围之内
+func (r ChenRange) Contains(a, b poker.Card) bool {
+	return r <= 0 || Strength(a, b) >= float64(r)
+}
+
+// HandRange 按起手牌编号记录每种起手牌进入范围的频率，0 到 1
+type HandRange [NumStartingHands]float64
+
+// Contains 起手牌是否在范围之内，频率不低于一半即视为在范围之内
+func (r *HandRange) Contains(a, b poker.Card) bool {
+	return r[StartingHandOf(a, b).Index()] >= 0.5
+}
+
+// Percent 按组合数计算范围占所有起手牌的百分比
+func (r *HandRange) Percent() float64 {
+	total := 0.0
+	for i, f := range r {
+		total += f * float64(len(StartingHandAt(i).Combos()))
+	}
+	return total / 1326 * 100
+}
+
+// Hands 获取范围之内的起手牌记法，按编号从大到小排列
+func (r *HandRange) Hands() []string {
+	hands := make([]string, 0)
+	for i := NumStartingHands - 1; i >= 0; i-- {
+		if r[i] >= 0.5 {
+			hands = append(hands, StartingHandAt(i).String())
+		}
+	}
+	return hands
+}
@@ -0,0 +1,259 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/lllllan02/pocker/stats"
+)
+
+// Config 启发式机器人的风格
+type Config struct {
+	Name       string  `json:"name"`        // 难度名称
+	Looseness  float64 `json:"looseness"`   // 放宽起手牌表的分数，越大入池的牌越多
+	Aggression float64 `json:"aggression"`  // 牌力足够时选择加注而不是跟注的概率，0 到 1
+	Bluff      float64 `json:"bluff"`       // 牌力不足时诈唬的概率，0 到 1
+	Trials     int     `json:"trials"`      // 估算胜率时的模拟次数
+	ReadRanges bool    `json:"read_ranges"` // 是否根据对手翻牌前的动作缩小对手的范围
+	PushFold   int     `json:"push_fold"`   // 有效筹码不超过多少个大盲注时按全下或弃牌的均衡行动，为 0 时不使用
+	Adapt      bool    `json:"adapt"`       // 是否根据牌桌共享的统计估算对手的范围，并调整诈唬和偷盲的频率
+}
+
+const (
+	botPushFoldTrials     = 100 // 机器人求解全下或弃牌均衡时估算胜率的模拟次数
+	botPushFoldIterations = 100 // 机器人求解全下或弃牌均衡时的迭代次数
+)
+
+// Presets 可以在补充座位时选择的难度
+var Presets = map[string]Config{
+	"easy":     {Name: "easy", Looseness: 2, Aggression: 0.2, Bluff: 0.02, Trials: 200},
+	"medium":   {Name: "medium", Looseness: 1, Aggression: 0.5, Bluff: 0.08, Trials: 500, ReadRanges: true, PushFold: 8},
+	"hard":     {Name: "hard", Aggression: 0.7, Bluff: 0.12, Trials: 1000, ReadRanges: true, PushFold: 12},
+	"adaptive": {Name: "adaptive", Aggression: 0.7, Bluff: 0.12, Trials: 1000, ReadRanges: true, PushFold: 12, Adapt: true},
+}
+
+// Levels 获取所有难度的名称，按字母顺序排列
+func Levels() []string {
+	levels := make([]string, 0, len(Presets))
+	for name := range Presets {
+		levels = append(levels, name)
+	}
+	sort.Strings(levels)
+	return levels
+}
+
+// Heuristic 基于胜率和底池赔率的启发式机器人。
+// 翻牌前按位置查起手牌表，筹码较短时改用全下或弃牌的均衡，翻牌后用蒙特卡洛模拟估算对抗对手范围的胜率，与底池赔率比较后做出决定
+type Heuristic struct {
+	Config Config         // 机器人的风格
+	Wait   bool           // 是否等待全下或弃牌的均衡求解完成，需要复现结果的模拟中使用
+	Stats  *stats.Tracker // 牌桌共享的对手统计，Config.Adapt 为 true 时使用，为 nil 时不调整
+
+	mu   sync.Mutex // 超时的决定可能仍在后台运行，随机数生成器需要加锁
+	rand *rand.Rand // 随机数生成器
+}
+
+// New 按难度名称创建启发式机器人
+func New(level string, seed int64) (*Heuristic, error) {
+	cfg, ok := Presets[level]
+	if !ok {
+		return nil, fmt.Errorf("unknown bot level %q, expected one of %v", level, Levels())
+	}
+	return NewHeuristic(cfg, seed), nil
+}
+
+// NewHeuristic 按指定的风格创建启发式机器人
+func NewHeuristic(cfg Config, seed int64) *Heuristic {
+	return &Heuristic{Config: cfg, rand: rand.New(rand.NewSource(seed))}
+}
+
+// Decide 根据玩家视角下的牌局做出决定
+func (h *Heuristic) Decide(ctx context.Context, v *poker.View) (poker.Decision, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(v.Legal) == 0 {
+		return poker.Decision{}, fmt.Errorf("it is not the bot's turn")
+	}
+	if v.Stage == poker.GameStagePreflop {
+		if d, ok := h.pushFold(v); ok {
+			return d, nil
+		}
+		return h.preflop(v), nil
+	}
+	return h.postflop(ctx, v), nil
+}
+
+// preflop 按位置查起手牌表做出翻牌前的决定
+func (h *Heuristic) preflop(v *poker.View) poker.Decision {
+	score := Strength(v.HoleCards[0], v.HoleCards[1]) + h.Config.Looseness
+	entry := Chart[PositionOf(v)]
+	raised := v.CallAmount > v.BigBlind
+
+	switch {
+	// 面对加注时只用强牌再加注
+	case raised && score >= entry.Raise+2:
+		return h.raise(v, v.CallAmount*3)
+	case raised && score >= entry.Raise:
+		return h.call(v)
+
+	// 无人加注时按表加注或跟注入池，偶尔在后位偷盲
+	case !raised && score >= entry.Raise:
+		return h.raise(v, v.BigBlind*3+limpers(v)*v.BigBlind)
+	case !raised && score >= entry.Call:
+		return h.call(v)
+	case !raised && PositionOf(v) == PositionLate && h.rand.Float64() < h.steal(v):
+		return h.raise(v, v.BigBlind*3)
+	}
+	return h.fold(v)
+}
+
+// pushFold 有效筹码足够短且处于全下或弃牌的局面时，按均衡的频率全下、跟注或弃牌。
+// 有效筹码按大盲注取整后在后台求解，不等待时求解完成之前仍然按起手牌表行动
+func (h *Heuristic) pushFold(v *poker.View) (poker.Decision, bool) {
+	spot, stack, ok := SpotOf(v)
+	if !ok || h.Config.PushFold <= 0 || v.BigBlind <= 0 || stack > h.Config.PushFold*v.BigBlind {
+		return poker.Decision{}, false
+	}
+
+	players := 0
+	for _, p := range v.Players {
+		if p.Status == poker.PlayerActive {
+			players++
+		}
+	}
+	opts, err := NormalizePushFold(players, stack, v.BigBlind, v.Ante)
+	if err != nil {
+		return poker.Decision{}, false
+	}
+	chart, ok := SolvedPushFold(opts)
+	if !ok && h.Wait {
+		chart, err = CachedPushFold(opts)
+		ok = err == nil
+	}
+	if !ok {
+		return poker.Decision{}, false
+	}
+
+	if h.rand.Float64() >= chart.Frequency(spot, v.HoleCards[0], v.HoleCards[1]) {
+		return h.fold(v), true
+	}
+	if spot == SpotButtonPush || spot == SpotSmallBlindPush {
+		return h.raise(v, stack), true
+	}
+	return h.call(v), true
+}
+
+// postflop 比较胜率和底池赔率做出翻牌后的决定
+func (h *Heuristic) postflop(ctx context.Context, v *poker.View) poker.Decision {
+	ranges := h.ranges(v)
+	equity := Equity(ctx, v.HoleCards, v.Board, ranges, h.Config.Trials, h.rand)
+	me := v.Players[0]
+	for _, p := range v.Players {
+		if p.Seat == v.Seat {
+			me = p
+		}
+	}
+	toCall := v.CallAmount - me.Bet
+	odds := float64(toCall) / float64(v.Pot+toCall)
+
+	// 价值下注的胜率要求随对手人数降低，越激进要求越低
+	value := 0.5 + 0.3/float64(max(len(ranges), 1)) - h.Config.Aggression*0.1
+
+	if toCall == 0 {
+		switch {
+		case equity >= value && h.rand.Float64() < 0.5+h.Config.Aggression/2:
+			return h.raise(v, v.Pot*2/3)
+		case h.rand.Float64() < h.bluff(v):
+			return h.raise(v, v.Pot/2)
+		}
+		return h.call(v)
+	}
+
+	switch {
+	case equity >= value+0.1 && h.rand.Float64() < h.Config.Aggression:
+		return h.raise(v, v.CallAmount*2+v.Pot/2)
+	case equity >= odds:
+		return h.call(v)
+	case h.rand.Float64() < h.bluff(v)/2:
+		return h.raise(v, v.CallAmount*3)
+	}
+	return h.fold(v)
+}
+
+// ranges 估算每名未弃牌的对手的范围，翻牌前加注过的对手范围最窄，跟注过的其次
+func (h *Heuristic) ranges(v *poker.View) []Range {
+	ranges := make([]Range, 0)
+	for _, p := range v.Players {
+		if p.Seat == v.Seat || p.Folded || p.Status != poker.PlayerActive {
+			continue
+		}
+
+		if rng, ok := h.modelRange(v, p); ok {
+			ranges = append(ranges, rng)
+			continue
+		}
+
+		rng := ChenRange(0)
+		if h.Config.ReadRanges {
+			for _, a := range v.Actions {
+				if a.Seat != p.Seat || a.Stage != poker.GameStagePreflop {
+					continue
+				}
+				switch a.Type {
+				case poker.ActionBet, poker.ActionRaise:
+					rng = max(rng, 8)
+				case poker.ActionCall:
+					rng = max(rng, 5)
+				}
+			}
+		}
+		ranges = append(ranges, rng)
+	}
+	return ranges
+}
+
+// limpers 翻牌前跟注入池的玩家人数
+func limpers(v *poker.View) int {
+	n := 0
+	for _, a := range v.Actions {
+		if a.Stage == poker.GameStagePreflop && a.Type == poker.ActionCall {
+			n++
+		}
+	}
+	return n
+}
+
+// raise 下注或加注到 amount，超出合法范围时取最近的合法金额，不能加注时跟注
+func (h *Heuristic) raise(v *poker.View, amount int) poker.Decision {
+	for _, a := range v.Legal {
+		if a.Type == poker.ActionBet || a.Type == poker.ActionRaise {
+			return poker.Decision{Type: a.Type, Amount: min(max(amount, a.Min), a.Max)}
+		}
+	}
+	return h.call(v)
+}
+
+// call 跟注，无需跟注时过牌
+func (h *Heuristic) call(v *poker.View) poker.Decision {
+	for _, a := range v.Legal {
+		if a.Type == poker.ActionCall {
+			return poker.Decision{Type: poker.ActionCall}
+		}
+	}
+	return poker.Decision{Type: poker.ActionCheck}
+}
+
+// fold 弃牌，可以过牌时过牌
+func (h *Heuristic) fold(v *poker.View) poker.Decision {
+	for _, a := range v.Legal {
+		if a.Type == poker.ActionCheck {
+			return poker.Decision{Type: poker.ActionCheck}
+		}
+	}
+	return poker.Decision{Type: poker.ActionFold}
+}
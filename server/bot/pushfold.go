@@ -0,0 +1,525 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+const (
+	minThreeWayFrequency = 0.02 // 计算三人全下的期望时忽略的起手牌频率
+	maxPushFoldSolves    = 64   // 最多缓存的求解结果数，超过时丢弃最早的结果
+)
+
+// MaxPushFoldStack 可以求解全下或弃牌均衡的最大有效筹码，单位为大盲注，更深的筹码不再是全下或弃牌的局面
+const MaxPushFoldStack = 25
+
+// Spot 全下或弃牌时需要做决定的局面
+type Spot string
+
+const (
+	SpotButtonPush       Spot = "btn_push"    // 三人桌庄家首先行动，全下或弃牌
+	SpotSmallBlindPush   Spot = "sb_push"     // 前面的玩家都弃牌后小盲注全下或弃牌，单挑时即庄家全下
+	SpotSmallBlindCall   Spot = "sb_call"     // 小盲注跟注庄家的全下或弃牌
+	SpotBigBlindCall     Spot = "bb_call"     // 大盲注跟注小盲注的全下或弃牌
+	SpotBigBlindCallBTN  Spot = "bb_call_btn" // 小盲注弃牌后，大盲注跟注庄家的全下或弃牌
+	SpotBigBlindOvercall Spot = "bb_overcall" // 小盲注跟注后，大盲注跟注庄家的全下或弃牌
+)
+
+// spots 单挑和三人桌各自需要求解的局面
+var spots = map[int][]Spot{
+	2: {SpotSmallBlindPush, SpotBigBlindCall},
+	3: {SpotButtonPush, SpotSmallBlindPush, SpotSmallBlindCall, SpotBigBlindCall, SpotBigBlindCallBTN, SpotBigBlindOvercall},
+}
+
+// PushFoldOptions 全下或弃牌均衡的求解条件
+type PushFoldOptions struct {
+	Players    int   `json:"players"`     // 玩家人数，2 或 3
+	Stack      int   `json:"stack"`       // 有效筹码，包括盲注和前注
+	SmallBlind int   `json:"small_blind"` // 小盲注，大盲注为小盲注的两倍
+	Ante       int   `json:"ante"`        // 每名玩家的前注
+	Trials     int   `json:"trials"`      // 估算每两种起手牌之间胜率的模拟次数，为 0 时使用默认值
+	Iterations int   `json:"iterations"`  // 虚拟对局的迭代次数，为 0 时使用默认值
+	Seed       int64 `json:"seed"`        // 估算胜率时使用的随机种子
+}
+
+// PushFoldChart 全下或弃牌的纳什均衡，每个局面下每种起手牌全下或跟注的频率
+type PushFoldChart struct {
+	Options PushFoldOptions                     `json:"options"` // 求解条件
+	Ranges  map[Spot]*HandRange                 `json:"ranges"`  // 每个局面下全下或跟注的范围
+	Gains   map[Spot]*[NumStartingHands]float64 `json:"gains"`   // 全下或跟注比弃牌多赢的筹码期望，负数表示应该弃牌
+}
+
+// Frequency 获取两张底牌在某个局面下全下或跟注的频率
+func (c *PushFoldChart) Frequency(spot Spot, a, b poker.Card) float64 {
+	r, ok := c.Ranges[spot]
+	if !ok {
+		return 0
+	}
+	return r[StartingHandOf(a, b).Index()]
+}
+
+// SolvePushFold 使用虚拟对局求解单挑或三人桌全下或弃牌的纳什均衡。
+// 每轮迭代中，每个局面都对其他局面当前的平均策略做出最优反应，再并入自己的平均策略。
+// 三人桌计算期望时假设两名对手的起手牌相互独立，三人摊牌的胜率由两两之间的胜率近似
+func SolvePushFold(opts PushFoldOptions) (*PushFoldChart, error) {
+	if _, ok := spots[opts.Players]; !ok {
+		return nil, fmt.Errorf("push/fold can only be solved for 2 or 3 players, got %d", opts.Players)
+	}
+	if opts.SmallBlind <= 0 || opts.Ante < 0 {
+		return nil, fmt.Errorf("invalid blinds %d/%d ante %d", opts.SmallBlind, opts.SmallBlind*2, opts.Ante)
+	}
+	if opts.Stack <= opts.SmallBlind*2+opts.Ante {
+		return nil, fmt.Errorf("the stack (%d) must be bigger than the big blind and the ante", opts.Stack)
+	}
+	if opts.Trials <= 0 {
+		opts.Trials = 200
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = 200
+	}
+
+	m := preflopMatrixFor(opts.Trials, opts.Seed)
+	chart := &PushFoldChart{Options: opts, Ranges: make(map[Spot]*HandRange), Gains: make(map[Spot]*[NumStartingHands]float64)}
+	for _, spot := range spots[opts.Players] {
+		chart.Ranges[spot] = &HandRange{}
+		chart.Gains[spot] = &[NumStartingHands]float64{}
+	}
+
+	for k := 0; k < opts.Iterations; k++ {
+		gains := pushFoldGains(opts, m, chart.Ranges)
+		for spot, r := range chart.Ranges {
+			for i := range r {
+				gain := gains[spot][i]
+				if math.IsNaN(gain) {
+					continue
+				}
+				best := 0.0
+				if gain > 0 {
+					best = 1
+				}
+				r[i] += (best - r[i]) / float64(k+2)
+				chart.Gains[spot][i] = gain
+			}
+		}
+	}
+	return chart, nil
+}
+
+// pushFoldGains 在当前策略下，计算每个局面中每种起手牌全下或跟注比弃牌多赢的筹码期望。
+// 起手牌在该局面下不可能出现时为 NaN
+func pushFoldGains(opts PushFoldOptions, m *preflopMatrix, ranges map[Spot]*HandRange) map[Spot]*[NumStartingHands]float64 {
+	stack, ante := float64(opts.Stack), float64(opts.Ante)
+	sb, bb := float64(opts.SmallBlind), float64(opts.SmallBlind*2)
+	gains := make(map[Spot]*[NumStartingHands]float64)
+	for spot := range ranges {
+		gains[spot] = &[NumStartingHands]float64{}
+	}
+
+	// expect 按对手起手牌的条件概率和权重计算期望，权重之和为 0 时返回 NaN
+	expect := func(x int, weight func(y int) float64, value func(y int) float64) float64 {
+		total, sum := 0.0, 0.0
+		for y := 0; y < NumStartingHands; y++ {
+			if w := float64(m.prob[x][y]) * weight(y); w > 0 {
+				total += w
+				sum += w * value(y)
+			}
+		}
+		if total == 0 {
+			return math.NaN()
+		}
+		return sum / total
+	}
+	always := func(int) float64 { return 1 }
+
+	if opts.Players == 2 {
+		push, call := ranges[SpotSmallBlindPush], ranges[SpotBigBlindCall]
+		for x := 0; x < NumStartingHands; x++ {
+			gains[SpotSmallBlindPush][x] = expect(x, always, func(y int) float64 {
+				return (1-call[y])*(ante+bb) + call[y]*(m.equity(x, y)*2*stack-stack)
+			}) + ante + sb
+			gains[SpotBigBlindCall][x] = expect(x, func(y int) float64 { return push[y] }, func(y int) float64 {
+				return m.equity(x, y)*2*stack - stack
+			}) + ante + bb
+		}
+		return gains
+	}
+
+	btnPush, sbPush, sbCall := ranges[SpotButtonPush], ranges[SpotSmallBlindPush], ranges[SpotSmallBlindCall]
+	bbCall, bbCallBTN, bbOvercall := ranges[SpotBigBlindCall], ranges[SpotBigBlindCallBTN], ranges[SpotBigBlindOvercall]
+	for x := 0; x < NumStartingHands; x++ {
+		// 庄家全下：小盲注和大盲注依次跟注或弃牌
+		sbFolds := expect(x, always, func(y int) float64 { return 1 - sbCall[y] })
+		sbCalls := 1 - sbFolds
+		bbFolds := expect(x, always, func(z int) float64 { return 1 - bbCallBTN[z] })
+		vsBB := expect(x, always, func(z int) float64 { return bbCallBTN[z] * (m.equity(x, z)*(2*stack+ante+sb) - stack) })
+		vsSB := expect(x, always, func(y int) float64 { return sbCall[y] * (m.equity(x, y)*(2*stack+ante+bb) - stack) })
+		bbFoldsAfterCall := expect(x, always, func(z int) float64 { return 1 - bbOvercall[z] })
+		threeWay := m.threeWay(x, sbCall, bbOvercall, stack)
+		gains[SpotButtonPush][x] = sbFolds*bbFolds*(2*ante+sb+bb) + sbFolds*vsBB + vsSB*bbFoldsAfterCall + sbCalls*(1-bbFoldsAfterCall)*threeWay + ante
+
+		// 庄家弃牌后小盲注全下，大盲注跟注或弃牌
+		gains[SpotSmallBlindPush][x] = expect(x, always, func(z int) float64 {
+			return (1-bbCall[z])*(2*ante+bb) + bbCall[z]*(m.equity(x, z)*(2*stack+ante)-stack)
+		}) + ante + sb
+
+		// 小盲注跟注庄家的全下，大盲注跟注或弃牌
+		vsBTN := expect(x, func(y int) float64 { return btnPush[y] }, func(y int) float64 { return m.equity(x, y)*(2*stack+ante+bb) - stack })
+		if !math.IsNaN(vsBTN) {
+			vsBTN = vsBTN*bbFoldsAfterCall + (1-bbFoldsAfterCall)*m.threeWay(x, btnPush, bbOvercall, stack)
+		}
+		gains[SpotSmallBlindCall][x] = vsBTN + ante + sb
+
+		// 大盲注跟注小盲注或者庄家的全下
+		gains[SpotBigBlindCall][x] = expect(x, func(y int) float64 { return sbPush[y] }, func(y int) float64 {
+			return m.equity(x, y)*(2*stack+ante) - stack
+		}) + ante + bb
+		gains[SpotBigBlindCallBTN][x] = expect(x, func(y int) float64 { return btnPush[y] }, func(y int) float64 {
+			return m.equity(x, y)*(2*stack+ante+sb) - stack
+		}) + ante + bb
+		gains[SpotBigBlindOvercall][x] = m.threeWay(x, btnPush, sbCall, stack) + ante + bb
+	}
+	return gains
+}
+
+// preflopMatrix 任意两种起手牌之间翻牌前全下的胜率，以及一种起手牌已知时另一种起手牌出现的条件概率
+type preflopMatrix struct {
+	eq   [NumStartingHands][NumStartingHands]float32 // eq[x][y] 为 x 对抗 y 分得奖池的比例
+	prob [NumStartingHands][NumStartingHands]float32 // prob[x][y] 为持有 x 时对手持有 y 的概率
+
+	once   sync.Once                                                      // 三人全下的分配比例只在三人桌求解时计算
+	shares *[NumStartingHands][NumStartingHands][NumStartingHands]float32 // shares[x][y][z] 为 x 与 y、z 三人全下时 x 分得奖池的比例
+}
+
+// equity x 对抗 y 分得奖池的比例
+func (m *preflopMatrix) equity(x, y int) float64 {
+	return float64(m.eq[x][y])
+}
+
+// threeWay 持有 x 的玩家与按范围 ry、rz 入池的两名对手三人全下时，摊牌后净赢的筹码期望。
+// 两名对手的起手牌视为相互独立，频率低于 minThreeWayFrequency 的起手牌对结果影响很小，直接忽略
+func (m *preflopMatrix) threeWay(x int, ry, rz *HandRange, stack float64) float64 {
+	shares := m.threeWayShares()
+	total, sum := 0.0, 0.0
+	for y := 0; y < NumStartingHands; y++ {
+		if ry[y] < minThreeWayFrequency {
+			continue
+		}
+		wy := float64(m.prob[x][y]) * ry[y]
+		row := &shares[x][y]
+		for z := 0; z < NumStartingHands; z++ {
+			if rz[z] < minThreeWayFrequency {
+				continue
+			}
+			w := wy * float64(m.prob[x][z]) * rz[z]
+			total += w
+			sum += w * float64(row[z])
+		}
+	}
+	if total == 0 {
+		return -stack
+	}
+	return sum/total*3*stack - stack
+}
+
+// threeWayShares 获取三种起手牌全下时第一种起手牌分得奖池的比例，第一次使用时计算。
+// 三人的胜率按两两胜率的乘积归一化近似
+func (m *preflopMatrix) threeWayShares() *[NumStartingHands][NumStartingHands][NumStartingHands]float32 {
+	m.once.Do(func() {
+		m.shares = new([NumStartingHands][NumStartingHands][NumStartingHands]float32)
+		for x := 0; x < NumStartingHands; x++ {
+			for y := 0; y < NumStartingHands; y++ {
+				for z := 0; z < NumStartingHands; z++ {
+					px := m.eq[x][y] * m.eq[x][z]
+					py := m.eq[y][x] * m.eq[y][z]
+					pz := m.eq[z][x] * m.eq[z][y]
+					m.shares[x][y][z] = 1.0 / 3
+					if px+py+pz > 0 {
+						m.shares[x][y][z] = px / (px + py + pz)
+					}
+				}
+			}
+		}
+	})
+	return m.shares
+}
+
+// preflopMatrices 按模拟次数和随机种子缓存的胜率矩阵
+var preflopMatrices = struct {
+	sync.Mutex
+	m map[[2]int64]*preflopMatrix
+}{m: make(map[[2]int64]*preflopMatrix)}
+
+// preflopMatrixFor 获取缓存的胜率矩阵，没有时计算并缓存
+func preflopMatrixFor(trials int, seed int64) *preflopMatrix {
+	preflopMatrices.Lock()
+	defer preflopMatrices.Unlock()
+
+	key := [2]int64{int64(trials), seed}
+	if m, ok := preflopMatrices.m[key]; ok {
+		return m
+	}
+	m := newPreflopMatrix(trials, seed)
+	preflopMatrices.m[key] = m
+	return m
+}
+
+// newPreflopMatrix 精确计算起手牌之间的条件概率，用蒙特卡洛模拟估算胜率
+func newPreflopMatrix(trials int, seed int64) *preflopMatrix {
+	m := &preflopMatrix{}
+	combos := make([][][2]poker.Card, NumStartingHands)
+	for i := range combos {
+		combos[i] = StartingHandAt(i).Combos()
+	}
+
+	// 持有 x 的某一种组合时，对手还有 1225 种可能的底牌
+	for x := range combos {
+		for y := range combos {
+			compatible := 0
+			for _, a := range combos[x] {
+				for _, b := range combos[y] {
+					if a[0] != b[0] && a[0] != b[1] && a[1] != b[0] && a[1] != b[1] {
+						compatible++
+					}
+				}
+			}
+			m.prob[x][y] = float32(compatible) / float32(len(combos[x])*1225)
+		}
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	deck := poker.NewDeck().Cards
+	rest := make([]poker.Card, 0, len(deck))
+	cards := make([]poker.Card, 7)
+	for x := 0; x < NumStartingHands; x++ {
+		for y := x; y < NumStartingHands; y++ {
+			if m.prob[x][y] == 0 {
+				continue
+			}
+
+			won := 0.0
+			for t := 0; t < trials; t++ {
+				a := combos[x][r.Intn(len(combos[x]))]
+				b := combos[y][r.Intn(len(combos[y]))]
+				for a[0] == b[0] || a[0] == b[1] || a[1] == b[0] || a[1] == b[1] {
+					b = combos[y][r.Intn(len(combos[y]))]
+				}
+
+				// 从剩下的牌中随机发出五张公共牌
+				rest = rest[:0]
+				for _, c := range deck {
+					if c != a[0] && c != a[1] && c != b[0] && c != b[1] {
+						rest = append(rest, c)
+					}
+				}
+				for i := 0; i < 5; i++ {
+					j := i + r.Intn(len(rest)-i)
+					rest[i], rest[j] = rest[j], rest[i]
+				}
+
+				copy(cards[2:], rest[:5])
+				cards[0], cards[1] = a[0], a[1]
+				va := poker.Evaluate(cards...)
+				cards[0], cards[1] = b[0], b[1]
+				won += float64(share(va, poker.Evaluate(cards...)))
+			}
+			m.eq[x][y] = float32(won / float64(trials))
+			m.eq[y][x] = 1 - m.eq[x][y]
+		}
+	}
+	return m
+}
+
+// share 两人摊牌时前一名玩家分得奖池的比例
+func share(a, b poker.HandValue) float32 {
+	switch {
+	case a > b:
+		return 1
+	case a == b:
+		return 0.5
+	}
+	return 0
+}
+
+// pushFoldSolve 一次求解的结果，done 关闭后 chart 和 err 可用
+type pushFoldSolve struct {
+	done  chan struct{}
+	chart *PushFoldChart
+	err   error
+}
+
+// pushFoldCache 按求解条件缓存的全下或弃牌均衡，同样的条件只求解一次。
+// 最多缓存 maxPushFoldSolves 个结果，按开始求解的顺序丢弃最早的结果
+var pushFoldCache = struct {
+	sync.Mutex
+	solves map[PushFoldOptions]*pushFoldSolve
+	order  []PushFoldOptions // 按开始求解的顺序排列的求解条件
+}{solves: make(map[PushFoldOptions]*pushFoldSolve)}
+
+// NormalizePushFold 以小盲注 5 为单位换算求解条件，有效筹码取整到大盲注并限制在 MaxPushFoldStack 以内，
+// 相同深度的局面可以共用缓存
+func NormalizePushFold(players, stack, bigBlind, ante int) (PushFoldOptions, error) {
+	if bigBlind <= 0 || ante < 0 {
+		return PushFoldOptions{}, fmt.Errorf("invalid big blind %d ante %d", bigBlind, ante)
+	}
+	bbs := min(max((stack+bigBlind/2)/bigBlind, 2), MaxPushFoldStack)
+	return PushFoldOptions{
+		Players:    players,
+		Stack:      bbs * 10,
+		SmallBlind: 5,
+		Ante:       ante * 10 / bigBlind,
+		Trials:     botPushFoldTrials,
+		Iterations: botPushFoldIterations,
+	}, nil
+}
+
+// pushFoldSolveFor 获取求解条件对应的结果，还没有开始求解时在后台开始求解
+func pushFoldSolveFor(opts PushFoldOptions) *pushFoldSolve {
+	pushFoldCache.Lock()
+	defer pushFoldCache.Unlock()
+
+	if s, ok := pushFoldCache.solves[opts]; ok {
+		return s
+	}
+	// 丢弃的求解仍会在后台完成，已经在等待的调用不受影响
+	if len(pushFoldCache.order) >= maxPushFoldSolves {
+		delete(pushFoldCache.solves, pushFoldCache.order[0])
+		pushFoldCache.order = pushFoldCache.order[1:]
+	}
+	s := &pushFoldSolve{done: make(chan struct{})}
+	pushFoldCache.solves[opts] = s
+	pushFoldCache.order = append(pushFoldCache.order, opts)
+	go func() {
+		defer close(s.done)
+		s.chart, s.err = SolvePushFold(opts)
+	}()
+	return s
+}
+
+// CachedPushFold 获取缓存的全下或弃牌均衡，没有时求解并缓存
+func CachedPushFold(opts PushFoldOptions) (*PushFoldChart, error) {
+	s := pushFoldSolveFor(opts)
+	<-s.done
+	return s.chart, s.err
+}
+
+// SolvedPushFold 获取已经求解完成的全下或弃牌均衡，没有时在后台开始求解并返回 false，不会阻塞
+func SolvedPushFold(opts PushFoldOptions) (*PushFoldChart, bool) {
+	s := pushFoldSolveFor(opts)
+	select {
+	case <-s.done:
+		return s.chart, s.err == nil
+	default:
+		return nil, false
+	}
+}
+
+// SpotOf 根据玩家视角下的牌局确定全下或弃牌的局面和有效筹码。
+// 只有两到三名玩家参与、翻牌前没有人平跟且之前的加注都是全下时才是全下或弃牌的局面
+func SpotOf(v *poker.View) (Spot, int, bool) {
+	if v.Stage != poker.GameStagePreflop {
+		return "", 0, false
+	}
+
+	// 按位置确定参与这手牌的玩家的角色，单挑时庄家即小盲注
+	order := make([]poker.PlayerView, 0)
+	for _, p := range v.Players {
+		if p.Position != poker.PositionNone {
+			order = append(order, p)
+		}
+	}
+	n := len(order)
+	if n < 2 || n > 3 {
+		return "", 0, false
+	}
+
+	// 有效筹码为自己与其他玩家中最大的筹码两者的较小值
+	var me poker.PlayerView
+	others := 0
+	for _, p := range order {
+		if p.Seat == v.Seat {
+			me = p
+		} else {
+			others = max(others, p.Chips+p.InPot)
+		}
+	}
+	stack := min(me.Chips+me.InPot, others)
+
+	// 按座位记录翻牌前的动作，加注必须是全下或者覆盖自己的筹码
+	acted := make(map[int]poker.ActionType)
+	for _, a := range v.Actions {
+		if a.Stage != poker.GameStagePreflop {
+			continue
+		}
+		switch a.Type {
+		case poker.ActionBet, poker.ActionRaise:
+			if a.Total < stack && !allIn(order, a.Seat) {
+				return "", 0, false
+			}
+		case poker.ActionCall:
+			if len(acted) == 0 {
+				return "", 0, false
+			}
+		}
+		if _, ok := acted[a.Seat]; ok {
+			return "", 0, false
+		}
+		acted[a.Seat] = a.Type
+	}
+
+	roles := map[int]poker.Position{}
+	for _, p := range order {
+		roles[p.Seat] = p.Position
+		if n == 2 && p.Position == poker.PositionButton {
+			roles[p.Seat] = poker.PositionSmallBlind
+		}
+	}
+	raised := func(role poker.Position) bool {
+		for seat, t := range acted {
+			if roles[seat] == role && (t == poker.ActionBet || t == poker.ActionRaise) {
+				return true
+			}
+		}
+		return false
+	}
+	called := func(role poker.Position) bool {
+		for seat, t := range acted {
+			if roles[seat] == role && t == poker.ActionCall {
+				return true
+			}
+		}
+		return false
+	}
+
+	var spot Spot
+	switch role := roles[v.Seat]; {
+	case role == poker.PositionButton && len(acted) == 0:
+		spot = SpotButtonPush
+	case role == poker.PositionSmallBlind && len(acted) == 0 && n == 2, role == poker.PositionSmallBlind && len(acted) == 1 && !raised(poker.PositionButton) && !called(poker.PositionButton):
+		spot = SpotSmallBlindPush
+	case role == poker.PositionSmallBlind && len(acted) == 1 && raised(poker.PositionButton):
+		spot = SpotSmallBlindCall
+	case role == poker.PositionBigBlind && raised(poker.PositionSmallBlind) && !raised(poker.PositionButton) && !called(poker.PositionButton):
+		spot = SpotBigBlindCall
+	case role == poker.PositionBigBlind && raised(poker.PositionButton) && called(poker.PositionSmallBlind):
+		spot = SpotBigBlindOvercall
+	case role == poker.PositionBigBlind && raised(poker.PositionButton) && !called(poker.PositionSmallBlind) && !raised(poker.PositionSmallBlind):
+		spot = SpotBigBlindCallBTN
+	default:
+		return "", 0, false
+	}
+	return spot, stack, true
+}
+
+// allIn 座位上的玩家是否已经全下
+func allIn(players []poker.PlayerView, seat int) bool {
+	for _, p := range players {
+		if p.Seat == seat {
+			return p.Chips == 0
+		}
+	}
+	return false
+}
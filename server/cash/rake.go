@@ -0,0 +1,77 @@
+package cash
+
+import (
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// RakeEntry 一手牌的抽水记录
+type RakeEntry struct {
+	HandId  int            `json:"hand_id"` // 手牌编号
+	Total   int            `json:"total"`   // 抽水总额
+	Players map[string]int `json:"players"` // 按下注比例记到每名玩家名下的抽水，key 为玩家名称
+	Time    time.Time      `json:"time"`    // 一手牌结束的时间
+}
+
+// RakeLedger 抽水账目，监听牌局事件记录每手牌的抽水，用于返水统计
+type RakeLedger struct {
+	Entries []RakeEntry      // 按顺序记录的有抽水的手牌
+	Now     func() time.Time // 获取当前时间
+
+	current *RakeEntry // 正在结算的手牌
+}
+
+// NewRakeLedger 创建抽水账目
+func NewRakeLedger() *RakeLedger {
+	return &RakeLedger{Now: time.Now}
+}
+
+// OnEvent 累计奖池分配时的抽水，一手牌结束时记入账目
+func (l *RakeLedger) OnEvent(g *poker.Game, e poker.Event) {
+	switch e := e.(type) {
+	case poker.PotAwarded:
+		if e.Rake == 0 {
+			return
+		}
+		if l.current == nil || l.current.HandId != g.HandId {
+			l.current = &RakeEntry{HandId: g.HandId, Players: make(map[string]int)}
+		}
+		l.current.Total += e.Rake
+		for _, share := range e.RakeFrom {
+			l.current.Players[g.Table.SeatAt(share.Seat).Player.Name] += share.Amount
+		}
+
+	case poker.HandEnded:
+		if l.current != nil {
+			l.current.Time = l.Now()
+			l.Entries = append(l.Entries, *l.current)
+			l.current = nil
+		}
+	}
+}
+
+// Total 获取抽水总额
+func (l *RakeLedger) Total() int {
+	total := 0
+	for _, e := range l.Entries {
+		total += e.Total
+	}
+	return total
+}
+
+// Rakeback 按返水比例计算每名玩家的返水，比例的单位为百分比，不足一个筹码的部分舍去
+func (l *RakeLedger) Rakeback(percent float64) map[string]int {
+	contributed := make(map[string]int)
+	for _, e := range l.Entries {
+		for name, rake := range e.Players {
+			contributed[name] += rake
+		}
+	}
+
+	rakeback := make(map[string]int, len(contributed))
+	for name, rake := range contributed {
+		rakeback[name] = int(float64(rake) * percent / 100)
+	}
+	return rakeback
+}
@@ -0,0 +1,54 @@
+package cash
+
+import (
+	"sort"
+	"time"
+)
+
+// State 现金桌在服务重启后需要恢复的状态，可以序列化为 JSON。
+// 牌局本身由 poker.Snapshot 保存，这里只包括买入规则之外的账目和离桌记录
+type State struct {
+	Auto       map[string]AutoSettings `json:"auto"`       // 每个座位的自动买入设置，key 为座位上的玩家标识
+	Ledger     []Transaction           `json:"ledger"`     // 按时间顺序记录的筹码变动
+	Rake       []RakeEntry             `json:"rake"`       // 按顺序记录的有抽水的手牌
+	Departures []Departure             `json:"departures"` // 最近离桌的玩家，按玩家名称排列
+}
+
+// Departure 玩家离桌时的筹码和时间，用于防止老鼠洞
+type Departure struct {
+	Name  string    `json:"name"`  // 玩家名称
+	Chips int       `json:"chips"` // 离开时的筹码
+	Time  time.Time `json:"time"`  // 离开的时间
+}
+
+// State 获取现金桌当前的状态
+func (t *Table) State() *State {
+	s := &State{
+		Auto:       make(map[string]AutoSettings, len(t.Auto)),
+		Ledger:     append([]Transaction(nil), t.Ledger...),
+		Rake:       append([]RakeEntry(nil), t.Rake.Entries...),
+		Departures: make([]Departure, 0, len(t.departures)),
+	}
+	for id, auto := range t.Auto {
+		s.Auto[id] = auto
+	}
+	for name, d := range t.departures {
+		s.Departures = append(s.Departures, Departure{Name: name, Chips: d.chips, Time: d.at})
+	}
+	sort.Slice(s.Departures, func(i, j int) bool { return s.Departures[i].Name < s.Departures[j].Name })
+	return s
+}
+
+// Restore 恢复现金桌的状态，替换现有的账目和离桌记录
+func (t *Table) Restore(s *State) {
+	t.Auto = make(map[string]AutoSettings, len(s.Auto))
+	for id, auto := range s.Auto {
+		t.Auto[id] = auto
+	}
+	t.Ledger = append([]Transaction(nil), s.Ledger...)
+	t.Rake.Entries = append([]RakeEntry(nil), s.Rake...)
+	t.departures = make(map[string]departure, len(s.Departures))
+	for _, d := range s.Departures {
+		t.departures[d.Name] = departure{chips: d.Chips, at: d.Time}
+	}
+}
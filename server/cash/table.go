@@ -0,0 +1,256 @@
+// Package cash 实现现金桌的买入规则：按大盲注数限制买入范围、只能在两手牌之间补充筹码、
+// 离桌后短时间内回来必须带回离开时的筹码（防止“老鼠洞”），以及每个座位的自动重购和自动补码
+package cash
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// 默认的买入设置
+const (
+	defaultMinBuyIn      = 40        // 最少买入 40 个大盲注
+	defaultMaxBuyIn      = 100       // 最多买入 100 个大盲注
+	defaultRatholeWindow = time.Hour // 离桌后一小时内回来需要带回离开时的筹码
+)
+
+var (
+	ErrHandInProgress = errors.New("chips can only be added between hands") // 只能在两手牌之间补充筹码
+	ErrNotSeated      = errors.New("the player is not seated at the table") // 玩家不在牌桌上
+)
+
+// Config 现金桌设置
+type Config struct {
+	MinBuyIn      int             // 最少买入的大盲注数，为 0 时为 40
+	MaxBuyIn      int             // 最多买入的大盲注数，为 0 时为 100
+	RatholeWindow time.Duration   // 离桌后多长时间内回来需要带回离开时的筹码，为 0 时为一小时，为负数时不限制
+	Rake          *poker.RakeRule // 抽水规则，为 nil 时不抽水
+}
+
+// AutoSettings 座位的自动买入设置
+type AutoSettings struct {
+	Rebuy  bool `json:"rebuy"`  // 输光筹码后自动按 Amount 重新买入
+	TopUp  bool `json:"top_up"` // 筹码少于 Amount 时自动补充到 Amount
+	Amount int  `json:"amount"` // 自动买入或补充到的筹码数，为 0 时为最多买入
+}
+
+// TransactionType 筹码变动的类型
+type TransactionType string
+
+const (
+	TransactionBuyIn     TransactionType = "buy_in"      // 入座时买入
+	TransactionTopUp     TransactionType = "top_up"      // 补充筹码
+	TransactionAutoRebuy TransactionType = "auto_rebuy"  // 输光后自动重新买入
+	TransactionAutoTopUp TransactionType = "auto_top_up" // 自动补充筹码
+	TransactionCashOut   TransactionType = "cash_out"    // 离桌时兑现筹码
+)
+
+// Transaction 一笔筹码变动
+type Transaction struct {
+	Name   string          `json:"name"`   // 玩家名称
+	Type   TransactionType `json:"type"`   // 变动类型
+	Amount int             `json:"amount"` // 筹码数
+	Time   time.Time       `json:"time"`   // 发生时间
+}
+
+// departure 玩家离桌时的筹码和时间
+type departure struct {
+	chips int       // 离开时的筹码
+	at    time.Time // 离开的时间
+}
+
+// Table 现金桌，所有筹码变动都经过买入规则校验并记入账目
+type Table struct {
+	Game   *poker.Game             // 牌桌上的牌局
+	Config Config                  // 现金桌设置
+	Auto   map[string]AutoSettings // 每个座位的自动买入设置，key 为座位上的玩家标识
+	Ledger []Transaction           // 按时间顺序记录的筹码变动
+	Now    func() time.Time        // 获取当前时间，用于防止老鼠洞
+	Rake   *RakeLedger             // 抽水账目
+
+	departures map[string]departure // 最近离桌的玩家，key 为玩家名称
+}
+
+// NewTable 在牌局上创建现金桌，未设置的买入规则使用默认值
+func NewTable(g *poker.Game, cfg Config) (*Table, error) {
+	if cfg.MinBuyIn == 0 {
+		cfg.MinBuyIn = defaultMinBuyIn
+	}
+	if cfg.MaxBuyIn == 0 {
+		cfg.MaxBuyIn = defaultMaxBuyIn
+	}
+	if cfg.RatholeWindow == 0 {
+		cfg.RatholeWindow = defaultRatholeWindow
+	}
+	if cfg.MinBuyIn < 0 || cfg.MinBuyIn > cfg.MaxBuyIn {
+		return nil, fmt.Errorf("invalid buy-in range %d-%d big blinds", cfg.MinBuyIn, cfg.MaxBuyIn)
+	}
+
+	// 抽水在牌局分配奖池时扣除，并记入抽水账目。
+	// 没有设置抽水规则时沿用牌局已有的规则，例如从快照恢复的牌局
+	if cfg.Rake == nil {
+		cfg.Rake = g.Rake
+	}
+	g.Rake = cfg.Rake
+	ledger := NewRakeLedger()
+	g.Listeners = append(g.Listeners, ledger)
+
+	return &Table{
+		Game:       g,
+		Config:     cfg,
+		Auto:       make(map[string]AutoSettings),
+		Now:        time.Now,
+		Rake:       ledger,
+		departures: make(map[string]departure),
+	}, nil
+}
+
+// BuyInRange 获取玩家入座时可以买入的筹码范围。
+// 离桌后在限定时间内回来的玩家，至少要带回离开时的筹码
+func (t *Table) BuyInRange(name string) (minChips, maxChips int) {
+	bigBlind := t.Game.Table.MinBet * 2
+	minChips, maxChips = t.Config.MinBuyIn*bigBlind, t.Config.MaxBuyIn*bigBlind
+
+	if d, ok := t.departures[name]; ok && t.Config.RatholeWindow > 0 && t.Now().Sub(d.at) < t.Config.RatholeWindow {
+		minChips = max(minChips, d.chips)
+		maxChips = max(maxChips, d.chips)
+	}
+	return minChips, maxChips
+}
+
+// Sit 玩家坐到指定座位上并买入筹码
+func (t *Table) Sit(seatId, name string, chips int, isHuman bool) error {
+	if p := t.player(name); p != nil {
+		return fmt.Errorf("%s is already seated", name)
+	}
+	minChips, maxChips := t.BuyInRange(name)
+	if chips < minChips || chips > maxChips {
+		return fmt.Errorf("the buy-in must be between %d and %d, got %d", minChips, maxChips, chips)
+	}
+
+	if err := t.Game.TakeSeat(seatId, name, chips, isHuman); err != nil {
+		return err
+	}
+	delete(t.departures, name)
+	t.record(name, TransactionBuyIn, chips)
+	return nil
+}
+
+// SitBot 让机器人坐到指定座位上并买入筹码，用于补充座位
+func (t *Table) SitBot(seatId, name string, chips int, bot poker.Bot) error {
+	if err := t.Sit(seatId, name, chips, false); err != nil {
+		return err
+	}
+	if t.Game.Bots == nil {
+		t.Game.Bots = make(map[string]poker.Bot)
+	}
+	t.Game.Bots[name] = bot
+	return nil
+}
+
+// TopUp 玩家在两手牌之间补充筹码，补充后不能超过最多买入
+func (t *Table) TopUp(name string, amount int) error {
+	p := t.player(name)
+	if p == nil {
+		return ErrNotSeated
+	}
+	if err := t.topUp(p, amount); err != nil {
+		return err
+	}
+	t.record(name, TransactionTopUp, amount)
+	return nil
+}
+
+// Leave 玩家带着筹码离桌，记录离开时的筹码以防止老鼠洞
+func (t *Table) Leave(name string) error {
+	p := t.player(name)
+	if p == nil {
+		return ErrNotSeated
+	}
+
+	chips := p.Chips
+	if err := t.Game.LeaveSeat(p.Id); err != nil {
+		return err
+	}
+	delete(t.Game.Bots, name)
+	delete(t.Auto, p.Id)
+	t.departures[name] = departure{chips: chips, at: t.Now()}
+	t.record(name, TransactionCashOut, chips)
+	return nil
+}
+
+// StartHand 按座位的自动买入设置重新买入或补充筹码，然后开始新的一手牌
+func (t *Table) StartHand() error {
+	s := t.Game.Table.Seats
+	for i := 0; i < s.Len(); i++ {
+		if err := t.autoBuy(s.Player); err != nil {
+			return err
+		}
+		s = s.Next()
+	}
+	return t.Game.StartHand()
+}
+
+// autoBuy 按座位的自动买入设置为玩家重新买入或补充筹码
+func (t *Table) autoBuy(p *poker.Player) error {
+	auto, ok := t.Auto[p.Id]
+	if !ok || p.Status == poker.PlayerVacated {
+		return nil
+	}
+
+	_, maxChips := t.BuyInRange(p.Name)
+	target := auto.Amount
+	if target == 0 || target > maxChips {
+		target = maxChips
+	}
+	if p.Chips >= target {
+		return nil
+	}
+
+	switch {
+	case p.Chips == 0 && auto.Rebuy:
+		if err := t.topUp(p, target); err != nil {
+			return err
+		}
+		t.record(p.Name, TransactionAutoRebuy, target)
+	case p.Chips > 0 && auto.TopUp:
+		amount := target - p.Chips
+		if err := t.topUp(p, amount); err != nil {
+			return err
+		}
+		t.record(p.Name, TransactionAutoTopUp, amount)
+	}
+	return nil
+}
+
+// topUp 校验后为玩家补充筹码
+func (t *Table) topUp(p *poker.Player, amount int) error {
+	if t.Game.IsPlayerStage() {
+		return ErrHandInProgress
+	}
+	_, maxChips := t.BuyInRange(p.Name)
+	if p.Chips+amount > maxChips {
+		return fmt.Errorf("%s can add at most %d chips", p.Name, max(maxChips-p.Chips, 0))
+	}
+	return t.Game.AddChips(p.Id, amount)
+}
+
+// player 获取坐在牌桌上的玩家
+func (t *Table) player(name string) *poker.Player {
+	s := t.Game.Table.Seats
+	for i := 0; i < s.Len(); i++ {
+		if p := s.Player; p.Status != poker.PlayerVacated && p.Name == name {
+			return p
+		}
+		s = s.Next()
+	}
+	return nil
+}
+
+// record 记录一笔筹码变动
+func (t *Table) record(name string, typ TransactionType, amount int) {
+	t.Ledger = append(t.Ledger, Transaction{Name: name, Type: typ, Amount: amount, Time: t.Now()})
+}
@@ -0,0 +1,119 @@
+package cash
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCashTable(t *testing.T) {
+	now := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	g := poker.NewGame()
+	g.Debug = true
+	table, err := NewTable(g, Config{MinBuyIn: 20, MaxBuyIn: 100, RatholeWindow: 30 * time.Minute})
+	require.NoError(t, err)
+	table.Now = func() time.Time { return now }
+	seat1, seat2 := g.Table.Seats.Player.Id, g.Table.Seats.Next().Player.Id
+
+	// 买入范围为 20 到 100 个大盲注
+	assert.Error(t, table.Sit(seat1, "p1", 100, true))
+	assert.Error(t, table.Sit(seat1, "p1", 1500, true))
+	require.NoError(t, table.Sit(seat1, "p1", 1000, true))
+	require.NoError(t, table.Sit(seat2, "p2", 500, true))
+
+	// 补充筹码后不能超过最多买入，且只能在两手牌之间补充
+	assert.Error(t, table.TopUp("p2", 600))
+	require.NoError(t, table.TopUp("p2", 300))
+	require.NoError(t, table.StartHand())
+	assert.ErrorIs(t, table.TopUp("p2", 100), ErrHandInProgress)
+	require.NoError(t, g.Fold())
+
+	// 离桌后限定时间内回来，至少要带回离开时的筹码
+	p1 := g.Table.Seats.Player
+	left := p1.Chips
+	require.NoError(t, table.Leave("p1"))
+	minChips, maxChips := table.BuyInRange("p1")
+	assert.Equal(t, left, minChips)
+	assert.Equal(t, 1000, maxChips)
+	assert.Error(t, table.Sit(seat1, "p1", 200, true))
+
+	now = now.Add(31 * time.Minute)
+	minChips, maxChips = table.BuyInRange("p1")
+	assert.Equal(t, 200, minChips)
+	assert.Equal(t, 1000, maxChips)
+	require.NoError(t, table.Sit(seat1, "p1", 200, true))
+
+	// 自动补充到最多买入
+	table.Auto[seat1] = AutoSettings{TopUp: true}
+	require.NoError(t, table.StartHand())
+	assert.Equal(t, 1000, p1.Chips+g.Table.Pot.Bets[p1])
+
+	last := table.Ledger[len(table.Ledger)-1]
+	assert.Equal(t, TransactionAutoTopUp, last.Type)
+	assert.Equal(t, 800, last.Amount)
+	assert.Equal(t, TransactionCashOut, table.Ledger[3].Type)
+	assert.Equal(t, left, table.Ledger[3].Amount)
+}
+
+func TestCashTableRestore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	g := poker.NewGame()
+	table, err := NewTable(g, Config{Rake: &poker.RakeRule{Percent: 5}})
+	require.NoError(t, err)
+	table.Now = func() time.Time { return now }
+	seat1, seat2 := g.Table.Seats.Player.Id, g.Table.Seats.Next().Player.Id
+	require.NoError(t, table.Sit(seat1, "p1", 1000, true))
+	require.NoError(t, table.Sit(seat2, "p2", 1000, true))
+	table.Auto[seat2] = AutoSettings{Rebuy: true}
+	table.Rake.Entries = append(table.Rake.Entries, RakeEntry{HandId: 1, Total: 5, Players: map[string]int{"p1": 5}, Time: now})
+	require.NoError(t, table.Leave("p1"))
+
+	// 服务重启后从快照恢复牌局和现金桌的状态
+	b, err := g.MarshalSnapshot()
+	require.NoError(t, err)
+	restored, err := poker.UnmarshalSnapshot(b)
+	require.NoError(t, err)
+	state, err := json.Marshal(table.State())
+	require.NoError(t, err)
+	var s State
+	require.NoError(t, json.Unmarshal(state, &s))
+	again, err := NewTable(restored, Config{})
+	require.NoError(t, err)
+	again.Now = table.Now
+	again.Restore(&s)
+
+	// 抽水规则、账目和离桌记录都被恢复，离桌的玩家不能少带筹码回来
+	assert.Equal(t, g.Rake, restored.Rake)
+	assert.Equal(t, table.State(), again.State())
+	assert.Equal(t, 5, again.Rake.Total())
+	minChips, _ := again.BuyInRange("p1")
+	assert.Equal(t, 1000, minChips)
+	assert.Error(t, again.Sit(seat1, "p1", 400, true))
+}
+
+func TestRakeLedger(t *testing.T) {
+	g := poker.NewGame()
+	seat1, seat2 := g.Table.Seats.Player.Id, g.Table.Seats.Next().Player.Id
+	require.NoError(t, g.TakeSeat(seat1, "p1", 1000, true))
+	require.NoError(t, g.TakeSeat(seat2, "p2", 1000, true))
+
+	l := NewRakeLedger()
+	for hand := 1; hand <= 2; hand++ {
+		g.HandId = hand
+		l.OnEvent(g, poker.PotAwarded{Amount: 400, Rake: 20, RakeFrom: []poker.PotShare{{Seat: 0, Amount: 12}, {Seat: 1, Amount: 8}}})
+		l.OnEvent(g, poker.HandEnded{Showdown: true})
+	}
+
+	// 没有抽水的手牌不记入账目
+	l.OnEvent(g, poker.PotAwarded{Amount: 30})
+	l.OnEvent(g, poker.HandEnded{})
+
+	require.Len(t, l.Entries, 2)
+	assert.Equal(t, 40, l.Total())
+	assert.Equal(t, map[string]int{"p1": 12, "p2": 8}, l.Entries[0].Players)
+	assert.Equal(t, map[string]int{"p1": 7, "p2": 4}, l.Rakeback(30))
+}
@@ -0,0 +1,124 @@
+// acpc 以 ACPC 发牌方的身份主持比赛，让外部的研究机器人与本地的机器人对局。
+//
+// 用法：
+//
+//	acpc [-addr host:port] [-hands n] [-limit] [-sb n] [-stack n] [-seed n] [-timeout d] [-log file] [-json] <player>...
+//
+// player 为 acpc 时等待一个外部客户端连接，其他值为本地机器人的难度，例如：acpc -limit acpc hard。
+// 外部客户端按连接的顺序对应参数中的 acpc
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/lllllan02/pocker/acpc"
+	"github.com/lllllan02/pocker/bot"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:18791", "address to listen on for ACPC clients")
+	hands := flag.Int("hands", 1000, "number of hands to play")
+	limit := flag.Bool("limit", false, "play fixed-limit instead of no-limit")
+	smallBlind := flag.Int("sb", 0, "small blind, 5 for limit and 50 for no-limit by default")
+	stack := flag.Int("stack", 0, "starting stack of every hand, 20000 by default")
+	seed := flag.Int64("seed", 1, "random seed for the decks and the bots")
+	timeout := flag.Duration("timeout", 0, "time limit for every action of an ACPC client")
+	logFile := flag.String("log", "", "write every hand to this file in the ACPC log format")
+	asJSON := flag.Bool("json", false, "print the result as JSON")
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		fmt.Fprintf(os.Stderr, "usage: acpc [flags] <player>...\nplayers: acpc, %s\n", strings.Join(bot.Levels(), ", "))
+		os.Exit(2)
+	}
+
+	players, err := parsePlayers(flag.Args(), *seed)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	var w io.Writer
+	if *logFile != "" {
+		f, err := os.Create(*logFile)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "waiting for ACPC clients on %s\n", l.Addr())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := acpc.Serve(ctx, l, acpc.Config{
+		Players:    players,
+		Hands:      *hands,
+		Limit:      *limit,
+		SmallBlind: *smallBlind,
+		Stack:      *stack,
+		Seed:       *seed,
+		Timeout:    *timeout,
+		Log:        w,
+	})
+	if result == nil {
+		log.Fatalf("error: %v", err)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "stopped early: %v\n", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		return
+	}
+
+	fmt.Printf("%d hands\n", result.Hands)
+	fmt.Printf("%-12s %10s %10s\n", "player", "won", "per hand")
+	for _, s := range result.Scores {
+		fmt.Printf("%-12s %10d %+10.2f\n", s.Name, s.Won, s.PerHand)
+	}
+}
+
+// parsePlayers 按参数创建玩家，同一名称出现多次时在后面加上序号
+func parsePlayers(args []string, seed int64) ([]acpc.Player, error) {
+	players := make([]acpc.Player, 0, len(args))
+	count := make(map[string]int)
+	for i, arg := range args {
+		count[arg]++
+		name := arg
+		if count[arg] > 1 {
+			name = fmt.Sprintf("%s#%d", arg, count[arg])
+		}
+		if arg == "acpc" {
+			players = append(players, acpc.Player{Name: name})
+			continue
+		}
+
+		b, err := bot.New(arg, seed+int64(i))
+		if err != nil {
+			return nil, err
+		}
+		b.Wait = true
+		players = append(players, acpc.Player{Name: name, Bot: b})
+	}
+	return players, nil
+}
@@ -0,0 +1,67 @@
+// env 通过标准输入输出提供强化学习环境，同一台机器上的 Python 训练程序可以直接驱动牌局。
+//
+// 用法：
+//
+//	env [-stack n] [-sb n] [-ante n] [-limit] [-seed n] [<player>...]
+//
+// player 为 agent 时由训练程序控制，其他值为机器人的难度，例如：env agent hard。
+// 没有参数时为两名由训练程序控制的玩家。每行输入一条 JSON 请求，每行输出一条 JSON 回复：
+//
+//	{"cmd":"spec"}
+//	{"cmd":"reset","seed":1}
+//	{"cmd":"step","action":1}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/lllllan02/pocker/bot"
+	"github.com/lllllan02/pocker/env"
+	"github.com/lllllan02/pocker/poker"
+)
+
+func main() {
+	stack := flag.Int("stack", 0, "starting stack of every hand, 100 big blinds by default")
+	smallBlind := flag.Int("sb", 5, "small blind, the big blind is twice as much")
+	ante := flag.Int("ante", 0, "ante")
+	limit := flag.Bool("limit", false, "play fixed-limit instead of no-limit")
+	seed := flag.Int64("seed", 1, "random seed for the bots")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"agent", "agent"}
+	}
+	bots := make([]poker.Bot, len(args))
+	for i, arg := range args {
+		if arg == "agent" {
+			continue
+		}
+		b, err := bot.New(arg, *seed+int64(i))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "usage: env [flags] [<player>...]\nplayers: agent, %s\n", strings.Join(bot.Levels(), ", "))
+			os.Exit(2)
+		}
+		b.Wait = true
+		bots[i] = b
+	}
+
+	e, err := env.New(env.Config{
+		Players:    len(args),
+		Stack:      *stack,
+		SmallBlind: *smallBlind,
+		Ante:       *ante,
+		Limit:      *limit,
+		Bots:       bots,
+	})
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if err := e.Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+}
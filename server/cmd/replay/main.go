@@ -0,0 +1,67 @@
+// replay 重现手牌记录并校验结果，用于复查有争议的牌局以及回归测试引擎的改动。
+//
+// 用法：
+//
+//	replay [-v] <file>...
+//
+// 以 .jsonl 结尾的文件按 JSON 格式读取，其他文件按 PokerStars 文本格式读取
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/lllllan02/pocker/history"
+)
+
+func main() {
+	verbose := flag.Bool("v", false, "print every replayed hand")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: replay [-v] <file>...")
+		os.Exit(2)
+	}
+
+	total, failed := 0, 0
+	for _, name := range flag.Args() {
+		hands, err := readHands(name)
+		if err != nil {
+			log.Fatalf("error: %s: %v", name, err)
+		}
+
+		for _, h := range hands {
+			total++
+			if _, err := history.Replay(h); err != nil {
+				failed++
+				fmt.Printf("FAIL %s: %v\n", name, err)
+			} else if *verbose {
+				fmt.Printf("ok   %s: hand #%d\n", name, h.Id)
+			}
+		}
+	}
+
+	fmt.Printf("%d hands replayed, %d failed\n", total, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// readHands 根据文件扩展名选择格式读取手牌记录
+func readHands(name string) ([]*history.Hand, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".jsonl") {
+		return history.ReadJSON(r)
+	}
+	return history.ParsePokerStars(r)
+}
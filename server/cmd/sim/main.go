@@ -0,0 +1,112 @@
+// sim 让机器人互相对局并统计每个机器人的 bb/100，用于比较不同难度和策略的强弱。
+//
+// 用法：
+//
+//	sim [-hands n] [-seed n] [-duplicate] [-stack n] [-sb n] [-ante n] [-workers n] [-json] <level>...
+//
+// level 为机器人的难度，可以重复，例如：sim -hands 1000000 -duplicate hard easy
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/lllllan02/pocker/bot"
+	"github.com/lllllan02/pocker/poker"
+	"github.com/lllllan02/pocker/sim"
+)
+
+func main() {
+	hands := flag.Int("hands", 100000, "number of hands to play")
+	seed := flag.Int64("seed", 1, "random seed for the decks and the bots")
+	duplicate := flag.Bool("duplicate", false, "replay every deal with the seats rotated")
+	stack := flag.Int("stack", 0, "starting stack of every hand, 100 big blinds by default")
+	smallBlind := flag.Int("sb", 5, "small blind, the big blind is twice as much")
+	ante := flag.Int("ante", 0, "ante")
+	workers := flag.Int("workers", 0, "number of tables played in parallel, the number of CPUs by default")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		fmt.Fprintf(os.Stderr, "usage: sim [flags] <level>...\nlevels: %s\n", strings.Join(bot.Levels(), ", "))
+		os.Exit(2)
+	}
+
+	entries, err := parseEntries(flag.Args())
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	// 中断时输出已经完成的部分
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	report, err := sim.Run(ctx, sim.Config{
+		Entries:    entries,
+		Hands:      *hands,
+		Seed:       *seed,
+		Stack:      *stack,
+		SmallBlind: *smallBlind,
+		Ante:       *ante,
+		Duplicate:  *duplicate,
+		Workers:    *workers,
+	})
+	if report == nil {
+		log.Fatalf("error: %v", err)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "stopped early: %v\n", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		return
+	}
+
+	mode := "normal"
+	if report.Duplicate {
+		mode = "duplicate"
+	}
+	elapsed := time.Since(start)
+	fmt.Printf("%d hands, %d deals, %s mode, %.0f hands/s\n", report.Hands, report.Deals, mode, float64(report.Hands)/elapsed.Seconds())
+	fmt.Printf("%-12s %10s %10s %10s\n", "bot", "won (bb)", "bb/100", "95% CI")
+	for _, s := range report.Stats {
+		fmt.Printf("%-12s %10.1f %+10.2f %10s\n", s.Name, s.Won, s.BB100, fmt.Sprintf("±%.2f", s.CI95))
+	}
+}
+
+// parseEntries 按难度创建机器人，同一难度出现多次时在名称后面加上序号
+func parseEntries(levels []string) ([]sim.Entry, error) {
+	entries := make([]sim.Entry, 0, len(levels))
+	count := make(map[string]int)
+	for _, level := range levels {
+		if _, err := bot.New(level, 0); err != nil {
+			return nil, err
+		}
+		count[level]++
+		name := level
+		if count[level] > 1 {
+			name = fmt.Sprintf("%s#%d", level, count[level])
+		}
+
+		level := level
+		entries = append(entries, sim.Entry{Name: name, New: func(seed int64) poker.Bot {
+			b, _ := bot.New(level, seed)
+			b.Wait = true
+			return b
+		}})
+	}
+	return entries, nil
+}
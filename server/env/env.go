@@ -0,0 +1,362 @@
+// Package env 在牌局引擎之上提供与 gym 类似的强化学习环境：Reset 开始新的一局，Step 执行当前玩家的动作。
+//
+// 每局为一手牌，所有玩家以相同的筹码开始。观察从当前行动的玩家的视角给出，
+// 座位按从该玩家开始的顺时针顺序排列，筹码都以大盲注为单位。
+// 没有设置机器人的玩家都由调用方控制，所有玩家都由调用方控制时即为自我对弈
+package env
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+const (
+	MaxPlayers = 6  // 最多的玩家数，观察中按座位排列的部分都有这么多项
+	MaxHistory = 32 // 观察中保留的最近的动作数
+
+	// historyFeatures 每个动作的特征数：相对座位、动作类型和阶段的 one-hot 编码，以及动作后本轮的下注总额
+	historyFeatures = MaxPlayers + 3 + 4 + 1
+
+	// ObservationSize Vector 返回的观察向量的长度
+	ObservationSize = 52 + 52 + 4 + MaxPlayers*5 + 2 + MaxHistory*historyFeatures
+)
+
+// Action 离散的动作
+type Action int
+
+const (
+	ActionFold     Action = iota // 弃牌
+	ActionCall                   // 过牌或跟注
+	ActionMinRaise               // 最小下注或加注，限注时为固定金额
+	ActionHalfPot                // 跟注后再加注半个底池
+	ActionPot                    // 跟注后再加注一个底池
+	ActionAllIn                  // 全下
+	NumActions                   // 动作的个数
+)
+
+// actionNames 动作的名称
+var actionNames = [NumActions]string{"fold", "call", "min_raise", "half_pot", "pot", "all_in"}
+
+// String 返回动作的名称
+func (a Action) String() string {
+	if a < 0 || a >= NumActions {
+		return fmt.Sprintf("action(%d)", int(a))
+	}
+	return actionNames[a]
+}
+
+// Config 环境的设置
+type Config struct {
+	Players    int         // 玩家数，2 到 6，为 0 时为 2
+	Stack      int         // 每局开始时的筹码，为 0 时为 100 个大盲注
+	SmallBlind int         // 小盲注，为 0 时为 5
+	Ante       int         // 前注
+	Limit      bool        // 是否为固定限注
+	Bots       []poker.Bot // 按玩家排列的机器人，为 nil 或超出长度的玩家由调用方控制
+}
+
+// Observation 当前行动的玩家的观察，按座位排列的部分从该玩家开始，超出玩家数的部分视为已弃牌的空座位
+type Observation struct {
+	Player  int       `json:"player"`  // 行动的玩家
+	Hole    []float32 `json:"hole"`    // 底牌的 one-hot 编码，下标为 花色*13+点数
+	Board   []float32 `json:"board"`   // 公共牌的 one-hot 编码
+	Stage   []float32 `json:"stage"`   // 翻牌前、翻牌圈、转牌圈和河牌圈的 one-hot 编码
+	Button  []float32 `json:"button"`  // 庄家相对座位的 one-hot 编码
+	Stacks  []float32 `json:"stacks"`  // 每个座位剩余的筹码
+	Bets    []float32 `json:"bets"`    // 每个座位在本轮的下注
+	InPot   []float32 `json:"in_pot"`  // 每个座位在本手牌投入奖池的筹码
+	Folded  []float32 `json:"folded"`  // 每个座位是否已经弃牌
+	Pot     float32   `json:"pot"`     // 奖池总额，包括本轮的下注
+	ToCall  float32   `json:"to_call"` // 跟注需要补充的筹码
+	History []float32 `json:"history"` // 最近的动作，每个动作 historyFeatures 项，按时间顺序排列，不足时在后面补 0
+	Mask    []bool    `json:"mask"`    // 每个动作当前是否合法
+}
+
+// Vector 按字段的顺序把观察拼接为长度为 ObservationSize 的向量，不包括 Player 和 Mask
+func (o *Observation) Vector() []float32 {
+	v := make([]float32, 0, ObservationSize)
+	for _, part := range [][]float32{o.Hole, o.Board, o.Stage, o.Button, o.Stacks, o.Bets, o.InPot, o.Folded} {
+		v = append(v, part...)
+	}
+	v = append(v, o.Pot, o.ToCall)
+	return append(v, o.History...)
+}
+
+// Step Reset 和 Step 的结果
+type Step struct {
+	Observation *Observation `json:"observation"` // 下一个行动的玩家的观察，一局结束时为 nil
+	Rewards     []float64    `json:"rewards"`     // 按玩家排列的收益，以大盲注计，只在一局结束时不为 0
+	Done        bool         `json:"done"`        // 这一局是否已经结束
+}
+
+// Env 强化学习环境，不能在多个 goroutine 中同时使用
+type Env struct {
+	cfg    Config
+	g      *poker.Game
+	ids    []string // 每名玩家的座位标识
+	dealer int      // 坐在庄家位置的玩家
+	view   *poker.View
+}
+
+// New 按设置创建环境，需要调用 Reset 开始第一局
+func New(cfg Config) (*Env, error) {
+	if cfg.Players == 0 {
+		cfg.Players = 2
+	}
+	if cfg.Players < 2 || cfg.Players > MaxPlayers {
+		return nil, fmt.Errorf("the environment needs 2 to %d players, got %d", MaxPlayers, cfg.Players)
+	}
+	if len(cfg.Bots) > cfg.Players {
+		return nil, fmt.Errorf("%d bots for %d players", len(cfg.Bots), cfg.Players)
+	}
+	if cfg.SmallBlind <= 0 {
+		cfg.SmallBlind = 5
+	}
+	if cfg.Stack <= 0 {
+		cfg.Stack = cfg.SmallBlind * 2 * 100
+	}
+	if cfg.Ante < 0 {
+		return nil, fmt.Errorf("the ante cannot be negative, got %d", cfg.Ante)
+	}
+	return &Env{cfg: cfg}, nil
+}
+
+// Reset 开始新的一局，牌堆和庄家的位置由 seed 决定，机器人行动之后返回第一个由调用方控制的玩家的观察
+func (e *Env) Reset(seed int64) (*Step, error) {
+	cfg := e.cfg
+	n := cfg.Players
+	r := rand.New(rand.NewSource(seed))
+	e.dealer = r.Intn(n)
+
+	g := poker.NewGameWithSeats(n)
+	g.BotTimeout, g.HandEvents = -1, true
+	deck := poker.NewDeckWithRand(r)
+	g.DeckSource = func() *poker.Deck { return deck }
+	if err := g.SetBlinds(cfg.SmallBlind, cfg.Ante); err != nil {
+		return nil, err
+	}
+	if err := g.SetLimit(cfg.Limit); err != nil {
+		return nil, err
+	}
+
+	// 第一手牌的庄家在座位 0，玩家按顺时针的顺序入座
+	e.ids = make([]string, n)
+	s := g.Table.Seats
+	for seat := 0; seat < n; seat++ {
+		i := (seat + e.dealer) % n
+		e.ids[i] = s.Player.Id
+		name := fmt.Sprintf("player%d", i)
+		var err error
+		if i < len(cfg.Bots) && cfg.Bots[i] != nil {
+			err = g.AddBot(s.Player.Id, name, cfg.Stack, cfg.Bots[i])
+		} else {
+			err = g.TakeSeat(s.Player.Id, name, cfg.Stack, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		s = s.Next()
+	}
+
+	e.g = g
+	if err := g.StartHand(); err != nil {
+		return nil, err
+	}
+	return e.next()
+}
+
+// Step 执行当前玩家的动作，不合法的动作返回错误且不改变牌局
+func (e *Env) Step(a Action) (*Step, error) {
+	if e.view == nil {
+		return nil, fmt.Errorf("the episode is over, call reset first")
+	}
+	if a < 0 || a >= NumActions {
+		return nil, fmt.Errorf("invalid action %d", int(a))
+	}
+
+	d, ok := decision(e.view, a)
+	if !ok {
+		return nil, fmt.Errorf("action %s is not legal now", a)
+	}
+
+	var err error
+	switch d.Type {
+	case poker.ActionFold:
+		err = e.g.Fold()
+	case poker.ActionCheck:
+		err = e.g.Check()
+	case poker.ActionCall:
+		err = e.g.Call()
+	default:
+		err = e.g.Raise(d.Amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.next()
+}
+
+// next 一局结束时返回收益，否则返回当前行动的玩家的观察
+func (e *Env) next() (*Step, error) {
+	g, bb := e.g, float64(e.cfg.SmallBlind*2)
+	step := &Step{Rewards: make([]float64, e.cfg.Players)}
+	if !g.IsPlayerStage() {
+		e.view = nil
+		step.Done = true
+		for i, id := range e.ids {
+			step.Rewards[i] = float64(g.PlayerMap[id].Chips-e.cfg.Stack) / bb
+		}
+		return step, nil
+	}
+
+	v, err := g.View(g.CurrentSeat.Player.Id)
+	if err != nil {
+		return nil, err
+	}
+	e.view = v
+	step.Observation = e.observe(v)
+	return step, nil
+}
+
+// player 座位上的玩家
+func (e *Env) player(seat int) int {
+	return (seat + e.dealer) % e.cfg.Players
+}
+
+// observe 按当前行动的玩家的视角编码观察
+func (e *Env) observe(v *poker.View) *Observation {
+	n, bb := e.cfg.Players, float32(e.cfg.SmallBlind*2)
+	p := e.player(v.Seat)
+	relative := func(seat int) int { return (e.player(seat) - p + n) % n }
+
+	o := &Observation{
+		Player:  p,
+		Hole:    make([]float32, 52),
+		Board:   make([]float32, 52),
+		Stage:   make([]float32, 4),
+		Button:  make([]float32, MaxPlayers),
+		Stacks:  make([]float32, MaxPlayers),
+		Bets:    make([]float32, MaxPlayers),
+		InPot:   make([]float32, MaxPlayers),
+		Folded:  make([]float32, MaxPlayers),
+		Pot:     float32(v.Pot) / bb,
+		History: make([]float32, MaxHistory*historyFeatures),
+		Mask:    make([]bool, NumActions),
+	}
+	for _, c := range v.HoleCards {
+		o.Hole[cardIndex(c)] = 1
+	}
+	for _, c := range v.Board {
+		o.Board[cardIndex(c)] = 1
+	}
+	o.Stage[v.Stage-poker.GameStagePreflop] = 1
+	o.Button[relative(v.Dealer)] = 1
+
+	for k := n; k < MaxPlayers; k++ {
+		o.Folded[k] = 1
+	}
+	for _, pv := range v.Players {
+		k := relative(pv.Seat)
+		o.Stacks[k] = float32(pv.Chips) / bb
+		o.Bets[k] = float32(pv.Bet) / bb
+		o.InPot[k] = float32(pv.InPot) / bb
+		if pv.Folded {
+			o.Folded[k] = 1
+		}
+		if pv.Seat == v.Seat {
+			o.ToCall = float32(min(v.CallAmount-pv.Bet, pv.Chips)) / bb
+		}
+	}
+
+	actions := v.Actions
+	if len(actions) > MaxHistory {
+		actions = actions[len(actions)-MaxHistory:]
+	}
+	for i, a := range actions {
+		f := o.History[i*historyFeatures : (i+1)*historyFeatures]
+		f[relative(a.Seat)] = 1
+		switch a.Type {
+		case poker.ActionFold:
+			f[MaxPlayers] = 1
+		case poker.ActionCheck, poker.ActionCall:
+			f[MaxPlayers+1] = 1
+		default:
+			f[MaxPlayers+2] = 1
+		}
+		f[MaxPlayers+3+int(a.Stage-poker.GameStagePreflop)] = 1
+		f[historyFeatures-1] = float32(a.Total) / bb
+	}
+
+	for a := Action(0); a < NumActions; a++ {
+		_, o.Mask[a] = decision(v, a)
+	}
+	return o
+}
+
+// decision 将离散的动作转换为牌局的决定，动作不合法时返回 false。
+// 按底池比例计算的加注金额会限制在合法的范围内，与最小加注或全下重复时视为不合法
+func decision(v *poker.View, a Action) (poker.Decision, bool) {
+	var call, check, raise *poker.LegalAction
+	for i, l := range v.Legal {
+		switch l.Type {
+		case poker.ActionFold:
+			if a == ActionFold {
+				return poker.Decision{Type: poker.ActionFold}, true
+			}
+		case poker.ActionCheck:
+			check = &v.Legal[i]
+		case poker.ActionCall:
+			call = &v.Legal[i]
+		case poker.ActionBet, poker.ActionRaise:
+			raise = &v.Legal[i]
+		}
+	}
+
+	switch a {
+	case ActionCall:
+		if call != nil {
+			return poker.Decision{Type: poker.ActionCall}, true
+		}
+		if check != nil {
+			return poker.Decision{Type: poker.ActionCheck}, true
+		}
+		return poker.Decision{}, false
+	case ActionFold:
+		return poker.Decision{}, false
+	}
+
+	if raise == nil {
+		return poker.Decision{}, false
+	}
+	switch a {
+	case ActionMinRaise:
+		return poker.Decision{Type: raise.Type, Amount: raise.Min}, true
+	case ActionAllIn:
+		return poker.Decision{Type: raise.Type, Amount: raise.Max}, raise.Max > raise.Min
+	}
+
+	// 跟注之后的底池乘以比例，再加上跟注的金额
+	bet := 0
+	for _, p := range v.Players {
+		if p.Seat == v.Seat {
+			bet = p.Bet
+		}
+	}
+	fraction := 0.5
+	if a == ActionPot {
+		fraction = 1
+	}
+	pot := v.Pot + v.CallAmount - bet
+	amount := v.CallAmount + int(fraction*float64(pot))
+	if amount <= raise.Min || amount >= raise.Max {
+		return poker.Decision{}, false
+	}
+	return poker.Decision{Type: raise.Type, Amount: amount}, true
+}
+
+// cardIndex 牌在 one-hot 编码中的下标
+func cardIndex(c poker.Card) int {
+	return int(c.Suit)*13 + int(c.Rank)
+}
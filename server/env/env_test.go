@@ -0,0 +1,129 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvHeadsUp(t *testing.T) {
+	e, err := New(Config{})
+	require.NoError(t, err)
+	step, err := e.Reset(1)
+	require.NoError(t, err)
+	require.False(t, step.Done)
+
+	// 单挑时庄家下小盲注并首先行动
+	o := step.Observation
+	assert.Equal(t, e.dealer, o.Player)
+	assert.Equal(t, []float32{1, 0, 0, 0}, o.Stage)
+	assert.Equal(t, float32(1), o.Button[0])
+	assert.Equal(t, float32(1.5), o.Pot)
+	assert.Equal(t, float32(0.5), o.ToCall)
+	assert.Equal(t, []float32{99.5, 99, 0, 0, 0, 0}, o.Stacks)
+	assert.Equal(t, []float32{0, 0, 1, 1, 1, 1}, o.Folded)
+	assert.Len(t, o.Vector(), ObservationSize)
+	var hole float32
+	for _, v := range o.Hole {
+		hole += v
+	}
+	assert.Equal(t, float32(2), hole)
+
+	// 半个底池的加注与最小加注相同
+	assert.Equal(t, []bool{true, true, true, false, true, true}, o.Mask)
+
+	// 小盲注加注到 3 个大盲注，大盲注看到的历史中有这次加注
+	step, err = e.Step(ActionPot)
+	require.NoError(t, err)
+	o = step.Observation
+	assert.Equal(t, 1-e.dealer, o.Player)
+	assert.Equal(t, float32(2), o.ToCall)
+	history := o.History[:historyFeatures]
+	assert.Equal(t, float32(1), history[1])
+	assert.Equal(t, float32(1), history[MaxPlayers+2])
+	assert.Equal(t, float32(3), history[historyFeatures-1])
+
+	step, err = e.Step(ActionFold)
+	require.NoError(t, err)
+	assert.True(t, step.Done)
+	assert.Nil(t, step.Observation)
+	rewards := []float64{0, 0}
+	rewards[e.dealer], rewards[1-e.dealer] = 1, -1
+	assert.Equal(t, rewards, step.Rewards)
+
+	_, err = e.Step(ActionCall)
+	assert.Error(t, err)
+}
+
+func TestEnvSelfPlay(t *testing.T) {
+	for _, limit := range []bool{false, true} {
+		e, err := New(Config{Players: 4, Limit: limit})
+		require.NoError(t, err)
+
+		r := rand.New(rand.NewSource(1))
+		for seed := int64(0); seed < 100; seed++ {
+			step, err := e.Reset(seed)
+			require.NoError(t, err)
+			for !step.Done {
+				var legal []Action
+				for a, ok := range step.Observation.Mask {
+					if ok {
+						legal = append(legal, Action(a))
+					}
+				}
+				require.NotEmpty(t, legal)
+				if limit {
+					assert.False(t, step.Observation.Mask[ActionAllIn])
+				}
+				step, err = e.Step(legal[r.Intn(len(legal))])
+				require.NoError(t, err)
+			}
+
+			var total float64
+			for _, v := range step.Rewards {
+				total += v
+			}
+			assert.InDelta(t, 0, total, 1e-9)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	e, err := New(Config{Players: 3})
+	require.NoError(t, err)
+
+	in := strings.Join([]string{
+		`{"cmd":"spec"}`,
+		`{"cmd":"reset","seed":7}`,
+		`{"cmd":"step","action":0}`,
+		`{"cmd":"jump"}`,
+	}, "\n")
+	var out bytes.Buffer
+	require.NoError(t, e.Serve(strings.NewReader(in), &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+
+	var spec Response
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &spec))
+	assert.Equal(t, 3, spec.Spec.Players)
+	assert.Equal(t, ObservationSize, spec.Spec.ObservationSize)
+	assert.Len(t, spec.Spec.Actions, int(NumActions))
+
+	var reset Response
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &reset))
+	assert.Len(t, reset.Vector, ObservationSize)
+	assert.Len(t, reset.Observation.Mask, int(NumActions))
+
+	var fold Response
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &fold))
+	assert.Empty(t, fold.Error)
+	assert.Len(t, fold.Rewards, 3)
+
+	assert.Contains(t, lines[3], `"error":"unknown command \"jump\""`)
+}
@@ -0,0 +1,101 @@
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Request JSON-lines 协议中的一条请求，例如：{"cmd":"reset","seed":1}、{"cmd":"step","action":1}
+type Request struct {
+	Cmd    string `json:"cmd"`              // reset、step 或 spec
+	Seed   int64  `json:"seed,omitempty"`   // reset 时的随机种子
+	Action Action `json:"action,omitempty"` // step 时的动作
+}
+
+// Spec 环境的规格，供训练程序创建网络
+type Spec struct {
+	Players         int      `json:"players"`          // 玩家数
+	Actions         []string `json:"actions"`          // 按编号排列的动作名称
+	ObservationSize int      `json:"observation_size"` // 观察向量的长度
+	MaxPlayers      int      `json:"max_players"`      // 观察中按座位排列的部分的长度
+	MaxHistory      int      `json:"max_history"`      // 观察中保留的最近的动作数
+	HistoryFeatures int      `json:"history_features"` // 每个动作的特征数
+}
+
+// Response JSON-lines 协议中的一条回复，出错时只有 error
+type Response struct {
+	*Step
+	Vector []float32 `json:"vector,omitempty"` // 观察向量，一局结束时为空
+	Spec   *Spec     `json:"spec,omitempty"`   // spec 请求的结果
+	Error  string    `json:"error,omitempty"`  // 错误信息
+}
+
+// Spec 返回环境的规格
+func (e *Env) Spec() *Spec {
+	s := &Spec{
+		Players:         e.cfg.Players,
+		ObservationSize: ObservationSize,
+		MaxPlayers:      MaxPlayers,
+		MaxHistory:      MaxHistory,
+		HistoryFeatures: historyFeatures,
+	}
+	for a := Action(0); a < NumActions; a++ {
+		s.Actions = append(s.Actions, a.String())
+	}
+	return s
+}
+
+// Serve 从 r 逐行读取请求，把每条请求的回复作为一行 JSON 写入 w，直到 r 结束。
+// 请求出错时回复错误信息并继续处理下一条请求
+func (e *Env) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := enc.Encode(e.handle(line)); err != nil {
+			return err
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// handle 处理一条请求
+func (e *Env) handle(line []byte) *Response {
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &Response{Error: fmt.Sprintf("invalid request: %v", err)}
+	}
+
+	var step *Step
+	var err error
+	switch req.Cmd {
+	case "spec":
+		return &Response{Spec: e.Spec()}
+	case "reset":
+		step, err = e.Reset(req.Seed)
+	case "step":
+		step, err = e.Step(req.Action)
+	default:
+		err = fmt.Errorf("unknown command %q", req.Cmd)
+	}
+	if err != nil {
+		return &Response{Error: err.Error()}
+	}
+
+	resp := &Response{Step: step}
+	if step.Observation != nil {
+		resp.Vector = step.Observation.Vector()
+	}
+	return resp
+}
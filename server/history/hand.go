@@ -0,0 +1,120 @@
+package history
+
+import (
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Hand 一手牌的完整记录。
+// 包含开始时的座位和筹码、每个动作、每条街的公共牌以及奖池的分配结果
+type Hand struct {
+	Id         int          `json:"id"`          // 手牌编号
+	Time       time.Time    `json:"time"`        // 开始时间
+	Table      string       `json:"table"`       // 牌桌名称
+	MaxSeats   int          `json:"max_seats"`   // 牌桌座位数
+	Button     int          `json:"button"`      // 庄家座位号，从 1 开始
+	SmallBlind int          `json:"small_blind"` // 小盲注金额
+	BigBlind   int          `json:"big_blind"`   // 大盲注金额
+	Ante       int          `json:"ante"`        // 前注金额，没有前注时为 0
+	Limit      bool         `json:"limit"`       // 是否为固定限注
+	Seats      []Seat       `json:"seats"`       // 参与本手牌的玩家
+	Deck       []poker.Card `json:"deck"`        // 开始时的牌堆顺序，用于重现牌局
+	Actions    []Action     `json:"actions"`     // 按顺序记录的所有动作，包括下盲注
+	Board      []poker.Card `json:"board"`       // 公共牌
+	Uncalled   *Refund      `json:"uncalled"`    // 无人跟注而退还的下注
+	Pots       []Pot        `json:"pots"`        // 主池和边池，主池在前
+	Showdown   bool         `json:"showdown"`    // 是否进行了摊牌
+}
+
+// Seat 记录一名玩家在本手牌中的信息
+type Seat struct {
+	Seat      int          `json:"seat"`       // 座位号，从 1 开始
+	PlayerId  string       `json:"player_id"`  // 玩家唯一标识
+	Name      string       `json:"name"`       // 玩家名称
+	Position  string       `json:"position"`   // 相对庄家的位置，例如 BTN、UTG+1
+	Chips     int          `json:"chips"`      // 开始时的筹码数
+	HoleCards []poker.Card `json:"hole_cards"` // 底牌
+	Shown     string       `json:"shown"`      // 摊牌时亮出的牌型描述，未摊牌则为空
+}
+
+// Action 记录玩家的一次动作
+type Action struct {
+	Street   string `json:"street"`    // 动作发生的阶段
+	PlayerId string `json:"player_id"` // 玩家唯一标识
+	Type     string `json:"type"`      // 动作类型
+	Amount   int    `json:"amount"`    // 本次投入的筹码数
+	Total    int    `json:"total"`     // 动作后玩家在本轮的下注总额
+	RaiseBy  int    `json:"raise_by"`  // 下注或加注超出原跟注金额的部分
+	AllIn    bool   `json:"all_in"`    // 是否全下
+}
+
+// Refund 记录退还给玩家的筹码
+type Refund struct {
+	PlayerId string `json:"player_id"` // 玩家唯一标识
+	Amount   int    `json:"amount"`    // 退还的筹码数
+}
+
+// Pot 记录一个奖池的分配结果
+type Pot struct {
+	Amount  int      `json:"amount"`         // 奖池金额
+	Players []string `json:"players"`        // 有资格赢取该奖池的玩家
+	Winners []Winner `json:"winners"`        // 赢家
+	Rake    int      `json:"rake,omitempty"` // 从该奖池中抽取的筹码
+}
+
+// Winner 记录赢家分得的筹码
+type Winner struct {
+	PlayerId string `json:"player_id"` // 玩家唯一标识
+	Amount   int    `json:"amount"`    // 分得的筹码数
+}
+
+// FindSeat 根据玩家标识查找座位记录，找不到时返回 nil
+func (h *Hand) FindSeat(playerId string) *Seat {
+	for i := range h.Seats {
+		if h.Seats[i].PlayerId == playerId {
+			return &h.Seats[i]
+		}
+	}
+	return nil
+}
+
+// StreetBoard 获取指定阶段可见的公共牌，本手牌没有进行到该阶段时返回 nil
+func (h *Hand) StreetBoard(street poker.GameStage) []poker.Card {
+	n := 0
+	switch street {
+	case poker.GameStageFlop:
+		n = 3
+	case poker.GameStageTurn:
+		n = 4
+	case poker.GameStageRiver, poker.GameStageShowdown:
+		n = 5
+	}
+	if n > len(h.Board) {
+		return nil
+	}
+	return h.Board[:n]
+}
+
+// FinalChips 根据开始时的筹码、投入、退还和赢得的筹码，计算每名玩家结束时的筹码数
+func (h *Hand) FinalChips() map[string]int {
+	chips := make(map[string]int, len(h.Seats))
+	for _, s := range h.Seats {
+		chips[s.PlayerId] = s.Chips
+	}
+
+	for _, a := range h.Actions {
+		chips[a.PlayerId] -= a.Amount
+	}
+
+	if h.Uncalled != nil {
+		chips[h.Uncalled.PlayerId] += h.Uncalled.Amount
+	}
+
+	for _, pot := range h.Pots {
+		for _, w := range pot.Winners {
+			chips[w.PlayerId] += w.Amount
+		}
+	}
+	return chips
+}
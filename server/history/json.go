@@ -0,0 +1,39 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxLineSize JSON 记录中单行的大小上限
+const maxLineSize = 1 << 20
+
+// WriteJSON 以 JSON 格式写出一手牌，每手牌占一行
+func WriteJSON(w io.Writer, h *Hand) error {
+	return json.NewEncoder(w).Encode(h)
+}
+
+// ReadJSON 读取每行一手牌的 JSON 记录
+func ReadJSON(r io.Reader) ([]*Hand, error) {
+	hands := make([]*Hand, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		h := &Hand{}
+		if err := json.Unmarshal(scanner.Bytes(), h); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		hands = append(hands, h)
+	}
+
+	return hands, scanner.Err()
+}
@@ -0,0 +1,332 @@
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// currencySymbols 真钱金额前可能带有的货币符号
+const currencySymbols = "$€£"
+
+// PokerStars 文本格式中各类行的匹配规则
+var (
+	headerPattern   = regexp.MustCompile(`^PokerStars Hand #(\d+):\s+(?:Tournament #\d+, .*?)?Hold'em (No Limit|Limit)(?: - Level \S+)? \((\S+)/(\S+)(?: \w+)?\) - ([^\[]+)`)
+	tablePattern    = regexp.MustCompile(`^Table '(.+)' (\d+)-max Seat #(\d+) is the button`)
+	seatPattern     = regexp.MustCompile(`^Seat (\d+): (.+) \((\S+) in chips\)`)
+	streetPattern   = regexp.MustCompile(`^\*\*\* (FLOP|TURN|RIVER) \*\*\* \[([^\]]+)\](?: \[([^\]]+)\])?`)
+	dealtPattern    = regexp.MustCompile(`^Dealt to (.+) \[(\S\S \S\S)\]`)
+	uncalledPattern = regexp.MustCompile(`^Uncalled bet \((\S+)\) returned to (.+)$`)
+	collectPattern  = regexp.MustCompile(`^(.+) collected (\S+) from (pot|main pot|side pot(?:-(\d+))?)$`)
+	showsPattern    = regexp.MustCompile(`^shows \[(\S\S \S\S)\](?: \((.+)\))?`)
+	raisePattern    = regexp.MustCompile(`^raises (\S+) to (\S+)`)
+	rakePattern     = regexp.MustCompile(`^Total pot .* \| Rake (\S+)`)
+)
+
+// ParsePokerStars 解析 PokerStars 文本格式的手牌记录，支持现金桌和锦标赛的记录。
+//
+// 记录中没有玩家标识，解析结果使用玩家名称作为 PlayerId。
+// 真钱现金桌的金额带有小数，解析结果以分为单位。
+// 记录中也没有牌堆顺序，重现牌局时会根据底牌和公共牌推算
+func ParsePokerStars(r io.Reader) ([]*Hand, error) {
+	hands := make([]*Hand, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var p *psParser
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if text == "" {
+			continue
+		}
+
+		// 每手牌以标题行开始
+		if headerPattern.MatchString(text) {
+			if p != nil {
+				hands = append(hands, p.hand)
+			}
+			p = newPSParser()
+		}
+
+		if p == nil {
+			return nil, fmt.Errorf("line %d: expected a hand header, got %q", line, text)
+		}
+		if err := p.parseLine(text); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if p != nil {
+		hands = append(hands, p.hand)
+	}
+	return hands, nil
+}
+
+// psParser 逐行解析一手 PokerStars 记录
+type psParser struct {
+	hand    *Hand
+	street  poker.GameStage // 当前所处的阶段
+	summary bool            // 是否已进入汇总部分
+	bets    map[string]int  // 当前阶段每名玩家的下注总额
+	cents   bool            // 金额是否为真钱，是时换算为分
+}
+
+func newPSParser() *psParser {
+	return &psParser{
+		hand:   &Hand{Seats: make([]Seat, 0), Actions: make([]Action, 0)},
+		street: poker.GameStagePreflop,
+		bets:   make(map[string]int),
+	}
+}
+
+// parseLine 解析一行记录
+func (p *psParser) parseLine(text string) error {
+	h := p.hand
+	if p.summary {
+		// 记录中只有抽水总额，全部算在主池上
+		if m := rakePattern.FindStringSubmatch(text); m != nil && len(h.Pots) > 0 {
+			rake, err := p.chips(m[1])
+			if err != nil {
+				return err
+			}
+			h.Pots[0].Rake = rake
+			h.Pots[0].Amount += rake
+		}
+		return nil
+	}
+
+	if m := headerPattern.FindStringSubmatch(text); m != nil {
+		h.Id, _ = strconv.Atoi(m[1])
+		h.Limit = m[2] == "Limit"
+		p.cents = strings.ContainsAny(m[3]+m[4], currencySymbols+".")
+		var err error
+		if h.SmallBlind, err = p.chips(m[3]); err != nil {
+			return err
+		}
+		if h.BigBlind, err = p.chips(m[4]); err != nil {
+			return err
+		}
+		h.Time, _ = time.Parse(timeLayout, strings.TrimSpace(m[5]))
+		return nil
+	}
+
+	if m := tablePattern.FindStringSubmatch(text); m != nil {
+		h.Table = m[1]
+		h.MaxSeats, _ = strconv.Atoi(m[2])
+		h.Button, _ = strconv.Atoi(m[3])
+		return nil
+	}
+
+	if m := seatPattern.FindStringSubmatch(text); m != nil {
+		// 暂时离座的玩家不参与本手牌
+		if strings.HasSuffix(text, "is sitting out") {
+			return nil
+		}
+
+		seat, _ := strconv.Atoi(m[1])
+		chips, err := p.chips(m[3])
+		if err != nil {
+			return err
+		}
+		h.Seats = append(h.Seats, Seat{Seat: seat, PlayerId: m[2], Name: m[2], Chips: chips})
+		return nil
+	}
+
+	if m := streetPattern.FindStringSubmatch(text); m != nil {
+		cards, err := parseCards(m[2] + " " + m[3])
+		if err != nil {
+			return err
+		}
+		h.Board = cards
+		p.street++
+		p.bets = make(map[string]int)
+		return nil
+	}
+
+	if m := dealtPattern.FindStringSubmatch(text); m != nil {
+		return p.setHoleCards(m[1], m[2])
+	}
+
+	if m := uncalledPattern.FindStringSubmatch(text); m != nil {
+		amount, err := p.chips(m[1])
+		if err != nil {
+			return err
+		}
+		h.Uncalled = &Refund{PlayerId: m[2], Amount: amount}
+		return nil
+	}
+
+	if m := collectPattern.FindStringSubmatch(text); m != nil {
+		amount, err := p.chips(m[2])
+		if err != nil {
+			return err
+		}
+
+		index := 0
+		switch {
+		case m[4] != "":
+			index, _ = strconv.Atoi(m[4])
+		case m[3] == "side pot":
+			index = 1
+		}
+		for len(h.Pots) <= index {
+			h.Pots = append(h.Pots, Pot{Players: make([]string, 0), Winners: make([]Winner, 0)})
+		}
+		h.Pots[index].Amount += amount
+		h.Pots[index].Winners = append(h.Pots[index].Winners, Winner{PlayerId: m[1], Amount: amount})
+		return nil
+	}
+
+	switch text {
+	case "*** HOLE CARDS ***":
+		return nil
+	case "*** SHOW DOWN ***":
+		h.Showdown = true
+		return nil
+	case "*** SUMMARY ***":
+		p.summary = true
+		return nil
+	}
+
+	// 玩家动作，以 "<玩家名称>: " 开头
+	for _, s := range h.Seats {
+		if rest, ok := strings.CutPrefix(text, s.Name+": "); ok {
+			return p.parseAction(s.PlayerId, rest)
+		}
+	}
+	return nil
+}
+
+// parseAction 解析玩家动作，不支持的动作返回错误
+func (p *psParser) parseAction(playerId, text string) error {
+	text, allIn := strings.CutSuffix(text, " and is all-in")
+	a := Action{Street: p.street.String(), PlayerId: playerId, AllIn: allIn}
+
+	var err error
+	switch {
+	case text == "folds":
+		a.Type = poker.ActionFold.String()
+	case text == "checks":
+		a.Type = poker.ActionCheck.String()
+	case strings.HasPrefix(text, "calls "):
+		a.Type = poker.ActionCall.String()
+		a.Amount, err = p.chips(strings.TrimPrefix(text, "calls "))
+	case strings.HasPrefix(text, "bets "):
+		a.Type = poker.ActionBet.String()
+		a.Amount, err = p.chips(strings.TrimPrefix(text, "bets "))
+		a.RaiseBy = a.Amount
+	case strings.HasPrefix(text, "raises "):
+		m := raisePattern.FindStringSubmatch(text)
+		if m == nil {
+			return fmt.Errorf("invalid raise: %q", text)
+		}
+		if a.RaiseBy, err = p.chips(m[1]); err != nil {
+			return err
+		}
+		a.Type = poker.ActionRaise.String()
+		a.Total, err = p.chips(m[2])
+		a.Amount = a.Total - p.bets[playerId]
+	case strings.HasPrefix(text, "posts the ante "):
+		// 前注不计入本轮的下注
+		a.Type = poker.ActionAnte.String()
+		if a.Amount, err = p.chips(strings.TrimPrefix(text, "posts the ante ")); err != nil {
+			return err
+		}
+		a.Total = p.bets[playerId]
+		p.hand.Ante = max(p.hand.Ante, a.Amount)
+		p.hand.Actions = append(p.hand.Actions, a)
+		return nil
+	case strings.HasPrefix(text, "posts small blind "):
+		a.Type = poker.ActionSmallBlind.String()
+		a.Amount, err = p.chips(strings.TrimPrefix(text, "posts small blind "))
+	case strings.HasPrefix(text, "posts big blind "):
+		a.Type = poker.ActionBigBlind.String()
+		a.Amount, err = p.chips(strings.TrimPrefix(text, "posts big blind "))
+	case strings.HasPrefix(text, "shows "):
+		if m := showsPattern.FindStringSubmatch(text); m != nil {
+			if err := p.setHoleCards(playerId, m[1]); err != nil {
+				return err
+			}
+			p.hand.FindSeat(playerId).Shown = m[2]
+		}
+		return nil
+	case strings.HasPrefix(text, "posts "):
+		return fmt.Errorf("unsupported action: %q", text)
+	default:
+		// 聊天、亮牌、离座等与筹码无关的内容
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	p.bets[playerId] += a.Amount
+	a.Total = p.bets[playerId]
+	p.hand.Actions = append(p.hand.Actions, a)
+	return nil
+}
+
+// setHoleCards 设置玩家的底牌
+func (p *psParser) setHoleCards(playerId, codes string) error {
+	s := p.hand.FindSeat(playerId)
+	if s == nil {
+		return fmt.Errorf("unknown player: %s", playerId)
+	}
+
+	cards, err := parseCards(codes)
+	if err != nil {
+		return err
+	}
+	s.HoleCards = cards
+	return nil
+}
+
+// parseCards 解析以空格分隔的多张牌
+func parseCards(codes string) ([]poker.Card, error) {
+	cards := make([]poker.Card, 0)
+	for _, code := range strings.Fields(codes) {
+		card, err := poker.ParseCard(code)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// chips 解析筹码数，允许带有货币符号和千位分隔符。
+// 真钱的金额换算为分，例如 $0.05 为 5、$2 为 200，筹码数不能带有小数
+func (p *psParser) chips(s string) (int, error) {
+	s = strings.ReplaceAll(strings.TrimLeft(s, currencySymbols), ",", "")
+	whole, frac, decimal := strings.Cut(s, ".")
+	if decimal && (!p.cents || len(frac) == 0 || len(frac) > 2) {
+		return 0, fmt.Errorf("invalid chip amount: %q", s)
+	}
+
+	n, err := strconv.Atoi(whole)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid chip amount: %q", s)
+	}
+	if !p.cents {
+		return n, nil
+	}
+
+	cents := 0
+	if decimal {
+		if cents, err = strconv.Atoi(frac + strings.Repeat("0", 2-len(frac))); err != nil || cents < 0 {
+			return 0, fmt.Errorf("invalid chip amount: %q", s)
+		}
+	}
+	return n*100 + cents, nil
+}
@@ -0,0 +1,271 @@
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// timeLayout PokerStars 手牌记录中的时间格式
+const timeLayout = "2006/01/02 15:04:05 MST"
+
+// streets 需要记录的下注阶段及其在 PokerStars 格式中的名称
+var streets = []struct {
+	stage poker.GameStage
+	name  string
+}{
+	{poker.GameStagePreflop, "HOLE CARDS"},
+	{poker.GameStageFlop, "FLOP"},
+	{poker.GameStageTurn, "TURN"},
+	{poker.GameStageRiver, "RIVER"},
+}
+
+// WritePokerStars 以 PokerStars 的文本格式写出一手牌。
+// 筹码不带货币符号，与 PokerStars 娱乐场的记录格式一致，常见的统计软件都可以直接导入。
+// 统计软件把 Dealt to 行中的玩家当作主角，因此只写出 hero 的底牌，其他玩家的底牌只在摊牌时亮出；
+// hero 为空时以旁观者的视角写出
+func WritePokerStars(w io.Writer, h *Hand, hero string) error {
+	bw := bufio.NewWriter(w)
+	names := make(map[string]string)
+	for _, s := range h.Seats {
+		names[s.PlayerId] = s.Name
+	}
+
+	// 牌局信息和座位
+	game := "No Limit"
+	if h.Limit {
+		game = "Limit"
+	}
+	fmt.Fprintf(bw, "PokerStars Hand #%d:  Hold'em %s (%d/%d) - %s\n",
+		h.Id, game, h.SmallBlind, h.BigBlind, h.Time.UTC().Format(timeLayout))
+	fmt.Fprintf(bw, "Table '%s' %d-max Seat #%d is the button\n", h.Table, h.MaxSeats, h.Button)
+	for _, s := range h.Seats {
+		fmt.Fprintf(bw, "Seat %d: %s (%d in chips)\n", s.Seat, s.Name, s.Chips)
+	}
+
+	// 盲注和每条街的动作
+	folded := make(map[string]string)
+	for _, street := range streets {
+		if street.stage > poker.GameStagePreflop && len(h.StreetBoard(street.stage)) == 0 {
+			break
+		}
+
+		if street.stage == poker.GameStagePreflop {
+			for _, a := range h.Actions {
+				switch a.Type {
+				case poker.ActionAnte.String():
+					fmt.Fprintf(bw, "%s: posts the ante %d%s\n", names[a.PlayerId], a.Amount, allIn(a))
+				case poker.ActionSmallBlind.String(), poker.ActionBigBlind.String():
+					fmt.Fprintf(bw, "%s: posts %s %d%s\n", names[a.PlayerId], a.Type, a.Amount, allIn(a))
+				}
+			}
+			fmt.Fprintln(bw, "*** HOLE CARDS ***")
+			for _, s := range h.Seats {
+				if s.Name == hero && len(s.HoleCards) == 2 {
+					fmt.Fprintf(bw, "Dealt to %s [%s]\n", s.Name, formatCards(s.HoleCards))
+				}
+			}
+		} else {
+			board := h.StreetBoard(street.stage)
+			// 转牌和河牌单独列出新发的一张，之前的公共牌放在前面
+			if len(board) > 3 {
+				fmt.Fprintf(bw, "*** %s *** [%s] [%s]\n", street.name,
+					formatCards(board[:len(board)-1]), formatCards(board[len(board)-1:]))
+			} else {
+				fmt.Fprintf(bw, "*** %s *** [%s]\n", street.name, formatCards(board))
+			}
+		}
+
+		for _, a := range h.Actions {
+			if a.Street != street.stage.String() {
+				continue
+			}
+
+			name := names[a.PlayerId]
+			switch a.Type {
+			case poker.ActionFold.String():
+				fmt.Fprintf(bw, "%s: folds\n", name)
+				folded[a.PlayerId] = a.Street
+			case poker.ActionCheck.String():
+				fmt.Fprintf(bw, "%s: checks\n", name)
+			case poker.ActionCall.String():
+				fmt.Fprintf(bw, "%s: calls %d%s\n", name, a.Amount, allIn(a))
+			case poker.ActionBet.String():
+				fmt.Fprintf(bw, "%s: bets %d%s\n", name, a.Amount, allIn(a))
+			case poker.ActionRaise.String():
+				fmt.Fprintf(bw, "%s: raises %d to %d%s\n", name, a.RaiseBy, a.Total, allIn(a))
+			}
+		}
+	}
+
+	if h.Uncalled != nil {
+		fmt.Fprintf(bw, "Uncalled bet (%d) returned to %s\n", h.Uncalled.Amount, names[h.Uncalled.PlayerId])
+	}
+
+	// 摊牌和奖池分配
+	if h.Showdown {
+		fmt.Fprintln(bw, "*** SHOW DOWN ***")
+		for _, s := range h.Seats {
+			if s.Shown != "" {
+				fmt.Fprintf(bw, "%s: shows [%s] (%s)\n", s.Name, formatCards(s.HoleCards), s.Shown)
+			}
+		}
+	}
+
+	won := make(map[string]int)
+	for i := len(h.Pots) - 1; i >= 0; i-- {
+		for _, w := range h.Pots[i].Winners {
+			fmt.Fprintf(bw, "%s collected %d from %s\n", names[w.PlayerId], w.Amount, potName(h, i))
+			won[w.PlayerId] += w.Amount
+		}
+	}
+
+	// 汇总
+	fmt.Fprintln(bw, "*** SUMMARY ***")
+	total, rake := 0, 0
+	for _, pot := range h.Pots {
+		total += pot.Amount
+		rake += pot.Rake
+	}
+	fmt.Fprintf(bw, "Total pot %d", total)
+	if len(h.Pots) > 1 {
+		for i, pot := range h.Pots {
+			fmt.Fprintf(bw, " %s %d.", capitalize(potName(h, i)), pot.Amount)
+		}
+	}
+	fmt.Fprintf(bw, " | Rake %d\n", rake)
+	if len(h.Board) > 0 {
+		fmt.Fprintf(bw, "Board [%s]\n", formatCards(h.Board))
+	}
+
+	for _, s := range h.Seats {
+		fmt.Fprintf(bw, "Seat %d: %s%s %s\n", s.Seat, s.Name, seatMarkers(h, s), seatSummary(h, s, folded, won))
+	}
+	fmt.Fprintf(bw, "\n\n")
+
+	return bw.Flush()
+}
+
+// allIn 全下时追加的说明
+func allIn(a Action) string {
+	if a.AllIn {
+		return " and is all-in"
+	}
+	return ""
+}
+
+// potName 奖池在 PokerStars 格式中的名称
+func potName(h *Hand, i int) string {
+	switch {
+	case len(h.Pots) == 1:
+		return "pot"
+	case i == 0:
+		return "main pot"
+	case len(h.Pots) == 2:
+		return "side pot"
+	default:
+		return fmt.Sprintf("side pot-%d", i)
+	}
+}
+
+// capitalize 将首字母转为大写
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// seatMarkers 座位在汇总中的庄家、盲注标记
+func seatMarkers(h *Hand, s Seat) string {
+	markers := ""
+	if s.Seat == h.Button {
+		markers += " (button)"
+	}
+	for _, a := range h.Actions {
+		if a.PlayerId != s.PlayerId {
+			continue
+		}
+		if a.Type == poker.ActionSmallBlind.String() || a.Type == poker.ActionBigBlind.String() {
+			markers += fmt.Sprintf(" (%s)", a.Type)
+		}
+	}
+	return markers
+}
+
+// seatSummary 座位在汇总中的结果说明
+func seatSummary(h *Hand, s Seat, folded map[string]string, won map[string]int) string {
+	if street, ok := folded[s.PlayerId]; ok {
+		if street == poker.GameStagePreflop.String() {
+			return "folded before Flop"
+		}
+		return fmt.Sprintf("folded on the %s", street)
+	}
+
+	if s.Shown == "" {
+		return fmt.Sprintf("collected (%d)", won[s.PlayerId])
+	}
+
+	if amount, ok := won[s.PlayerId]; ok {
+		return fmt.Sprintf("showed [%s] and won (%d) with %s", formatCards(s.HoleCards), amount, s.Shown)
+	}
+	return fmt.Sprintf("showed [%s] and lost with %s", formatCards(s.HoleCards), s.Shown)
+}
+
+// formatCards 将多张牌格式化为以空格分隔的代码，例如 "As Kd"
+func formatCards(cards []poker.Card) string {
+	codes := make([]string, len(cards))
+	for i := range cards {
+		codes[i] = cards[i].Code()
+	}
+	return strings.Join(codes, " ")
+}
+
+// rankNames 点数的复数形式
+var rankNames = map[poker.CardRank]string{poker.Two: "Deuces", poker.Six: "Sixes"}
+
+// pluralRank 返回点数的复数形式，例如 "Aces"
+func pluralRank(r poker.CardRank) string {
+	if name, ok := rankNames[r]; ok {
+		return name
+	}
+	return r.String() + "s"
+}
+
+// DescribeHand 返回牌型的英文描述，例如 "a pair of Aces"
+func DescribeHand(hand *poker.Hand) string {
+	tb := hand.TieBreakers
+	switch hand.Rank {
+	case poker.HighCard:
+		return fmt.Sprintf("high card %s", tb[0])
+	case poker.OnePair:
+		return fmt.Sprintf("a pair of %s", pluralRank(tb[0]))
+	case poker.TwoPair:
+		return fmt.Sprintf("two pair, %s and %s", pluralRank(max(tb[0], tb[1])), pluralRank(min(tb[0], tb[1])))
+	case poker.ThreeOfAKind:
+		return fmt.Sprintf("three of a kind, %s", pluralRank(tb[0]))
+	case poker.Straight:
+		return fmt.Sprintf("a straight, %s to %s", lowStraightCard(tb[0]), tb[0])
+	case poker.Flush:
+		return fmt.Sprintf("a flush, %s high", tb[0])
+	case poker.FullHouse:
+		return fmt.Sprintf("a full house, %s full of %s", pluralRank(tb[0]), pluralRank(tb[1]))
+	case poker.FourOfAKind:
+		return fmt.Sprintf("four of a kind, %s", pluralRank(tb[0]))
+	case poker.StraightFlush:
+		return fmt.Sprintf("a straight flush, %s to %s", lowStraightCard(tb[0]), tb[0])
+	default:
+		return "a Royal Flush"
+	}
+}
+
+// lowStraightCard 返回顺子中最小的牌，A-5 顺子的最小牌为 A
+func lowStraightCard(high poker.CardRank) poker.CardRank {
+	if high == poker.Five {
+		return poker.Ace
+	}
+	return high - 4
+}
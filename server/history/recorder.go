@@ -0,0 +1,149 @@
+package history
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Recorder 手牌记录器。
+// 作为牌局观察者挂在 poker.Game 上，每手牌结束时以 PokerStars 格式写出手牌记录，
+// 并可同时写出包含所有底牌和牌堆顺序的 JSON 记录，用于重现牌局
+type Recorder struct {
+	Table      string                      // 牌桌名称
+	Writer     io.Writer                   // 旁观者视角的 PokerStars 格式记录的输出，每手牌只调用一次 Write
+	JSONWriter io.Writer                   // JSON 格式记录的输出，为 nil 时不写出
+	Heroes     func(name string) io.Writer // 获取以人类玩家为主角的 PokerStars 格式记录的输出，为 nil 时不写出
+	hand       *Hand                       // 正在记录的手牌
+}
+
+// NewRecorder 创建手牌记录器
+func NewRecorder(table string, w io.Writer) *Recorder {
+	return &Recorder{Table: table, Writer: w}
+}
+
+// OnHandStart 记录座位、庄家和盲注信息
+func (r *Recorder) OnHandStart(g *poker.Game) {
+	t := g.Table
+	r.hand = &Hand{
+		Id:         g.HandId,
+		Time:       time.Now(),
+		Table:      r.Table,
+		MaxSeats:   t.Seats.Len(),
+		SmallBlind: t.MinBet,
+		BigBlind:   t.MinBet * 2,
+		Ante:       t.Ante,
+		Limit:      t.Limit,
+		Seats:      make([]Seat, 0),
+		Deck:       append([]poker.Card(nil), g.Deck.Cards...),
+		Actions:    make([]Action, 0),
+	}
+
+	s := t.Seats
+	for i := 0; i < s.Len(); i++ {
+		if s == t.Dealer {
+			r.hand.Button = s.Index() + 1
+		}
+
+		if p := s.Player; p != nil && p.Status == poker.PlayerActive {
+			r.hand.Seats = append(r.hand.Seats, Seat{
+				Seat:     s.Index() + 1,
+				PlayerId: p.Id,
+				Name:     p.Name,
+				Chips:    p.Chips,
+			})
+		}
+		s = s.Next()
+	}
+}
+
+// OnAction 记录玩家的动作
+func (r *Recorder) OnAction(g *poker.Game, a poker.Action) {
+	if r.hand == nil {
+		return
+	}
+
+	r.hand.Actions = append(r.hand.Actions, Action{
+		Street:   a.Stage.String(),
+		PlayerId: a.Player.Id,
+		Type:     a.Type.String(),
+		Amount:   a.Amount,
+		Total:    a.Total,
+		RaiseBy:  a.RaiseBy,
+		AllIn:    a.AllIn,
+	})
+}
+
+// OnStage 公共牌在手牌结束时统一记录
+func (r *Recorder) OnStage(g *poker.Game, stage poker.GameStage) {}
+
+// OnHandEnd 记录位置、底牌、公共牌和奖池分配，并写出手牌记录
+func (r *Recorder) OnHandEnd(g *poker.Game, result *poker.HandResult) {
+	h := r.hand
+	if h == nil {
+		return
+	}
+	r.hand = nil
+
+	h.Board = g.Table.GetBoard()
+	h.Showdown = result.Showdown
+	if result.Uncalled != nil {
+		h.Uncalled = &Refund{PlayerId: result.Uncalled.Player.Id, Amount: result.Uncalled.Total}
+	}
+
+	for i := range h.Seats {
+		seat := &h.Seats[i]
+		seat.Position = g.Table.Position(g.Table.SeatAt(seat.Seat - 1)).String()
+		p := g.PlayerMap[seat.PlayerId]
+		if p.HoleCards[0] != nil && p.HoleCards[1] != nil {
+			seat.HoleCards = []poker.Card{*p.HoleCards[0], *p.HoleCards[1]}
+		}
+
+		if result.Showdown && !p.HasFolded {
+			seat.Shown = DescribeHand(poker.GetBestHand(p, g.Table))
+		}
+	}
+
+	for _, pr := range result.Pots {
+		pot := Pot{Amount: pr.Total, Players: make([]string, 0), Winners: make([]Winner, 0), Rake: pr.Rake}
+		for _, p := range pr.Players {
+			pot.Players = append(pot.Players, p.Id)
+		}
+		for _, w := range pr.Winners {
+			pot.Winners = append(pot.Winners, Winner{PlayerId: w.Player.Id, Amount: w.ChipsWon})
+		}
+		h.Pots = append(h.Pots, pot)
+	}
+
+	r.write(r.Writer, h, func(w io.Writer, h *Hand) error { return WritePokerStars(w, h, "") })
+	r.write(r.JSONWriter, h, WriteJSON)
+
+	// 每名参与这手牌的人类玩家各自得到一份只有自己底牌的记录
+	if r.Heroes != nil {
+		for _, s := range h.Seats {
+			if p := g.PlayerMap[s.PlayerId]; p.IsHuman {
+				r.write(r.Heroes(s.Name), h, func(w io.Writer, h *Hand) error { return WritePokerStars(w, h, s.Name) })
+			}
+		}
+	}
+}
+
+// write 使用指定格式写出手牌记录。
+// 先写入缓冲区，保证一手牌通过一次 Write 完整写出
+func (r *Recorder) write(w io.Writer, h *Hand, format func(io.Writer, *Hand) error) {
+	if w == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := format(&buf, h); err != nil {
+		log.Printf("error: format hand #%d: %v", h.Id, err)
+		return
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("error: write hand #%d: %v", h.Id, err)
+	}
+}
@@ -0,0 +1,109 @@
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderPokerStars(t *testing.T) {
+	var buf, records bytes.Buffer
+	g := poker.NewGame()
+	recorder := NewRecorder("Main", &buf)
+	recorder.JSONWriter = &records
+	heroes := make(map[string]*bytes.Buffer)
+	recorder.Heroes = func(name string) io.Writer {
+		heroes[name] = &bytes.Buffer{}
+		return heroes[name]
+	}
+	g.Observers = append(g.Observers, recorder)
+
+	s := g.Table.Seats
+	for i := 0; i < 3; i++ {
+		require.NoError(t, g.TakeSeat(s.Player.Id, fmt.Sprintf("p%d", i+1), 500, true))
+		s = s.Next()
+	}
+
+	require.NoError(t, g.StartHand())
+	require.NoError(t, g.Raise(30))
+	require.NoError(t, g.Call())
+	require.NoError(t, g.Fold())
+	require.NoError(t, g.Check())
+	require.NoError(t, g.Raise(40))
+	require.NoError(t, g.Fold())
+
+	out := buf.String()
+	assert.Contains(t, out, "PokerStars Hand #1:  Hold'em No Limit (5/10)")
+	assert.Contains(t, out, "Table 'Main' 6-max Seat #1 is the button\n")
+	assert.Contains(t, out, "Seat 2: p2 (500 in chips)\n")
+	assert.Contains(t, out, "p2: posts small blind 5\np3: posts big blind 10\n*** HOLE CARDS ***\n")
+	assert.Contains(t, out, "p1: raises 20 to 30\np2: calls 25\np3: folds\n*** FLOP *** [")
+	assert.Contains(t, out, "p2: checks\np1: bets 40\np2: folds\nUncalled bet (40) returned to p1\np1 collected 70 from pot\n")
+	assert.Contains(t, out, "Total pot 70 | Rake 0\n")
+	assert.Contains(t, out, "Seat 1: p1 (button) collected (70)\n")
+	assert.Contains(t, out, "Seat 2: p2 (small blind) folded on the Flop\n")
+	assert.Contains(t, out, "Seat 3: p3 (big blind) folded before Flop\n")
+	assert.NotContains(t, out, "Dealt to")
+
+	// 每名玩家的记录中只有自己的底牌
+	require.Len(t, heroes, 3)
+	for name, hero := range heroes {
+		assert.Equal(t, 1, strings.Count(hero.String(), "Dealt to"), name)
+		assert.Contains(t, hero.String(), "*** HOLE CARDS ***\nDealt to "+name+" [")
+	}
+
+	hands, err := ReadJSON(&records)
+	require.NoError(t, err)
+	require.Len(t, hands, 1)
+	for i, want := range []string{"BTN", "SB", "BB"} {
+		assert.Equal(t, want, hands[0].Seats[i].Position)
+	}
+}
+
+func TestDescribeHand(t *testing.T) {
+	tests := []struct {
+		hand poker.Hand
+		want string
+	}{
+		{poker.Hand{Rank: poker.OnePair, TieBreakers: []poker.CardRank{poker.Six}}, "a pair of Sixes"},
+		{poker.Hand{Rank: poker.TwoPair, TieBreakers: []poker.CardRank{poker.Two, poker.King, poker.Ace}}, "two pair, Kings and Deuces"},
+		{poker.Hand{Rank: poker.Straight, TieBreakers: []poker.CardRank{poker.Five}}, "a straight, Ace to Five"},
+		{poker.Hand{Rank: poker.FullHouse, TieBreakers: []poker.CardRank{poker.Ten, poker.Four}}, "a full house, Tens full of Fours"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DescribeHand(&tt.hand))
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	name := func(i int) string {
+		return filepath.Join(dir, fmt.Sprintf("Main_%s_%d.txt", time.Now().Format("20060102"), i))
+	}
+
+	// 重新打开没有写满的文件时，写不下这次的内容就换到下一个文件
+	require.NoError(t, os.WriteFile(name(1), []byte("123456"), 0o644))
+	f := NewRotatingFile(dir, "Main", ".txt", 10)
+	_, err := f.Write([]byte("abcde"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("fgh"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	first, err := os.ReadFile(name(1))
+	require.NoError(t, err)
+	assert.Equal(t, "123456", string(first))
+	second, err := os.ReadFile(name(2))
+	require.NoError(t, err)
+	assert.Equal(t, "abcdefgh", string(second))
+}
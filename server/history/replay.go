@@ -0,0 +1,231 @@
+package history
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Replayer 在 poker.Game 中逐步重现一手牌。
+// 使用与记录相同的座位、筹码和牌堆顺序，依次执行记录中的动作，
+// 并校验引擎产生的每个动作与记录一致
+type Replayer struct {
+	Hand    *Hand                    // 被重现的手牌
+	Game    *poker.Game              // 重现牌局的游戏
+	players map[string]*poker.Player // 记录中的玩家标识到游戏中玩家的映射
+	actions []poker.Action           // 引擎产生的动作
+	antes   []Action                 // 记录中的前注，按引擎收取的顺序校验
+	next    int                      // 下一个需要执行的记录动作
+}
+
+// NewReplayer 根据手牌记录创建游戏并开始这手牌，盲注由引擎自动收取
+func NewReplayer(h *Hand) (*Replayer, error) {
+	if h.BigBlind != h.SmallBlind*2 {
+		return nil, fmt.Errorf("hand #%d: big blind (%d) must be twice the small blind (%d)", h.Id, h.BigBlind, h.SmallBlind)
+	}
+
+	deck, err := h.deck()
+	if err != nil {
+		return nil, fmt.Errorf("hand #%d: %w", h.Id, err)
+	}
+
+	g := poker.NewGameWithSeats(h.MaxSeats)
+	g.HandId = h.Id - 1
+	g.DeckSource = func() *poker.Deck { return deck }
+	if err := g.SetBlinds(h.SmallBlind, h.Ante); err != nil {
+		return nil, fmt.Errorf("hand #%d: %w", h.Id, err)
+	}
+	if err := g.SetLimit(h.Limit); err != nil {
+		return nil, fmt.Errorf("hand #%d: %w", h.Id, err)
+	}
+
+	r := &Replayer{Hand: h, Game: g, players: make(map[string]*poker.Player)}
+	for _, a := range h.Actions {
+		if a.Type == poker.ActionAnte.String() {
+			r.antes = append(r.antes, a)
+		}
+	}
+	g.Observers = append(g.Observers, r)
+
+	// 按记录的座位号入座
+	for _, s := range h.Seats {
+		if s.Seat < 1 || s.Seat > h.MaxSeats {
+			return nil, fmt.Errorf("hand #%d: invalid seat %d", h.Id, s.Seat)
+		}
+
+		p := g.Table.SeatAt(s.Seat - 1).Player
+		if err := g.TakeSeat(p.Id, s.Name, s.Chips, true); err != nil {
+			return nil, fmt.Errorf("hand #%d: %w", h.Id, err)
+		}
+		r.players[s.PlayerId] = p
+	}
+
+	// 开始新的一手牌时庄家会移动到下一个活跃座位，因此先放在记录中庄家的上一个座位
+	g.Table.Dealer = g.Table.SeatAt((h.Button - 2 + h.MaxSeats) % h.MaxSeats)
+
+	if err := g.StartHand(); err != nil {
+		return nil, fmt.Errorf("hand #%d: %w", h.Id, err)
+	}
+	if err := r.verify(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Replay 完整重现一手牌，并校验所有玩家结束时的筹码与记录一致
+func Replay(h *Hand) (*poker.Game, error) {
+	r, err := NewReplayer(h)
+	if err != nil {
+		return nil, err
+	}
+
+	for !r.Done() {
+		if err := r.Step(); err != nil {
+			return r.Game, err
+		}
+	}
+	return r.Game, r.Verify()
+}
+
+// Done 是否已经执行完所有记录的动作
+func (r *Replayer) Done() bool {
+	return r.next >= len(r.Hand.Actions)
+}
+
+// Step 执行下一个记录的动作
+func (r *Replayer) Step() error {
+	if r.Done() {
+		return fmt.Errorf("hand #%d: no more actions to replay", r.Hand.Id)
+	}
+
+	a := r.Hand.Actions[r.next]
+	g := r.Game
+	if p := r.players[a.PlayerId]; g.CurrentSeat.Player != p {
+		return fmt.Errorf("hand #%d: action %d: expected %s to act, but it is %s's turn",
+			r.Hand.Id, r.next, a.PlayerId, g.CurrentSeat.Player.Name)
+	}
+
+	var err error
+	switch a.Type {
+	case poker.ActionFold.String():
+		err = g.Fold()
+	case poker.ActionCheck.String():
+		err = g.Check()
+	case poker.ActionCall.String():
+		err = g.Call()
+	case poker.ActionBet.String(), poker.ActionRaise.String():
+		err = g.Raise(a.Total)
+	default:
+		err = fmt.Errorf("unexpected action %q", a.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("hand #%d: action %d: %w", r.Hand.Id, r.next, err)
+	}
+
+	return r.verify()
+}
+
+// Verify 校验这手牌已经结束，且所有玩家的筹码与记录一致
+func (r *Replayer) Verify() error {
+	if r.Game.Stage != poker.GameStageShowdown {
+		return fmt.Errorf("hand #%d: the hand did not finish, stopped at the %s stage", r.Hand.Id, r.Game.Stage)
+	}
+
+	for id, chips := range r.Hand.FinalChips() {
+		if p := r.players[id]; p.Chips != chips {
+			return fmt.Errorf("hand #%d: %s finished with %d chips, expected %d", r.Hand.Id, p.Name, p.Chips, chips)
+		}
+	}
+	return nil
+}
+
+// verify 校验引擎产生的动作与记录一致，并将已校验的动作标记为已执行
+func (r *Replayer) verify() error {
+	for ; r.next < len(r.actions); r.next++ {
+		if r.next >= len(r.Hand.Actions) {
+			return fmt.Errorf("hand #%d: the engine produced more actions than recorded", r.Hand.Id)
+		}
+
+		got, want := r.actions[r.next], r.Hand.Actions[r.next]
+		// 前注同时收取，不同的记录列出的顺序不同，只校验每名玩家的前注
+		if got.Type == poker.ActionAnte && want.Type == poker.ActionAnte.String() {
+			i := slices.IndexFunc(r.antes, func(a Action) bool { return r.players[a.PlayerId] == got.Player })
+			if i >= 0 {
+				want = r.antes[i]
+				r.antes = slices.Delete(r.antes, i, i+1)
+			}
+		}
+		if r.players[want.PlayerId] != got.Player || want.Type != got.Type.String() ||
+			want.Amount != got.Amount || want.Total != got.Total {
+			return fmt.Errorf("hand #%d: action %d: recorded %s %s %d, replayed %s %s %d",
+				r.Hand.Id, r.next, want.PlayerId, want.Type, want.Amount, got.Player.Name, got.Type, got.Amount)
+		}
+	}
+	return nil
+}
+
+// OnHandStart 重现时无需处理
+func (r *Replayer) OnHandStart(g *poker.Game) {}
+
+// OnAction 收集引擎产生的动作
+func (r *Replayer) OnAction(g *poker.Game, a poker.Action) {
+	r.actions = append(r.actions, a)
+}
+
+// OnStage 重现时无需处理
+func (r *Replayer) OnStage(g *poker.Game, stage poker.GameStage) {}
+
+// OnHandEnd 重现时无需处理
+func (r *Replayer) OnHandEnd(g *poker.Game, result *poker.HandResult) {}
+
+// deck 获取记录中的牌堆顺序。
+// 没有记录牌堆时，按照引擎的发牌顺序由底牌和公共牌推算，其余位置用未出现的牌补齐
+func (h *Hand) deck() (*poker.Deck, error) {
+	if len(h.Deck) > 0 {
+		return poker.NewDeckFromCards(h.Deck)
+	}
+
+	cards := make([]poker.Card, 0, poker.DeckSize)
+	used := make(map[poker.Card]bool)
+	unknown := make([]int, 0)
+
+	seats := append([]Seat(nil), h.Seats...)
+	sort.Slice(seats, func(i, j int) bool { return seats[i].Seat < seats[j].Seat })
+
+	// 引擎按座位顺序先给每名玩家发第一张底牌，再发第二张
+	for round := 0; round < 2; round++ {
+		for _, s := range seats {
+			if len(s.HoleCards) != 2 {
+				unknown = append(unknown, len(cards))
+				cards = append(cards, poker.Card{})
+				continue
+			}
+			cards = append(cards, s.HoleCards[round])
+			used[s.HoleCards[round]] = true
+		}
+	}
+
+	for _, c := range h.Board {
+		cards = append(cards, c)
+		used[c] = true
+	}
+
+	// 用剩余的牌补齐未知的底牌和牌堆
+	remaining := make([]poker.Card, 0)
+	for _, c := range poker.NewDeck().Cards {
+		if !used[c] {
+			remaining = append(remaining, c)
+		}
+	}
+	if len(remaining) < len(unknown) {
+		return nil, fmt.Errorf("not enough cards left to deal")
+	}
+	for i, index := range unknown {
+		cards[index] = remaining[i]
+	}
+	cards = append(cards, remaining[len(unknown):]...)
+
+	return poker.NewDeckFromCards(cards)
+}
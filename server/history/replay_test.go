@@ -0,0 +1,140 @@
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// playHands 在 4 人桌上随机行动打完 n 手牌，并同时写出两种格式的记录
+func playHands(t *testing.T, n int, limit bool) (text, jsonl *bytes.Buffer) {
+	text, jsonl = &bytes.Buffer{}, &bytes.Buffer{}
+	recorder := NewRecorder("Replay", text)
+	recorder.JSONWriter = jsonl
+
+	g := poker.NewGame()
+	g.Observers = append(g.Observers, recorder)
+	require.NoError(t, g.SetBlinds(5, 2))
+	require.NoError(t, g.SetLimit(limit))
+	s := g.Table.Seats
+	for i := 0; i < 4; i++ {
+		require.NoError(t, g.TakeSeat(s.Player.Id, fmt.Sprintf("p%d", i+1), 300+100*i, true))
+		s = s.Next()
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		if err := g.StartHand(); err != nil {
+			break
+		}
+
+		for g.IsPlayerStage() {
+			p, b := g.CurrentSeat.Player, g.BettingRound
+			switch r := rng.Intn(10); {
+			case r < 2 && p.CanFold(b):
+				require.NoError(t, g.Fold())
+			case r < 4 && b.Limit > 0 && p.CanRaise(b) && p.Chips > b.CallAmount-b.Bets[p]:
+				require.NoError(t, g.Raise(min(b.Bets[p]+p.Chips, b.CallAmount+b.Limit)))
+			case r < 4 && b.Limit == 0 && p.Chips > b.CallAmount-b.Bets[p]:
+				require.NoError(t, g.Raise(min(b.Bets[p]+p.Chips, b.CallAmount+b.RaiseByAmount+rng.Intn(50))))
+			case p.CanCheck(b):
+				require.NoError(t, g.Check())
+			default:
+				require.NoError(t, g.Call())
+			}
+		}
+	}
+	return text, jsonl
+}
+
+func TestReplayJSON(t *testing.T) {
+	_, jsonl := playHands(t, 50, false)
+
+	hands, err := ReadJSON(jsonl)
+	require.NoError(t, err)
+	require.NotEmpty(t, hands)
+
+	for _, h := range hands {
+		_, err := Replay(h)
+		assert.NoError(t, err)
+	}
+}
+
+func TestReplayPokerStars(t *testing.T) {
+	text, _ := playHands(t, 50, false)
+
+	hands, err := ParsePokerStars(text)
+	require.NoError(t, err)
+	require.NotEmpty(t, hands)
+
+	for _, h := range hands {
+		_, err := Replay(h)
+		assert.NoError(t, err)
+	}
+}
+
+func TestReplayLimit(t *testing.T) {
+	text, jsonl := playHands(t, 30, true)
+	assert.Contains(t, text.String(), "Hold'em Limit (5/10)")
+
+	parsed, err := ParsePokerStars(bytes.NewReader(text.Bytes()))
+	require.NoError(t, err)
+	records, err := ReadJSON(jsonl)
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+
+	for _, h := range append(parsed, records...) {
+		assert.True(t, h.Limit)
+		g, err := Replay(h)
+		assert.NoError(t, err)
+		assert.True(t, g.Table.Limit)
+	}
+}
+
+func TestReplayDetectsMismatch(t *testing.T) {
+	_, jsonl := playHands(t, 1, false)
+	hands, err := ReadJSON(jsonl)
+	require.NoError(t, err)
+
+	h := hands[0]
+	h.Pots[0].Winners[0].Amount += 10
+	_, err = Replay(h)
+	assert.Error(t, err)
+}
+
+func TestParsePokerStarsFixtures(t *testing.T) {
+	parse := func(name string) *Hand {
+		f, err := os.Open(filepath.Join("testdata", name))
+		require.NoError(t, err)
+		defer f.Close()
+		hands, err := ParsePokerStars(f)
+		require.NoError(t, err)
+		require.Len(t, hands, 1)
+		return hands[0]
+	}
+
+	// 锦标赛的记录，金额为筹码
+	h := parse("tournament.txt")
+	assert.Equal(t, 208958160478, h.Id)
+	assert.Equal(t, "2702413640 1", h.Table)
+	assert.Equal(t, []int{15, 30, 5}, []int{h.SmallBlind, h.BigBlind, h.Ante})
+	assert.Equal(t, 1470, h.FindSeat("Bob").Chips)
+	_, err := Replay(h)
+	assert.NoError(t, err)
+
+	// 真钱现金桌的记录，金额换算为分
+	h = parse("cash_usd.txt")
+	assert.Equal(t, []int{1, 2, 0}, []int{h.SmallBlind, h.BigBlind, h.Ante})
+	assert.Equal(t, []int{200, 196, 217}, []int{h.Seats[0].Chips, h.Seats[1].Chips, h.Seats[2].Chips})
+	assert.Equal(t, 84, h.Pots[0].Amount)
+	g, err := Replay(h)
+	require.NoError(t, err)
+	assert.Equal(t, 239, g.PlayerMap[g.Table.SeatAt(2).Player.Id].Chips)
+}
@@ -0,0 +1,145 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// defaultMaxFileSize 单个手牌记录文件的默认大小上限
+const defaultMaxFileSize int64 = 10 << 20
+
+// RotatingFile 按牌桌写入的滚动文件。
+// 每天使用新的文件，当天的文件超过大小上限时再切换到下一个序号，
+// 文件名形如 "<table>_20060102_1.txt"
+type RotatingFile struct {
+	Dir     string // 文件所在目录
+	Table   string // 牌桌名称，作为文件名前缀
+	Ext     string // 文件扩展名，例如 ".txt"
+	MaxSize int64  // 单个文件的大小上限
+
+	mu    sync.Mutex
+	file  *os.File // 当前写入的文件
+	date  string   // 当前文件对应的日期
+	index int      // 当前文件在当天的序号
+	size  int64    // 当前文件已写入的字节数
+}
+
+// NewRotatingFile 创建滚动文件，maxSize 不大于 0 时使用默认上限
+func NewRotatingFile(dir, table, ext string, maxSize int64) *RotatingFile {
+	if maxSize <= 0 {
+		maxSize = defaultMaxFileSize
+	}
+	return &RotatingFile{Dir: dir, Table: table, Ext: ext, MaxSize: maxSize}
+}
+
+// Write 写入数据，必要时先切换到新文件。
+// 单次写入的内容不会被拆分到两个文件中
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	date := time.Now().Format("20060102")
+	if f.file == nil || f.date != date || f.size+int64(len(p)) > f.MaxSize {
+		if err := f.rotate(date, int64(len(p))); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close 关闭当前文件
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// rotate 关闭当前文件，并打开当天下一个还能写下 pending 个字节的文件
+func (f *RotatingFile) rotate(date string, pending int64) error {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return err
+	}
+
+	if f.date != date {
+		f.date = date
+		f.index = 0
+	}
+
+	for {
+		f.index++
+		name := filepath.Join(f.Dir, fmt.Sprintf("%s_%s_%d%s", f.Table, f.date, f.index, f.Ext))
+
+		info, err := os.Stat(name)
+		if err == nil && info.Size() > 0 && info.Size()+pending > f.MaxSize {
+			continue
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		f.file = file
+		f.size = 0
+		if info != nil {
+			f.size = info.Size()
+		}
+		return nil
+	}
+}
+
+// PlayerFiles 按玩家写入的滚动文件，每名玩家使用自己的一组文件，文件名以玩家名称为前缀
+type PlayerFiles struct {
+	Dir     string // 文件所在目录
+	Ext     string // 文件扩展名，例如 ".txt"
+	MaxSize int64  // 单个文件的大小上限
+
+	mu    sync.Mutex
+	files map[string]*RotatingFile // 每名玩家的滚动文件，key 为玩家名称
+}
+
+// NewPlayerFiles 创建按玩家写入的滚动文件，maxSize 不大于 0 时使用默认上限
+func NewPlayerFiles(dir, ext string, maxSize int64) *PlayerFiles {
+	return &PlayerFiles{Dir: dir, Ext: ext, MaxSize: maxSize, files: make(map[string]*RotatingFile)}
+}
+
+// Writer 获取玩家的滚动文件，玩家名称中不能用于文件名的字符替换为下划线
+func (f *PlayerFiles) Writer(name string) io.Writer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, ok := f.files[name]
+	if !ok {
+		prefix := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+				return r
+			}
+			return '_'
+		}, name)
+		file = NewRotatingFile(f.Dir, prefix, f.Ext, f.MaxSize)
+		f.files[name] = file
+	}
+	return file
+}
@@ -6,17 +6,26 @@ import (
 )
 
 func main() {
+	// 设置 Gin 模式，调试模式下游戏中心会在每个动作之后校验牌局状态
+	gin.SetMode(gin.DebugMode)
+
 	// 创建新的游戏中心
 	hub := server.NewHub()
 	go hub.Run()
 
-	// 设置 Gin 模式
-	gin.SetMode(gin.DebugMode)
 	r := gin.Default()
 
 	// 处理 WebSocket 连接
 	r.GET("/ws", func(c *gin.Context) { server.ServeWs(hub, c.Writer, c.Request) })
 
+	// 玩家的长期统计，用于个人主页
+	r.GET("/stats", func(c *gin.Context) { server.ServeStats(hub, c.Writer, c.Request) })
+	r.GET("/stats/:name", func(c *gin.Context) { server.ServeProfile(hub, c.Writer, c.Request, c.Param("name")) })
+	r.GET("/stats/:name/sessions", func(c *gin.Context) { server.ServeSessions(hub, c.Writer, c.Request, c.Param("name")) })
+
+	// 开始一场由游戏中心主持的比赛
+	r.POST("/tournament", func(c *gin.Context) { server.ServeTournament(hub, c.Writer, c.Request) })
+
 	// 启动 HTTP 服务器
 	r.Run(":8080")
 }
@@ -0,0 +1,31 @@
+package poker
+
+// ActionType 玩家动作的枚举类型
+type ActionType int
+
+const (
+	ActionFold       ActionType = iota // 弃牌
+	ActionCheck                        // 过牌
+	ActionCall                         // 跟注
+	ActionBet                          // 下注
+	ActionRaise                        // 加注
+	ActionSmallBlind                   // 小盲注
+	ActionBigBlind                     // 大盲注
+	ActionAnte                         // 前注
+)
+
+// String 返回动作的英文名称
+func (a ActionType) String() string {
+	return [...]string{"fold", "check", "call", "bet", "raise", "small blind", "big blind", "ante"}[a]
+}
+
+// Action 记录玩家在牌局中执行的一次动作
+type Action struct {
+	Player  *Player    // 执行动作的玩家
+	Stage   GameStage  // 动作发生的阶段
+	Type    ActionType // 动作类型
+	Amount  int        // 本次动作投入的筹码数
+	Total   int        // 动作完成后玩家在本轮的下注总额
+	RaiseBy int        // 下注或加注时，超出原跟注金额的部分
+	AllIn   bool       // 是否因本次动作全下
+}
@@ -0,0 +1,437 @@
+package poker
+
+import (
+	"fmt"
+	"slices"
+)
+
+// emit 应用事件并记录到事件日志，然后通知监听者和观察者
+func (g *Game) emit(e Event) error {
+	if err := g.apply(e); err != nil {
+		return err
+	}
+	if _, ok := e.(HandStarted); ok && g.HandEvents {
+		g.Events = nil
+	}
+	g.Events = append(g.Events, e)
+
+	for _, l := range g.Listeners {
+		l.OnEvent(g, e)
+	}
+	g.notify(e)
+	return nil
+}
+
+// apply 将事件应用到牌局状态上。
+// 这是唯一修改牌局状态的地方，事件不合法时返回错误
+func (g *Game) apply(e Event) error {
+	if _, ok := e.(GameCreated); !ok && g.Table == nil {
+		return fmt.Errorf("the game has not been created yet")
+	}
+
+	switch e := e.(type) {
+	case GameCreated:
+		seats := NewSeat(len(e.PlayerIds))
+		playerMap := make(map[string]*Player)
+		for _, id := range e.PlayerIds {
+			seats.Player = &Player{
+				Id:      id,            // 玩家 id
+				Status:  PlayerVacated, // 玩家状态为离开
+				IsHuman: false,         // 玩家是否为真人
+			}
+			playerMap[id] = seats.Player
+			seats = seats.Next()
+		}
+
+		g.Stage = GameStageWaiting          // 游戏阶段为等待阶段
+		g.Deck = NewDeck()                  // 创建牌堆
+		g.CurrentSeat = seats.Next()        // 获取当前座位
+		g.Table = NewTable(NewPot(), seats) // 创建牌桌
+		g.PlayerMap = playerMap             // 玩家映射
+
+	case BlindsSet:
+		if g.IsPlayerStage() {
+			return fmt.Errorf("cannot change the blinds during the %s stage", g.Stage)
+		}
+		if e.SmallBlind <= 0 {
+			return fmt.Errorf("the small blind must be positive, got %d", e.SmallBlind)
+		}
+		if e.Ante < 0 {
+			return fmt.Errorf("the ante cannot be negative, got %d", e.Ante)
+		}
+		g.Table.MinBet = e.SmallBlind
+		g.Table.Ante = e.Ante
+		g.Table.Limit = e.Limit
+
+	case PlayerSeated:
+		p, err := g.playerAt(e.Seat)
+		if err != nil {
+			return err
+		}
+		if p.Status != PlayerVacated {
+			return fmt.Errorf("seat %s is already taken by %s", p.Id, p.Name)
+		}
+		if e.Chips <= 0 {
+			return fmt.Errorf("%s must bring chips to the table", e.Name)
+		}
+
+		p.Name = e.Name
+		p.Chips = e.Chips
+		g.chips += e.Chips
+		p.IsHuman = e.IsHuman
+		p.Status = PlayerActive
+
+		// 在一手牌进行中入座的玩家从下一手牌开始参与
+		p.HasFolded = g.IsPlayerStage()
+
+	case PlayerSatOut:
+		p, err := g.playerAt(e.Seat)
+		if err != nil {
+			return err
+		}
+		p.Status = PlayerSittingOut
+
+	case PlayerLeft:
+		p, err := g.playerAt(e.Seat)
+		if err != nil {
+			return err
+		}
+		if p.Status == PlayerVacated {
+			return fmt.Errorf("seat %s is already empty", p.Id)
+		}
+		if g.IsPlayerStage() && p.Status == PlayerActive {
+			return fmt.Errorf("%s cannot leave during the %s stage", p.Name, g.Stage)
+		}
+
+		g.chips -= p.Chips
+		*p = Player{Id: p.Id, Status: PlayerVacated}
+
+	case ChipsAdded:
+		p, err := g.playerAt(e.Seat)
+		if err != nil {
+			return err
+		}
+		if p.Status == PlayerVacated {
+			return fmt.Errorf("seat %s is empty", p.Id)
+		}
+		if e.Amount <= 0 {
+			return fmt.Errorf("the amount of chips to add must be positive, got %d", e.Amount)
+		}
+		if g.IsPlayerStage() && p.Status == PlayerActive && !p.HasFolded {
+			return fmt.Errorf("%s cannot add chips while playing a hand", p.Name)
+		}
+
+		p.Chips += e.Amount
+		g.chips += e.Amount
+		if p.Status == PlayerSittingOut {
+			p.Status = PlayerActive
+			p.HasFolded = g.IsPlayerStage()
+		}
+
+	case HandStarted:
+		deck, err := NewDeckFromCards(e.Deck)
+		if err != nil {
+			return err
+		}
+
+		// 重置牌桌和玩家状态
+		t := g.Table
+		t.ClearBoard()
+		for _, p := range g.PlayerMap {
+			p.HasFolded = false
+			p.HoleCards = [2]*Card{}
+		}
+
+		g.HandId = e.HandId
+		g.Deck = deck
+		t.Dealer = t.SeatAt(e.Dealer)
+		t.SmallBlind = t.SeatAt(e.SmallBlind)
+		t.BigBlind = t.SeatAt(e.BigBlind)
+
+		// 即使大盲注在前注中已经全下，其他玩家仍需跟注完整的大盲注
+		round, err := NewBettingRound(t.SmallBlind, t.MinBet*2, t.MinBet*2)
+		if err != nil {
+			return err
+		}
+		round.Limit, round.Raises = t.LimitBet(GameStagePreflop), 1
+		g.BettingRound = round
+		g.Stage = GameStagePreflop
+		g.CurrentSeat = t.BigBlind
+		g.Result = &HandResult{}
+
+	case BlindPosted:
+		t := g.Table
+		switch {
+		case e.Type == ActionAnte:
+			p, err := g.playerAt(e.Seat)
+			if err != nil {
+				return err
+			}
+			if p.Status != PlayerActive || e.Amount != min(t.Ante, p.Chips) {
+				return fmt.Errorf("%s should post an ante of %d, got %d", p.Name, min(t.Ante, p.Chips), e.Amount)
+			}
+			return t.TakeAnte(p)
+		case e.Type == ActionSmallBlind && e.Seat == t.SmallBlind.Index():
+			if e.Amount != min(t.MinBet, t.SmallBlind.Player.Chips) {
+				return fmt.Errorf("the small blind should be %d, got %d", min(t.MinBet, t.SmallBlind.Player.Chips), e.Amount)
+			}
+			return t.TakeSmallBlind(g.BettingRound)
+		case e.Type == ActionBigBlind && e.Seat == t.BigBlind.Index():
+			if e.Amount != min(t.MinBet*2, t.BigBlind.Player.Chips) {
+				return fmt.Errorf("the big blind should be %d, got %d", min(t.MinBet*2, t.BigBlind.Player.Chips), e.Amount)
+			}
+			return t.TakeBigBlind(g.BettingRound)
+		default:
+			return fmt.Errorf("seat %d cannot post the %s", e.Seat, e.Type)
+		}
+
+	case CardsDealt:
+		g.Table.DealHands(g.Deck)
+		for _, hand := range e.Hands {
+			p, err := g.playerAt(hand.Seat)
+			if err != nil {
+				return err
+			}
+			if p.HoleCards[0] == nil || *p.HoleCards[0] != hand.Cards[0] || *p.HoleCards[1] != hand.Cards[1] {
+				return fmt.Errorf("the cards dealt to seat %d do not match the deck", hand.Seat)
+			}
+		}
+
+		// 从大盲注的下一位开始行动
+		if next := g.nextToAct(); next != nil {
+			g.CurrentSeat = next
+		}
+
+	case PlayerActed:
+		if !g.IsPlayerStage() {
+			return fmt.Errorf("you cannot move during the %s stage", g.Stage)
+		}
+
+		p, err := g.playerAt(e.Seat)
+		if err != nil {
+			return err
+		}
+		if p != g.CurrentSeat.Player {
+			return fmt.Errorf("%s cannot move out of turn", p.Name)
+		}
+
+		t, b := g.Table, g.BettingRound
+		switch e.Type {
+		case ActionFold:
+			err = p.Fold(b)
+		case ActionCheck:
+			err = p.Check(b)
+		case ActionCall:
+			err = p.Call(t, b)
+		case ActionBet, ActionRaise:
+			err = p.Raise(t, b, e.Total)
+		default:
+			err = fmt.Errorf("invalid action: %s", e.Type)
+		}
+		if err != nil {
+			return err
+		}
+
+		// 加注后，其他玩家需要重新行动
+		if e.Type == ActionBet || e.Type == ActionRaise {
+			clear(b.Acted)
+		}
+		b.Acted[p] = true
+
+		if next := g.nextToAct(); next != nil {
+			g.CurrentSeat = next
+		}
+
+	case StreetDealt:
+		t, d := g.Table, g.Deck
+		if e.Stage != g.Stage+1 || e.Stage > GameStageRiver {
+			return fmt.Errorf("cannot deal the %s during the %s stage", e.Stage, g.Stage)
+		}
+		if d.CurrentCardIndex+len(e.Cards) > DeckSize || !slices.Equal(e.Cards, d.Cards[d.CurrentCardIndex:d.CurrentCardIndex+len(e.Cards)]) {
+			return fmt.Errorf("the %s cards do not match the deck", e.Stage)
+		}
+
+		switch e.Stage {
+		case GameStageFlop:
+			t.DealFlop(d)
+		case GameStageTurn:
+			t.DealTurn(d)
+		case GameStageRiver:
+			t.DealRiver(d)
+		}
+		g.Stage = e.Stage
+
+		// 翻牌后从庄家的下一位未弃牌玩家开始行动
+		start := t.Dealer.Next()
+		for start.Player == nil || start.Player.Status != PlayerActive || start.Player.HasFolded {
+			start = start.Next()
+		}
+
+		round, err := NewBettingRound(start, 0, t.MinBet*2)
+		if err != nil {
+			return err
+		}
+		if round.Limit = t.LimitBet(e.Stage); round.Limit > 0 {
+			round.RaiseByAmount = round.Limit
+		}
+		g.BettingRound = round
+		g.CurrentSeat = t.Dealer
+		if next := g.nextToAct(); next != nil {
+			g.CurrentSeat = next
+		}
+
+	case BetReturned:
+		p, err := g.playerAt(e.Seat)
+		if err != nil {
+			return err
+		}
+		if e.Amount <= 0 || e.Amount > g.Table.Pot.Bets[p] {
+			return fmt.Errorf("cannot return %d chips to %s", e.Amount, p.Name)
+		}
+
+		g.Table.Pot.Bets[p] -= e.Amount
+		if g.BettingRound.Bets[p] >= e.Amount {
+			g.BettingRound.Bets[p] -= e.Amount
+		}
+		p.Chips += e.Amount
+		g.Result.Uncalled = &PlayerBet{Player: p, Total: e.Amount}
+
+	case PotAwarded:
+		result := PotResult{SidePot: SidePot{Total: e.Amount, MaxBet: e.MaxBet}}
+		for _, seat := range e.Seats {
+			p, err := g.playerAt(seat)
+			if err != nil {
+				return err
+			}
+			result.Players = append(result.Players, p)
+		}
+
+		awarded := 0
+		showdown := len(g.inHandPlayers()) > 1
+		for _, share := range e.Winners {
+			p, err := g.playerAt(share.Seat)
+			if err != nil {
+				return err
+			}
+
+			winner := PlayerHand{ChipsWon: share.Amount, Player: p}
+			if showdown {
+				winner.Hand = GetBestHand(p, g.Table)
+			}
+			result.Winners = append(result.Winners, winner)
+			awarded += share.Amount
+		}
+		dropped := 0
+		for _, d := range e.Drops {
+			if d.Amount <= 0 {
+				return fmt.Errorf("the drop for %s must be positive, got %d", d.Name, d.Amount)
+			}
+			dropped += d.Amount
+		}
+		if awarded+e.Rake+dropped != e.Amount {
+			return fmt.Errorf("awarded %d chips, raked %d and dropped %d from a pot of %d", awarded, e.Rake, dropped, e.Amount)
+		}
+		raked := 0
+		for _, share := range e.RakeFrom {
+			if _, err := g.playerAt(share.Seat); err != nil {
+				return err
+			}
+			raked += share.Amount
+		}
+		if e.Rake < 0 || (len(e.RakeFrom) > 0 && raked != e.Rake) {
+			return fmt.Errorf("the rake of %d is attributed as %d", e.Rake, raked)
+		}
+
+		// 从奖池中扣除这一层的下注，上一层已分配的部分不再重复计算
+		level := 0
+		if n := len(g.Result.Pots); n > 0 {
+			level = g.Result.Pots[n-1].MaxBet
+		}
+		bets, taken := g.Table.Pot.Bets, 0
+		for _, bet := range bets {
+			taken += min(bet, e.MaxBet-level)
+		}
+		if taken != e.Amount {
+			return fmt.Errorf("the pot between %d and %d holds %d chips, not %d", level, e.MaxBet, taken, e.Amount)
+		}
+		for p, bet := range bets {
+			bets[p] = bet - min(bet, e.MaxBet-level)
+			g.BettingRound.Bets[p] = min(g.BettingRound.Bets[p], bets[p])
+		}
+
+		for _, w := range result.Winners {
+			w.Player.Chips += w.ChipsWon
+		}
+		result.Rake, result.Drops = e.Rake, e.Drops
+		g.chips -= e.Rake + dropped
+		g.Result.Rake += e.Rake
+		g.Result.Drops = append(g.Result.Drops, e.Drops...)
+		g.Result.Pots = append(g.Result.Pots, result)
+
+	case HandEnded:
+		g.Result.Showdown = e.Showdown
+		g.Stage = GameStageShowdown
+
+	default:
+		return fmt.Errorf("unknown event: %T", e)
+	}
+
+	return nil
+}
+
+// notify 将事件转换为观察者关心的牌局节点并通知观察者
+func (g *Game) notify(e Event) {
+	switch e := e.(type) {
+	case HandStarted:
+		for _, o := range g.Observers {
+			o.OnHandStart(g)
+		}
+
+	case BlindPosted:
+		p := g.Table.SeatAt(e.Seat).Player
+		action := Action{
+			Player: p,
+			Stage:  GameStagePreflop,
+			Type:   e.Type,
+			Amount: e.Amount,
+			Total:  g.BettingRound.Bets[p],
+			AllIn:  p.Chips == 0,
+		}
+		for _, o := range g.Observers {
+			o.OnAction(g, action)
+		}
+
+	case PlayerActed:
+		p := g.Table.SeatAt(e.Seat).Player
+		action := Action{
+			Player:  p,
+			Stage:   g.Stage,
+			Type:    e.Type,
+			Amount:  e.Amount,
+			Total:   e.Total,
+			RaiseBy: e.RaiseBy,
+			AllIn:   e.Type != ActionFold && e.Type != ActionCheck && p.Chips == 0,
+		}
+		for _, o := range g.Observers {
+			o.OnAction(g, action)
+		}
+
+	case StreetDealt:
+		for _, o := range g.Observers {
+			o.OnStage(g, e.Stage)
+		}
+
+	case HandEnded:
+		for _, o := range g.Observers {
+			o.OnHandEnd(g, g.Result)
+		}
+	}
+}
+
+// playerAt 获取指定座位上的玩家
+func (g *Game) playerAt(seat int) (*Player, error) {
+	if seat < 0 || seat >= g.Table.Seats.Len() {
+		return nil, fmt.Errorf("seat %d does not exist", seat)
+	}
+	return g.Table.SeatAt(seat).Player, nil
+}
@@ -28,12 +28,18 @@ func (p ByPlayerBet) Less(i, j int) bool {
 // BettingRound 记录一轮下注的状态。
 // 包括前注、翻牌圈、转牌圈和河牌圈等不同阶段的下注信息
 type BettingRound struct {
-	Bets          map[*Player]int // 记录每个玩家在当前轮次的下注金额
-	CallAmount    int             // 当前需要跟注的金额
-	Raiser        *Player         // 最后一个加注的玩家
-	RaiseByAmount int             // 最小加注金额，通常是前一次加注的两倍
+	Bets          map[*Player]int  // 记录每个玩家在当前轮次的下注金额
+	CallAmount    int              // 当前需要跟注的金额
+	Raiser        *Player          // 最后一个加注的玩家
+	RaiseByAmount int              // 最小加注金额，通常是前一次加注的两倍
+	Acted         map[*Player]bool // 记录本轮已经主动行动过的玩家，加注后其他玩家需要重新行动
+	Limit         int              // 限注时每次下注和加注的固定金额，不限注时为 0
+	Raises        int              // 本轮下注和加注的次数，翻牌前的大盲注算作一次
 }
 
+// limitBets 限注时每轮最多的下注和加注次数，包括翻牌前的大盲注
+const limitBets = 4
+
 // NewBettingRound 创建一个新的下注轮次。
 // 初始化所有活跃玩家的下注金额为 0，并设置基本的下注参数
 func NewBettingRound(startSeat *Seat, callAmount int, minBetAmount int) (*BettingRound, error) {
@@ -55,5 +61,6 @@ func NewBettingRound(startSeat *Seat, callAmount int, minBetAmount int) (*Bettin
 		CallAmount:    callAmount,
 		Raiser:        p,
 		RaiseByAmount: minBetAmount,
+		Acted:         make(map[*Player]bool),
 	}, nil
 }
@@ -0,0 +1,262 @@
+package poker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultBotTimeout 机器人做出决定的默认时限
+const defaultBotTimeout = time.Second
+
+// Bot 机器人，代替非人类玩家行动。
+// Decide 需要在 ctx 结束前返回，超时、出错或返回不合法的动作时由牌局代为过牌或弃牌
+type Bot interface {
+	Decide(ctx context.Context, v *View) (Decision, error)
+}
+
+// BotFunc 将普通函数用作机器人
+type BotFunc func(ctx context.Context, v *View) (Decision, error)
+
+// Decide 调用函数本身
+func (f BotFunc) Decide(ctx context.Context, v *View) (Decision, error) {
+	return f(ctx, v)
+}
+
+// Decision 机器人做出的决定
+type Decision struct {
+	Type   ActionType // 动作类型，下注和加注都可以使用 ActionRaise
+	Amount int        // 下注或加注到的总额，其他动作忽略
+}
+
+// LegalAction 玩家当前可以执行的一种动作
+type LegalAction struct {
+	Type ActionType `json:"type"` // 动作类型
+	Min  int        `json:"min"`  // 动作完成后本轮下注总额的最小值
+	Max  int        `json:"max"`  // 动作完成后本轮下注总额的最大值，等于最小值时没有选择的余地
+}
+
+// PlayerView 其他玩家公开的信息
+type PlayerView struct {
+	Seat     int          `json:"seat"`     // 座位编号，从 0 开始
+	Name     string       `json:"name"`     // 玩家名称
+	Position Position     `json:"position"` // 在这手牌中相对庄家的位置，没有参与这手牌时为 PositionNone
+	Chips    int          `json:"chips"`    // 剩余筹码
+	Bet      int          `json:"bet"`      // 本轮的下注
+	InPot    int          `json:"in_pot"`   // 本手牌投入奖池的筹码
+	Folded   bool         `json:"folded"`   // 是否已弃牌
+	Status   PlayerStatus `json:"status"`   // 玩家状态
+}
+
+// ActionView 本手牌中已经发生的一次动作
+type ActionView struct {
+	Seat  int        `json:"seat"`  // 座位编号
+	Stage GameStage  `json:"stage"` // 动作发生的阶段
+	Type  ActionType `json:"type"`  // 动作类型
+	Total int        `json:"total"` // 动作完成后本轮的下注总额
+}
+
+// View 玩家视角下的牌局，只包含该玩家能够看到的信息
+type View struct {
+	HandId     int           `json:"hand_id"`     // 手牌编号
+	Stage      GameStage     `json:"stage"`       // 当前阶段
+	Seat       int           `json:"seat"`        // 玩家的座位编号
+	HoleCards  [2]Card       `json:"hole_cards"`  // 玩家的底牌
+	Board      []Card        `json:"board"`       // 公共牌
+	Pot        int           `json:"pot"`         // 奖池总额，包括本轮的下注
+	CallAmount int           `json:"call_amount"` // 本轮需要跟到的下注额
+	SmallBlind int           `json:"small_blind"` // 小盲注
+	BigBlind   int           `json:"big_blind"`   // 大盲注
+	Ante       int           `json:"ante"`        // 前注
+	Limit      bool          `json:"limit"`       // 是否为固定限注
+	Dealer     int           `json:"dealer"`      // 庄家的座位编号
+	Players    []PlayerView  `json:"players"`     // 按座位顺序排列的玩家，不包括空座位
+	Actions    []ActionView  `json:"actions"`     // 本手牌已经发生的动作，不包括盲注和前注
+	Legal      []LegalAction `json:"legal"`       // 当前可以执行的动作，没有轮到玩家时为空
+}
+
+// AddBot 让机器人坐到指定座位上，并带入筹码
+func (g *Game) AddBot(seatId string, name string, chips int, bot Bot) error {
+	if err := g.TakeSeat(seatId, name, chips, false); err != nil {
+		return err
+	}
+	if g.Bots == nil {
+		g.Bots = make(map[string]Bot)
+	}
+	g.Bots[name] = bot
+	return nil
+}
+
+// View 获取指定座位上的玩家视角下的牌局
+func (g *Game) View(seatId string) (*View, error) {
+	p, ok := g.PlayerMap[seatId]
+	if !ok {
+		return nil, fmt.Errorf("seat %s does not exist", seatId)
+	}
+
+	t := g.Table
+	v := &View{
+		HandId:     g.HandId,
+		Stage:      g.Stage,
+		Seat:       g.seatOf(p),
+		Board:      t.GetBoard(),
+		Pot:        t.Pot.GetTotal(),
+		SmallBlind: t.MinBet,
+		BigBlind:   t.MinBet * 2,
+		Ante:       t.Ante,
+		Limit:      t.Limit,
+		Players:    make([]PlayerView, 0),
+		Actions:    make([]ActionView, 0),
+		Legal:      make([]LegalAction, 0),
+	}
+	if p.HoleCards[0] != nil && p.HoleCards[1] != nil {
+		v.HoleCards = [2]Card{*p.HoleCards[0], *p.HoleCards[1]}
+	}
+	if t.Dealer != nil {
+		v.Dealer = t.Dealer.Index()
+	}
+
+	b := g.BettingRound
+	s := t.Seats
+	for i := 0; i < s.Len(); i++ {
+		if other := s.Player; other.Status != PlayerVacated {
+			pv := PlayerView{Seat: s.Index(), Name: other.Name, Position: t.Position(s), Chips: other.Chips, InPot: t.Pot.Bets[other], Folded: other.HasFolded, Status: other.Status}
+			if b != nil {
+				pv.Bet = b.Bets[other]
+			}
+			v.Players = append(v.Players, pv)
+		}
+		s = s.Next()
+	}
+
+	v.Actions = g.handActions()
+	if b != nil {
+		v.CallAmount = b.CallAmount
+	}
+	if g.IsPlayerStage() && g.CurrentSeat.Player == p {
+		v.Legal = g.legalActions(p)
+	}
+	return v, nil
+}
+
+// handActions 从事件日志中找出本手牌已经发生的动作
+func (g *Game) handActions() []ActionView {
+	start := len(g.Events)
+	for start > 0 {
+		if _, ok := g.Events[start-1].(HandStarted); ok {
+			break
+		}
+		start--
+	}
+
+	actions := make([]ActionView, 0)
+	stage := GameStagePreflop
+	for _, e := range g.Events[start:] {
+		switch e := e.(type) {
+		case PlayerActed:
+			actions = append(actions, ActionView{Seat: e.Seat, Stage: stage, Type: e.Type, Total: e.Total})
+		case StreetDealt:
+			stage = e.Stage
+		}
+	}
+	return actions
+}
+
+// legalActions 计算当前玩家可以执行的动作
+func (g *Game) legalActions(p *Player) []LegalAction {
+	b := g.BettingRound
+	bet := b.Bets[p]
+	allIn := bet + p.Chips
+
+	legal := make([]LegalAction, 0)
+	if p.CanFold(b) {
+		legal = append(legal, LegalAction{Type: ActionFold, Min: bet, Max: bet})
+	}
+	if p.CanCheck(b) {
+		legal = append(legal, LegalAction{Type: ActionCheck, Min: bet, Max: bet})
+	}
+	if p.CanCall(b) {
+		call := min(b.CallAmount, allIn)
+		legal = append(legal, LegalAction{Type: ActionCall, Min: call, Max: call})
+	}
+	if allIn > b.CallAmount && (b.Limit == 0 || b.Raises < limitBets) {
+		raise := LegalAction{Type: ActionRaise, Min: min(b.CallAmount+b.RaiseByAmount, allIn), Max: allIn}
+		if b.Limit > 0 {
+			raise.Min = min(b.CallAmount+b.Limit, allIn)
+			raise.Max = raise.Min
+		}
+		if b.CallAmount == 0 {
+			raise.Type = ActionBet
+		}
+		legal = append(legal, raise)
+	}
+	return legal
+}
+
+// RunBots 让轮到行动的机器人依次行动，直到轮到人类玩家或者一手牌结束。
+// 从快照恢复牌局并重新接入机器人之后调用，使牌局继续进行
+func (g *Game) RunBots() error {
+	return g.runBots()
+}
+
+// runBots 轮到机器人行动时向机器人询问决定并代为执行，直到轮到人类玩家或者一手牌结束
+func (g *Game) runBots() error {
+	for g.IsPlayerStage() {
+		p := g.CurrentSeat.Player
+		bot := g.Bots[p.Name]
+		if p.IsHuman || bot == nil {
+			return nil
+		}
+
+		v, err := g.View(p.Id)
+		if err != nil {
+			return err
+		}
+		d, err := g.decide(bot, v)
+		if err == nil {
+			err = g.perform(d.Type, d.Amount)
+		}
+		if err != nil {
+			// 机器人超时、出错或动作不合法时，能过牌就过牌，否则弃牌
+			fallback := ActionFold
+			if p.CanCheck(g.BettingRound) {
+				fallback = ActionCheck
+			}
+			if err := g.perform(fallback, 0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decide 在时限内向机器人询问决定，不限时时直接在当前协程中询问，结果可以复现
+func (g *Game) decide(bot Bot, v *View) (Decision, error) {
+	if g.BotTimeout < 0 {
+		return bot.Decide(context.Background(), v)
+	}
+
+	timeout := g.BotTimeout
+	if timeout <= 0 {
+		timeout = defaultBotTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type answer struct {
+		d   Decision
+		err error
+	}
+	ch := make(chan answer, 1)
+	go func() {
+		d, err := bot.Decide(ctx, v)
+		ch <- answer{d, err}
+	}()
+
+	select {
+	case a := <-ch:
+		return a.d, a.err
+	case <-ctx.Done():
+		return Decision{}, ctx.Err()
+	}
+}
@@ -0,0 +1,72 @@
+package poker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGameBots(t *testing.T) {
+	g := NewGame()
+	g.Debug, g.BotTimeout = true, 10*time.Millisecond
+	s := g.Table.Seats
+
+	// 能跟注就跟注，否则过牌
+	caller := BotFunc(func(ctx context.Context, v *View) (Decision, error) {
+		for _, a := range v.Legal {
+			if a.Type == ActionCall {
+				return Decision{Type: ActionCall}, nil
+			}
+		}
+		return Decision{Type: ActionCheck}, nil
+	})
+	// 总是超时
+	slow := BotFunc(func(ctx context.Context, v *View) (Decision, error) {
+		<-ctx.Done()
+		time.Sleep(time.Millisecond)
+		return Decision{Type: ActionRaise, Amount: v.Players[0].Chips}, nil
+	})
+
+	require.NoError(t, g.TakeSeat(s.Player.Id, "p1", 500, true))
+	require.NoError(t, g.AddBot(s.Next().Player.Id, "p2", 500, caller))
+	require.NoError(t, g.AddBot(s.Next().Next().Player.Id, "p3", 500, slow))
+	p1, p3 := s.Player, s.Next().Next().Player
+
+	// 庄家 p1 先行动，可以弃牌、跟注或加注到 20 以上
+	require.NoError(t, g.StartHand())
+	v, err := g.View(p1.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []LegalAction{
+		{Type: ActionFold, Min: 0, Max: 0},
+		{Type: ActionCall, Min: 10, Max: 10},
+		{Type: ActionRaise, Min: 20, Max: 500},
+	}, v.Legal)
+	assert.Len(t, v.Players, 3)
+	assert.Equal(t, 15, v.Pot)
+
+	// p2 跟注，p3 超时后代为过牌，翻牌后 p2 过牌，p3 过牌，轮到 p1
+	require.NoError(t, g.Call())
+	assert.Equal(t, GameStageFlop, g.Stage)
+	assert.Equal(t, p1, g.CurrentSeat.Player)
+	v, err = g.View(p1.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []LegalAction{
+		{Type: ActionCheck, Min: 0, Max: 0},
+		{Type: ActionBet, Min: 10, Max: 490},
+	}, v.Legal)
+	assert.Len(t, v.Actions, 5)
+
+	// p1 下注后 p2 跟注，p3 超时且不能过牌，代为弃牌
+	require.NoError(t, g.Raise(100))
+	assert.True(t, p3.HasFolded)
+	assert.Equal(t, GameStageTurn, g.Stage)
+	assert.Equal(t, p1, g.CurrentSeat.Player)
+
+	// 其他玩家视角下看不到轮到谁的合法动作
+	v, err = g.View(p3.Id)
+	require.NoError(t, err)
+	assert.Empty(t, v.Legal)
+}
@@ -1,6 +1,9 @@
 package poker
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // CardSuit 扑克牌花色的枚举类型
 type CardSuit int
@@ -71,6 +74,27 @@ func (c *Card) Symbol() string {
 	return fmt.Sprintf("%s%s", c.Rank.Symbol(), c.Suit.Symbol())
 }
 
+// Code 返回扑克牌的两字符代码，例如："As"、"Td"。
+// 这是 PokerStars 等手牌记录中通用的写法
+func (c *Card) Code() string {
+	return fmt.Sprintf("%c%c", "23456789TJQKA"[c.Rank], "cdhs"[c.Suit])
+}
+
+// ParseCard 解析两字符的扑克牌代码，例如："As"、"Td"
+func ParseCard(code string) (Card, error) {
+	if len(code) != 2 {
+		return Card{}, fmt.Errorf("invalid card code: %q", code)
+	}
+
+	rank := strings.IndexByte("23456789TJQKA", code[0])
+	suit := strings.IndexByte("cdhs", code[1])
+	if rank < 0 || suit < 0 {
+		return Card{}, fmt.Errorf("invalid card code: %q", code)
+	}
+
+	return Card{Rank: CardRank(rank), Suit: CardSuit(suit)}, nil
+}
+
 // ByCard 实现了扑克牌的排序接口，按点数升序排列
 // 注意：由于没有使用花色作为次要排序条件，因此排序结果可能不是唯一的
 type ByCard []Card
@@ -27,6 +27,11 @@ func (d *Deck) GetNextCard() (*Card, error) {
 
 // NewDeck 创建一副新的扑克牌
 func NewDeck() *Deck {
+	return NewDeckWithRand(nil)
+}
+
+// NewDeckWithRand 使用指定的随机数生成器洗牌，相同种子的生成器得到相同的牌序，r 为 nil 时使用全局的随机数
+func NewDeckWithRand(r *rand.Rand) *Deck {
 	suits := []CardSuit{Clubs, Diamonds, Hearts, Spades}
 	ranks := []CardRank{Two, Three, Four, Five, Six, Seven, Eight, Nine, Ten, Jack, Queen, King, Ace}
 	cards := make([]Card, DeckSize)
@@ -44,7 +49,29 @@ func NewDeck() *Deck {
 	}
 
 	// 使用随机算法打乱牌堆
-	rand.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	shuffle := rand.Shuffle
+	if r != nil {
+		shuffle = r.Shuffle
+	}
+	shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
 
 	return &Deck{Cards: cards, CurrentCardIndex: 0}
 }
+
+// NewDeckFromCards 使用指定顺序的牌创建牌堆，用于重现牌局。
+// cards 必须恰好是一副完整且不重复的扑克牌
+func NewDeckFromCards(cards []Card) (*Deck, error) {
+	if len(cards) != DeckSize {
+		return nil, fmt.Errorf("a deck must have %d cards, got %d", DeckSize, len(cards))
+	}
+
+	seen := make(map[Card]bool, DeckSize)
+	for _, c := range cards {
+		if seen[c] {
+			return nil, fmt.Errorf("duplicate card in deck: %s", c.Symbol())
+		}
+		seen[c] = true
+	}
+
+	return &Deck{Cards: append([]Card(nil), cards...), CurrentCardIndex: 0}, nil
+}
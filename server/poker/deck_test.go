@@ -1,6 +1,7 @@
 package poker
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,3 +30,12 @@ func TestDeckGetNextCard(t *testing.T) {
 		}
 	}
 }
+
+func TestNewDeckWithRand(t *testing.T) {
+	a := NewDeckWithRand(rand.New(rand.NewSource(7)))
+	b := NewDeckWithRand(rand.New(rand.NewSource(7)))
+	c := NewDeckWithRand(rand.New(rand.NewSource(8)))
+	assert.Equal(t, a.Cards, b.Cards)
+	assert.NotEqual(t, a.Cards, c.Cards)
+	assert.ElementsMatch(t, NewDeck().Cards, a.Cards)
+}
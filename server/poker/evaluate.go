@@ -0,0 +1,115 @@
+package poker
+
+import "math/bits"
+
+// HandValue 五到七张牌中最大组合的大小，数值越大牌越大，相等时平分奖池。
+// 高位为牌型等级，之后每四位依次为一个平局判定值，与 CheckHand 的比较结果一致
+type HandValue uint32
+
+// Rank 获取牌型等级
+func (v HandValue) Rank() HandRank {
+	return HandRank(v >> 20)
+}
+
+// Evaluate 快速计算五到七张牌中最大组合的大小，用于需要大量比较手牌的模拟
+func Evaluate(cards ...Card) HandValue {
+	var counts [13]int
+	var suits [4]uint16
+	var ranks uint16
+	for _, c := range cards {
+		counts[c.Rank]++
+		suits[c.Suit] |= 1 << c.Rank
+		ranks |= 1 << c.Rank
+	}
+
+	for _, mask := range suits {
+		if bits.OnesCount16(mask) < 5 {
+			continue
+		}
+		if high, ok := straightHigh(mask); ok {
+			if high == Ace {
+				return value(RoyalFlush)
+			}
+			return value(StraightFlush, high)
+		}
+		return value(Flush, topRanks(mask, 5)...)
+	}
+
+	// 按张数找出最大的四条、三条和对子
+	quad, trips, pairs := -1, make([]CardRank, 0, 2), make([]CardRank, 0, 3)
+	for r := Ace; r >= Two; r-- {
+		switch counts[r] {
+		case 4:
+			quad = int(r)
+		case 3:
+			trips = append(trips, r)
+		case 2:
+			pairs = append(pairs, r)
+		}
+	}
+
+	without := func(rs ...CardRank) uint16 {
+		mask := ranks
+		for _, r := range rs {
+			mask &^= 1 << r
+		}
+		return mask
+	}
+
+	switch {
+	case quad >= 0:
+		return value(FourOfAKind, CardRank(quad), topRanks(without(CardRank(quad)), 1)[0])
+	case len(trips) > 0 && len(trips)+len(pairs) > 1:
+		// 第二组三条也可以当作对子
+		pair := trips[len(trips)-1]
+		if len(trips) == 1 || len(pairs) > 0 && pairs[0] > pair {
+			pair = pairs[0]
+		}
+		return value(FullHouse, trips[0], pair)
+	}
+	if high, ok := straightHigh(ranks); ok {
+		return value(Straight, high)
+	}
+	switch {
+	case len(trips) > 0:
+		return value(ThreeOfAKind, append(trips[:1], topRanks(without(trips[0]), 2)...)...)
+	case len(pairs) > 1:
+		return value(TwoPair, pairs[0], pairs[1], topRanks(without(pairs[0], pairs[1]), 1)[0])
+	case len(pairs) == 1:
+		return value(OnePair, append(pairs[:1], topRanks(without(pairs[0]), 3)...)...)
+	}
+	return value(HighCard, topRanks(ranks, 5)...)
+}
+
+// value 将牌型等级和平局判定值编码为 HandValue
+func value(rank HandRank, tieBreakers ...CardRank) HandValue {
+	v := HandValue(rank) << 20
+	for i, r := range tieBreakers {
+		v |= HandValue(r) << (16 - 4*i)
+	}
+	return v
+}
+
+// straightHigh 找出点数集合中最大顺子的最大点数，A 也可以当作 1 使用
+func straightHigh(mask uint16) (CardRank, bool) {
+	// 左移一位后第 0 位表示当作 1 使用的 A
+	m := mask<<1 | mask>>Ace&1
+	for high := Ace; high >= Five; high-- {
+		straight := uint16(0x1F) << (high - 3)
+		if m&straight == straight {
+			return high, true
+		}
+	}
+	return 0, false
+}
+
+// topRanks 从大到小取出点数集合中最大的 n 个点数
+func topRanks(mask uint16, n int) []CardRank {
+	ranks := make([]CardRank, 0, n)
+	for r := Ace; r >= Two && len(ranks) < n; r-- {
+		if mask&(1<<r) != 0 {
+			ranks = append(ranks, r)
+		}
+	}
+	return ranks
+}
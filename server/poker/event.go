@@ -0,0 +1,245 @@
+package poker
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Event 牌局的领域事件。
+// Game 的所有状态变化都由事件描述，按顺序应用全部事件即可重建出完全相同的牌局。
+// 事件中的玩家以座位号表示（从 0 开始），不包含指针，可以直接序列化
+type Event interface {
+	EventType() string // 事件类型名称
+}
+
+// 事件类型名称
+const (
+	EventGameCreated  = "game_created"   // 创建游戏
+	EventBlindsSet    = "blinds_set"     // 设置盲注
+	EventPlayerSeated = "player_seated"  // 玩家入座
+	EventPlayerSatOut = "player_sat_out" // 玩家暂时离座
+	EventPlayerLeft   = "player_left"    // 玩家离开座位
+	EventChipsAdded   = "chips_added"    // 玩家补充筹码
+	EventHandStarted  = "hand_started"   // 开始新的一手牌
+	EventBlindPosted  = "blind_posted"   // 玩家下盲注或前注
+	EventCardsDealt   = "cards_dealt"    // 发放手牌
+	EventPlayerActed  = "player_acted"   // 玩家行动
+	EventStreetDealt  = "street_dealt"   // 发出公共牌
+	EventBetReturned  = "bet_returned"   // 退还无人跟注的下注
+	EventPotAwarded   = "pot_awarded"    // 分配奖池
+	EventHandEnded    = "hand_ended"     // 一手牌结束
+)
+
+// GameCreated 创建游戏，每个座位上预先放置一名空闲的玩家
+type GameCreated struct {
+	PlayerIds []string `json:"player_ids"` // 按座位顺序排列的玩家标识
+}
+
+// BlindsSet 设置盲注、前注和下注方式，大盲注为小盲注的两倍
+type BlindsSet struct {
+	SmallBlind int  `json:"small_blind"`     // 小盲注金额
+	Ante       int  `json:"ante,omitempty"`  // 前注金额，没有前注时为 0
+	Limit      bool `json:"limit,omitempty"` // 是否为固定限注
+}
+
+// PlayerSeated 玩家入座并带入筹码
+type PlayerSeated struct {
+	Seat    int    `json:"seat"`     // 座位号
+	Name    string `json:"name"`     // 玩家名称
+	Chips   int    `json:"chips"`    // 带入的筹码
+	IsHuman bool   `json:"is_human"` // 是否是人类玩家
+}
+
+// PlayerSatOut 玩家暂时离座，不再参与之后的牌局
+type PlayerSatOut struct {
+	Seat int `json:"seat"` // 座位号
+}
+
+// PlayerLeft 玩家带着筹码离开座位，座位重新变为空闲
+type PlayerLeft struct {
+	Seat int `json:"seat"` // 座位号
+}
+
+// ChipsAdded 玩家在座位上补充筹码，因筹码不足暂时离座的玩家重新回到牌局
+type ChipsAdded struct {
+	Seat   int `json:"seat"`   // 座位号
+	Amount int `json:"amount"` // 补充的筹码
+}
+
+// HandStarted 开始新的一手牌
+type HandStarted struct {
+	HandId     int    `json:"hand_id"`     // 手牌编号
+	Dealer     int    `json:"dealer"`      // 庄家座位号
+	SmallBlind int    `json:"small_blind"` // 小盲注座位号
+	BigBlind   int    `json:"big_blind"`   // 大盲注座位号
+	Deck       []Card `json:"deck"`        // 洗好的牌堆
+}
+
+// BlindPosted 玩家下盲注或前注
+type BlindPosted struct {
+	Seat   int        `json:"seat"`   // 座位号
+	Type   ActionType `json:"type"`   // ActionSmallBlind、ActionBigBlind 或 ActionAnte
+	Amount int        `json:"amount"` // 实际下注的金额，筹码不足时少于盲注
+}
+
+// CardsDealt 给所有活跃玩家发放手牌
+type CardsDealt struct {
+	Hands []DealtHand `json:"hands"` // 按发牌顺序排列的手牌
+}
+
+// DealtHand 一名玩家拿到的两张底牌
+type DealtHand struct {
+	Seat  int     `json:"seat"`  // 座位号
+	Cards [2]Card `json:"cards"` // 底牌
+}
+
+// PlayerActed 玩家行动
+type PlayerActed struct {
+	Seat    int        `json:"seat"`     // 座位号
+	Type    ActionType `json:"type"`     // 动作类型
+	Amount  int        `json:"amount"`   // 本次投入的筹码数
+	Total   int        `json:"total"`    // 动作后玩家在本轮的下注总额
+	RaiseBy int        `json:"raise_by"` // 下注或加注超出原跟注金额的部分
+}
+
+// StreetDealt 发出公共牌并开始新的下注轮次
+type StreetDealt struct {
+	Stage GameStage `json:"stage"` // 进入的阶段
+	Cards []Card    `json:"cards"` // 新发出的公共牌
+}
+
+// BetReturned 退还无人跟注的下注
+type BetReturned struct {
+	Seat   int `json:"seat"`   // 座位号
+	Amount int `json:"amount"` // 退还的筹码数
+}
+
+// PotAwarded 将一个奖池分配给赢家
+type PotAwarded struct {
+	Seats    []int      `json:"seats"`               // 有资格赢取该奖池的玩家
+	Amount   int        `json:"amount"`              // 奖池金额
+	MaxBet   int        `json:"max_bet"`             // 该奖池中每个玩家的最大下注额
+	Winners  []PotShare `json:"winners"`             // 赢家及其分得的筹码
+	Rake     int        `json:"rake,omitempty"`      // 从该奖池中抽取的筹码，赢家分得的筹码之和为 Amount - Rake - Drops
+	RakeFrom []PotShare `json:"rake_from,omitempty"` // 按下注比例记到每名玩家名下的抽水
+	Drops    []Drop     `json:"drops,omitempty"`     // 为促销活动抽取的筹码，只从主池中抽取
+}
+
+// PotShare 赢家分得的筹码
+type PotShare struct {
+	Seat   int `json:"seat"`   // 座位号
+	Amount int `json:"amount"` // 分得的筹码数
+}
+
+// HandEnded 一手牌结束
+type HandEnded struct {
+	Showdown bool `json:"showdown"` // 是否进行了摊牌
+}
+
+func (GameCreated) EventType() string  { return EventGameCreated }
+func (BlindsSet) EventType() string    { return EventBlindsSet }
+func (PlayerSeated) EventType() string { return EventPlayerSeated }
+func (PlayerSatOut) EventType() string { return EventPlayerSatOut }
+func (PlayerLeft) EventType() string   { return EventPlayerLeft }
+func (ChipsAdded) EventType() string   { return EventChipsAdded }
+func (HandStarted) EventType() string  { return EventHandStarted }
+func (BlindPosted) EventType() string  { return EventBlindPosted }
+func (CardsDealt) EventType() string   { return EventCardsDealt }
+func (PlayerActed) EventType() string  { return EventPlayerActed }
+func (StreetDealt) EventType() string  { return EventStreetDealt }
+func (BetReturned) EventType() string  { return EventBetReturned }
+func (PotAwarded) EventType() string   { return EventPotAwarded }
+func (HandEnded) EventType() string    { return EventHandEnded }
+
+// eventFactories 根据事件类型名称创建空事件，用于反序列化
+var eventFactories = map[string]func() Event{
+	EventGameCreated:  func() Event { return &GameCreated{} },
+	EventBlindsSet:    func() Event { return &BlindsSet{} },
+	EventPlayerSeated: func() Event { return &PlayerSeated{} },
+	EventPlayerSatOut: func() Event { return &PlayerSatOut{} },
+	EventPlayerLeft:   func() Event { return &PlayerLeft{} },
+	EventChipsAdded:   func() Event { return &ChipsAdded{} },
+	EventHandStarted:  func() Event { return &HandStarted{} },
+	EventBlindPosted:  func() Event { return &BlindPosted{} },
+	EventCardsDealt:   func() Event { return &CardsDealt{} },
+	EventPlayerActed:  func() Event { return &PlayerActed{} },
+	EventStreetDealt:  func() Event { return &StreetDealt{} },
+	EventBetReturned:  func() Event { return &BetReturned{} },
+	EventPotAwarded:   func() Event { return &PotAwarded{} },
+	EventHandEnded:    func() Event { return &HandEnded{} },
+}
+
+// eventEnvelope 序列化后的事件，记录事件类型以便反序列化
+type eventEnvelope struct {
+	Type string          `json:"type"` // 事件类型名称
+	Data json.RawMessage `json:"data"` // 事件内容
+}
+
+// MarshalEvent 将事件序列化为 JSON
+func MarshalEvent(e Event) ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(eventEnvelope{Type: e.EventType(), Data: data})
+}
+
+// UnmarshalEvent 从 JSON 中反序列化事件
+func UnmarshalEvent(b []byte) (Event, error) {
+	var envelope eventEnvelope
+	if err := json.Unmarshal(b, &envelope); err != nil {
+		return nil, err
+	}
+
+	factory, ok := eventFactories[envelope.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type: %q", envelope.Type)
+	}
+
+	e := factory()
+	if err := json.Unmarshal(envelope.Data, e); err != nil {
+		return nil, fmt.Errorf("%s: %w", envelope.Type, err)
+	}
+	return derefEvent(e), nil
+}
+
+// derefEvent 将反序列化得到的事件指针转换为事件值，与 Game 产生的事件保持一致
+func derefEvent(e Event) Event {
+	switch e := e.(type) {
+	case *GameCreated:
+		return *e
+	case *BlindsSet:
+		return *e
+	case *PlayerSeated:
+		return *e
+	case *PlayerSatOut:
+		return *e
+	case *PlayerLeft:
+		return *e
+	case *ChipsAdded:
+		return *e
+	case *HandStarted:
+		return *e
+	case *BlindPosted:
+		return *e
+	case *CardsDealt:
+		return *e
+	case *PlayerActed:
+		return *e
+	case *StreetDealt:
+		return *e
+	case *BetReturned:
+		return *e
+	case *PotAwarded:
+		return *e
+	case *HandEnded:
+		return *e
+	}
+	return e
+}
+
+// EventListener 事件监听者，每个事件应用到 Game 后都会收到通知。
+// 可用于持久化事件日志，或向客户端推送精确的状态更新
+type EventListener interface {
+	OnEvent(g *Game, e Event)
+}
@@ -0,0 +1,66 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildFromEvents(t *testing.T) {
+	g, _ := newTestGame(t, 500, 500, 500)
+	require.NoError(t, g.StartHand())
+	require.NoError(t, g.Raise(30))
+	require.NoError(t, g.Call())
+	require.NoError(t, g.Fold())
+	require.NoError(t, g.Check())
+	require.NoError(t, g.Raise(40))
+	require.NoError(t, g.Fold())
+
+	// 第二手牌停在翻牌圈
+	require.NoError(t, g.StartHand())
+	require.NoError(t, g.Call())
+	require.NoError(t, g.Call())
+	require.NoError(t, g.Check())
+	require.Equal(t, GameStageFlop, g.Stage)
+
+	// 事件经过序列化后重建的牌局应与原牌局一致
+	events := make([]Event, len(g.Events))
+	for i, e := range g.Events {
+		b, err := MarshalEvent(e)
+		require.NoError(t, err)
+		events[i], err = UnmarshalEvent(b)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, g.Events, events)
+
+	rebuilt, err := Rebuild(events)
+	require.NoError(t, err)
+	assert.Equal(t, g.HandId, rebuilt.HandId)
+	assert.Equal(t, g.Stage, rebuilt.Stage)
+	assert.Equal(t, g.Table.GetBoard(), rebuilt.Table.GetBoard())
+	assert.Equal(t, g.CurrentSeat.Player.Id, rebuilt.CurrentSeat.Player.Id)
+	assert.Equal(t, g.Table.Pot.GetTotal(), rebuilt.Table.Pot.GetTotal())
+	for id, p := range g.PlayerMap {
+		other := rebuilt.PlayerMap[id]
+		require.NotNil(t, other)
+		assert.Equal(t, p.Chips, other.Chips)
+		assert.Equal(t, p.Status, other.Status)
+		assert.Equal(t, p.HasFolded, other.HasFolded)
+		assert.Equal(t, p.HoleCards, other.HoleCards)
+	}
+
+	// 重建的牌局可以继续进行
+	require.NoError(t, rebuilt.Check())
+}
+
+func TestApplyRejectsInvalidEvent(t *testing.T) {
+	g, _ := newTestGame(t, 500, 500)
+	require.NoError(t, g.StartHand())
+	n := len(g.Events)
+
+	// 不是当前玩家的行动不会被记录
+	other := 1 - g.seatOf(g.CurrentSeat.Player)
+	assert.Error(t, g.emit(PlayerActed{Seat: other, Type: ActionFold}))
+	assert.Len(t, g.Events, n)
+}
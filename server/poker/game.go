@@ -2,6 +2,8 @@ package poker
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -32,34 +34,56 @@ func (g GameStage) String() string {
 }
 
 type Game struct {
+	HandId       int                // 当前手牌编号，每开始一手牌加一
 	Stage        GameStage          // 游戏阶段
 	Deck         *Deck              // 牌堆
 	CurrentSeat  *Seat              // 当前座位
 	Table        *Table             // 牌桌
 	BettingRound *BettingRound      // 当前下注轮次
 	PlayerMap    map[string]*Player // 玩家映射
+	Result       *HandResult        // 当前或最近一手牌的结算结果
+	Events       []Event            // 事件日志，按顺序应用即可重建牌局
+	Listeners    []EventListener    // 事件监听者
+	Observers    []Observer         // 牌局观察者
+	DeckSource   func() *Deck       // 每手牌开始时获取牌堆，为 nil 时使用随机洗好的新牌堆
+	Debug        bool               // 调试模式，每个动作之后都会校验牌局状态
+	Rake         *RakeRule          // 抽水规则，为 nil 时不抽水
+	Drops        []DropRule         // 为促销活动抽取筹码的规则
+	Bots         map[string]Bot     // 代替非人类玩家行动的机器人，key 为玩家名称
+	BotTimeout   time.Duration      // 机器人做出决定的时限，为 0 时使用默认的一秒，为负数时不限时
+	HandEvents   bool               // 只保留当前这手牌的事件日志，用于长时间运行的模拟，开启后不能用事件日志重建牌局
+
+	chips int // 玩家带入牌桌的筹码总数，用于校验筹码守恒
 }
 
+// NewGame 创建默认座位数的游戏
 func NewGame() *Game {
-	seats := NewSeat(numPlayers)          // 创建座位
-	playerMap := make(map[string]*Player) // 创建玩家映射
-	for i := 0; i > seats.Len(); i++ {
-		seats.Player = &Player{
-			Id:      fmt.Sprintf("player_%s", uuid.New().String()[:8]), // 生成玩家 id
-			Status:  PlayerVacated,                                     // 玩家状态为离开
-			IsHuman: false,                                             // 玩家是否为真人
-		}
-		playerMap[seats.Player.Id] = seats.Player
-		seats = seats.Next()
+	return NewGameWithSeats(numPlayers)
+}
+
+// NewGameWithSeats 创建包含 n 个座位的游戏
+func NewGameWithSeats(n int) *Game {
+	playerIds := make([]string, n)
+	for i := range playerIds {
+		playerIds[i] = fmt.Sprintf("player_%s", uuid.New().String()[:8]) // 生成玩家 id
 	}
 
-	return &Game{
-		Stage:       GameStageWaiting,          // 游戏阶段为等待阶段
-		Deck:        NewDeck(),                 // 创建牌堆
-		CurrentSeat: seats.Next(),              // 获取当前座位
-		Table:       NewTable(NewPot(), seats), // 创建牌桌
-		PlayerMap:   playerMap,                 // 玩家映射
+	g := &Game{}
+	g.emit(GameCreated{PlayerIds: playerIds})
+	return g
+}
+
+// Rebuild 按顺序应用事件日志，重建出牌局。
+// 重建的过程不会通知监听者和观察者
+func Rebuild(events []Event) (*Game, error) {
+	g := &Game{}
+	for i, e := range events {
+		if err := g.apply(e); err != nil {
+			return nil, fmt.Errorf("event %d (%s): %w", i, e.EventType(), err)
+		}
+		g.Events = append(g.Events, e)
 	}
+	return g, nil
 }
 
 // IsPlayerStage 是否为玩家阶段: 等待阶段、摊牌阶段为 false，其他阶段为 true
@@ -71,3 +95,445 @@ func (g *Game) IsPlayerStage() bool {
 func (g *Game) IsPlayerTurn(seatId string) bool {
 	return g.CurrentSeat.Player.Id == seatId && g.IsPlayerStage()
 }
+
+// TakeSeat 让玩家坐到指定座位上，并带入筹码
+func (g *Game) TakeSeat(seatId string, name string, chips int, isHuman bool) error {
+	p, ok := g.PlayerMap[seatId]
+	if !ok {
+		return fmt.Errorf("seat %s does not exist", seatId)
+	}
+
+	return g.emit(PlayerSeated{Seat: g.seatOf(p), Name: name, Chips: chips, IsHuman: isHuman})
+}
+
+// LeaveSeat 让玩家带着筹码离开座位，一手牌进行中参与牌局的玩家不能离开
+func (g *Game) LeaveSeat(seatId string) error {
+	p, ok := g.PlayerMap[seatId]
+	if !ok {
+		return fmt.Errorf("seat %s does not exist", seatId)
+	}
+
+	return g.emit(PlayerLeft{Seat: g.seatOf(p)})
+}
+
+// AddChips 玩家在座位上补充筹码，正在参与一手牌的玩家不能补充
+func (g *Game) AddChips(seatId string, amount int) error {
+	p, ok := g.PlayerMap[seatId]
+	if !ok {
+		return fmt.Errorf("seat %s does not exist", seatId)
+	}
+
+	return g.emit(ChipsAdded{Seat: g.seatOf(p), Amount: amount})
+}
+
+// SetBlinds 设置小盲注和前注金额，大盲注为小盲注的两倍，只能在两手牌之间设置
+func (g *Game) SetBlinds(smallBlind, ante int) error {
+	return g.emit(BlindsSet{SmallBlind: smallBlind, Ante: ante, Limit: g.Table.Limit})
+}
+
+// SetLimit 设置是否为固定限注，只能在两手牌之间设置
+func (g *Game) SetLimit(limit bool) error {
+	return g.emit(BlindsSet{SmallBlind: g.Table.MinBet, Ante: g.Table.Ante, Limit: limit})
+}
+
+// StartHand 开始新的一手牌。
+// 依次移动庄家位置、收取盲注、发放手牌，并将行动权交给第一个需要行动的玩家
+func (g *Game) StartHand() error {
+	if g.IsPlayerStage() {
+		return fmt.Errorf("cannot start a new hand during the %s stage", g.Stage)
+	}
+
+	// 没有筹码的玩家暂时离座
+	for _, p := range g.Table.Seats.GetActivePlayers() {
+		if p.Chips <= 0 {
+			if err := g.emit(PlayerSatOut{Seat: g.seatOf(p)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	activePlayers := g.Table.Seats.GetActivePlayers()
+	if len(activePlayers) < minPlayers {
+		return fmt.Errorf("at least %d players are needed to start a hand, got %d", minPlayers, len(activePlayers))
+	}
+
+	deck := NewDeck()
+	if g.DeckSource != nil {
+		deck = g.DeckSource()
+	}
+
+	t := g.Table
+	dealer, smallBlind, bigBlind := g.NextBlinds()
+
+	err := g.emit(HandStarted{
+		HandId:     g.HandId + 1,
+		Dealer:     dealer.Index(),
+		SmallBlind: smallBlind.Index(),
+		BigBlind:   bigBlind.Index(),
+		Deck:       deck.Cards,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 从小盲注开始依次收取前注
+	if t.Ante > 0 {
+		s := smallBlind
+		for range activePlayers {
+			err := g.emit(BlindPosted{
+				Seat:   s.Index(),
+				Type:   ActionAnte,
+				Amount: min(t.Ante, s.Player.Chips),
+			})
+			if err != nil {
+				return err
+			}
+			s = s.NextActive()
+		}
+	}
+
+	// 收取盲注，筹码不足的玩家全下，在前注中已经全下的玩家不再下盲注
+	if p := smallBlind.Player; p.Chips > 0 {
+		err = g.emit(BlindPosted{
+			Seat:   smallBlind.Index(),
+			Type:   ActionSmallBlind,
+			Amount: min(t.MinBet, p.Chips),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if p := bigBlind.Player; p.Chips > 0 {
+		err = g.emit(BlindPosted{
+			Seat:   bigBlind.Index(),
+			Type:   ActionBigBlind,
+			Amount: min(t.MinBet*2, p.Chips),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 按座位顺序先给每名玩家发第一张底牌，再发第二张
+	n := len(activePlayers)
+	hands := make([]DealtHand, n)
+	for i, p := range activePlayers {
+		hands[i] = DealtHand{
+			Seat:  g.seatOf(p),
+			Cards: [2]Card{g.Deck.Cards[i], g.Deck.Cards[n+i]},
+		}
+	}
+	if err := g.emit(CardsDealt{Hands: hands}); err != nil {
+		return err
+	}
+
+	if err := g.check(g.advance()); err != nil {
+		return err
+	}
+	return g.runBots()
+}
+
+// NextBlinds 获取下一手牌的庄家、小盲注和大盲注座位，单挑时庄家下小盲注。
+// 只有持有筹码的活跃玩家才会参与下一手牌
+func (g *Game) NextBlinds() (dealer, smallBlind, bigBlind *Seat) {
+	t := g.Table
+	next := func(s *Seat) *Seat {
+		for i := 0; i < s.Len(); i++ {
+			s = s.Next()
+			if p := s.Player; p != nil && p.Status == PlayerActive && p.Chips > 0 {
+				return s
+			}
+		}
+		return s
+	}
+
+	players := 0
+	for _, p := range t.Seats.GetActivePlayers() {
+		if p.Chips > 0 {
+			players++
+		}
+	}
+
+	dealer = next(t.Seats.prev())
+	if t.Dealer != nil {
+		dealer = next(t.Dealer)
+	}
+	smallBlind = next(dealer)
+	if players == minPlayers {
+		smallBlind = dealer
+	}
+	bigBlind = next(smallBlind)
+	return dealer, smallBlind, bigBlind
+}
+
+// Fold 当前玩家弃牌
+func (g *Game) Fold() error {
+	return g.act(ActionFold, 0)
+}
+
+// Check 当前玩家过牌
+func (g *Game) Check() error {
+	return g.act(ActionCheck, 0)
+}
+
+// Call 当前玩家跟注
+func (g *Game) Call() error {
+	return g.act(ActionCall, 0)
+}
+
+// Raise 当前玩家下注或加注到 amount
+func (g *Game) Raise(amount int) error {
+	return g.act(ActionRaise, amount)
+}
+
+// act 执行当前玩家的动作，然后让接下来轮到的机器人依次行动
+func (g *Game) act(actionType ActionType, amount int) error {
+	if err := g.perform(actionType, amount); err != nil {
+		return err
+	}
+	return g.runBots()
+}
+
+// perform 执行当前玩家的动作，并推进牌局
+func (g *Game) perform(actionType ActionType, amount int) error {
+	if !g.IsPlayerStage() {
+		return fmt.Errorf("you cannot move during the %s stage", g.Stage)
+	}
+
+	p := g.CurrentSeat.Player
+	b := g.BettingRound
+	e := PlayerActed{Seat: g.seatOf(p), Type: actionType, Total: b.Bets[p]}
+
+	switch actionType {
+	case ActionFold, ActionCheck:
+	case ActionCall:
+		e.Total += min(b.CallAmount-b.Bets[p], p.Chips)
+	case ActionBet, ActionRaise:
+		e.Type = ActionRaise
+		if b.CallAmount == 0 {
+			e.Type = ActionBet
+		}
+		if amount <= b.CallAmount {
+			return fmt.Errorf("%s must %s more than the call amount (%d)", p.Name, e.Type, b.CallAmount)
+		}
+		e.Total = amount
+		e.RaiseBy = amount - b.CallAmount
+	default:
+		return fmt.Errorf("invalid action: %s", actionType)
+	}
+	e.Amount = e.Total - b.Bets[p]
+
+	if err := g.emit(e); err != nil {
+		return err
+	}
+	return g.check(g.advance())
+}
+
+// check 调试模式下在动作完成后校验牌局状态
+func (g *Game) check(err error) error {
+	if err != nil || !g.Debug {
+		return err
+	}
+	if err := g.Validate(); err != nil {
+		return fmt.Errorf("invalid game state: %w", err)
+	}
+	return nil
+}
+
+// advance 推进牌局。
+// 本轮下注结束时进入下一阶段，只剩一名玩家未弃牌时直接结束本手牌
+func (g *Game) advance() error {
+	if len(g.inHandPlayers()) == 1 {
+		return g.endHand()
+	}
+
+	if g.nextToAct() != nil {
+		return nil
+	}
+
+	return g.nextStage()
+}
+
+// nextStage 发出下一阶段的公共牌并开始新的下注轮次，河牌圈结束后进行摊牌
+func (g *Game) nextStage() error {
+	n := 0
+	switch g.Stage {
+	case GameStagePreflop:
+		n = 3
+	case GameStageFlop, GameStageTurn:
+		n = 1
+	default:
+		return g.endHand()
+	}
+
+	d := g.Deck
+	if d.CurrentCardIndex+n > DeckSize {
+		return fmt.Errorf("no more cards left in deck")
+	}
+
+	cards := d.Cards[d.CurrentCardIndex : d.CurrentCardIndex+n]
+	if err := g.emit(StreetDealt{Stage: g.Stage + 1, Cards: cards}); err != nil {
+		return err
+	}
+	return g.advance()
+}
+
+// endHand 结束本手牌，退还无人跟注的下注，并将主池和边池分配给赢家
+func (g *Game) endHand() error {
+	t := g.Table
+	inHand := g.inHandPlayers()
+
+	// 退还无人跟注的部分
+	bets := make([]PlayerBet, 0, len(t.Pot.Bets))
+	for p, total := range t.Pot.Bets {
+		bets = append(bets, PlayerBet{Player: p, Total: total})
+	}
+	sort.Sort(sort.Reverse(ByPlayerBet(bets)))
+	if len(bets) > 0 {
+		uncalled := bets[0].Total
+		if len(bets) > 1 {
+			uncalled -= bets[1].Total
+		}
+		if uncalled > 0 {
+			if err := g.emit(BetReturned{Seat: g.seatOf(bets[0].Player), Amount: uncalled}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 按规则计算抽水，发牌时的玩家数决定抽水上限。
+	// 有资格的玩家都已弃牌的一层是死钱，并入下面一层由其中的玩家赢取
+	sidePots := make([]SidePot, 0)
+	for _, sidePot := range t.Pot.GetSidePots() {
+		if n := len(sidePots); len(sidePot.Players) == 0 && n > 0 {
+			sidePots[n-1].Total += sidePot.Total
+			sidePots[n-1].MaxBet = sidePot.MaxBet
+			continue
+		}
+		if len(sidePot.Players) > 0 {
+			sidePots = append(sidePots, sidePot)
+		}
+	}
+	dealt := 0
+	for _, p := range g.PlayerMap {
+		if p.HoleCards[0] != nil {
+			dealt++
+		}
+	}
+	rakes := splitRake(g.Rake.Amount(t.Pot.GetTotal(), dealt, t.Flop[0] != nil), sidePots)
+
+	showdown := len(inHand) > 1
+	level := 0
+	for i, sidePot := range sidePots {
+
+		// 无需摊牌时，唯一未弃牌的玩家赢得奖池
+		var winners []PlayerHand
+		if showdown {
+			winners = FindWinningHands(sidePot.Players, t)
+		} else {
+			winners = []PlayerHand{{Player: sidePot.Players[0]}}
+		}
+		g.sortBySeat(winners)
+
+		// 扣除抽水和促销活动的筹码后平分奖池，无法整除的筹码从庄家左手边开始依次分配
+		e := PotAwarded{Amount: sidePot.Total, MaxBet: sidePot.MaxBet, Rake: rakes[i]}
+		e.RakeFrom = g.rakeFrom(rakes[i], level, sidePot.MaxBet)
+		amount := sidePot.Total - rakes[i]
+		if i == 0 {
+			e.Drops = g.drops(t.Pot.GetTotal(), amount)
+			for _, d := range e.Drops {
+				amount -= d.Amount
+			}
+		}
+		for _, p := range sidePot.Players {
+			e.Seats = append(e.Seats, g.seatOf(p))
+		}
+		share := amount / len(winners)
+		remainder := amount % len(winners)
+		for i, w := range winners {
+			amount := share
+			if i < remainder {
+				amount++
+			}
+			e.Winners = append(e.Winners, PotShare{Seat: g.seatOf(w.Player), Amount: amount})
+		}
+
+		if err := g.emit(e); err != nil {
+			return err
+		}
+		level = sidePot.MaxBet
+	}
+
+	return g.emit(HandEnded{Showdown: showdown})
+}
+
+// nextToAct 从当前座位的下一位开始，找出下一个需要行动的玩家。
+// 所有玩家都已行动且下注持平时返回 nil
+func (g *Game) nextToAct() *Seat {
+	actors := g.actors()
+	s := g.CurrentSeat.Next()
+	for i := 0; i < s.Len(); i++ {
+		if g.needsToAct(s.Player, actors) {
+			return s
+		}
+		s = s.Next()
+	}
+	return nil
+}
+
+// actors 能够行动的玩家数量，即没有弃牌也没有全下的玩家
+func (g *Game) actors() int {
+	actors := 0
+	for _, p := range g.inHandPlayers() {
+		if p.Chips > 0 {
+			actors++
+		}
+	}
+	return actors
+}
+
+// needsToAct 玩家在本轮下注中是否还需要行动，actors 为能够行动的玩家数量
+func (g *Game) needsToAct(p *Player, actors int) bool {
+	if p == nil || p.Status != PlayerActive || p.HasFolded || p.Chips <= 0 {
+		return false
+	}
+
+	// 需要跟注的玩家必须行动
+	b := g.BettingRound
+	if b.Bets[p] < b.CallAmount {
+		return true
+	}
+
+	// 其他玩家都已全下时无需再行动
+	return !b.Acted[p] && actors > 1
+}
+
+// inHandPlayers 获取本手牌中尚未弃牌的玩家
+func (g *Game) inHandPlayers() []*Player {
+	players := make([]*Player, 0)
+	for _, p := range g.Table.Seats.GetActivePlayers() {
+		if !p.HasFolded {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+// sortBySeat 将玩家按照从庄家左手边开始的顺时针顺序排序
+func (g *Game) sortBySeat(hands []PlayerHand) {
+	order := make(map[*Player]int)
+	s := g.Table.Dealer.Next()
+	for i := 0; i < s.Len(); i++ {
+		order[s.Player] = i
+		s = s.Next()
+	}
+	sort.SliceStable(hands, func(i, j int) bool {
+		return order[hands[i].Player] < order[hands[j].Player]
+	})
+}
+
+// seatOf 获取玩家所在的座位号
+func (g *Game) seatOf(p *Player) int {
+	return g.Table.seatOf(p).Index()
+}
@@ -0,0 +1,318 @@
+package poker
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGame 创建一个游戏，并按座位顺序让玩家带入给定的筹码
+func newTestGame(t *testing.T, chips ...int) (*Game, []*Player) {
+	g := NewGame()
+	g.Observers = append(g.Observers, &resultRecorder{})
+	players := make([]*Player, len(chips))
+	s := g.Table.Seats
+	for i := range chips {
+		require.NoError(t, g.TakeSeat(s.Player.Id, fmt.Sprintf("p%d", i+1), chips[i], true))
+		players[i] = s.Player
+		s = s.Next()
+	}
+	return g, players
+}
+
+// setCards 指定玩家的底牌，并用 board 替换剩余牌堆
+func setCards(g *Game, holeCards map[*Player][2]Card, board ...Card) {
+	for p, cards := range holeCards {
+		first, second := cards[0], cards[1]
+		p.HoleCards = [2]*Card{&first, &second}
+	}
+	g.Deck = &Deck{Cards: make([]Card, DeckSize), CurrentCardIndex: DeckSize - len(board)}
+	copy(g.Deck.Cards[DeckSize-len(board):], board)
+}
+
+func TestGameFoldPreflop(t *testing.T) {
+	g, ps := newTestGame(t, 500, 500, 500)
+	require.NoError(t, g.StartHand())
+
+	// 三人桌：p1 庄家，p2 小盲，p3 大盲，p1 首先行动
+	assert.Equal(t, ps[0], g.Table.Dealer.Player)
+	assert.Equal(t, ps[1], g.Table.SmallBlind.Player)
+	assert.Equal(t, ps[2], g.Table.BigBlind.Player)
+	assert.Equal(t, ps[0], g.CurrentSeat.Player)
+
+	require.NoError(t, g.Raise(30))
+	require.NoError(t, g.Fold())
+	require.NoError(t, g.Fold())
+
+	assert.Equal(t, GameStageShowdown, g.Stage)
+	assert.Equal(t, []int{515, 495, 490}, []int{ps[0].Chips, ps[1].Chips, ps[2].Chips})
+}
+
+func TestGameHeadsUpBlinds(t *testing.T) {
+	g, ps := newTestGame(t, 500, 500)
+	require.NoError(t, g.StartHand())
+
+	// 单挑时庄家下小盲注并首先行动
+	assert.Equal(t, ps[0], g.Table.SmallBlind.Player)
+	assert.Equal(t, ps[0], g.CurrentSeat.Player)
+	require.NoError(t, g.Call())
+
+	// 大盲注拥有行动权
+	assert.Equal(t, ps[1], g.CurrentSeat.Player)
+	require.NoError(t, g.Check())
+	assert.Equal(t, GameStageFlop, g.Stage)
+
+	// 翻牌后大盲注首先行动
+	assert.Equal(t, ps[1], g.CurrentSeat.Player)
+}
+
+func TestGameSidePots(t *testing.T) {
+	g, ps := newTestGame(t, 100, 300, 500)
+	require.NoError(t, g.StartHand())
+
+	setCards(g, map[*Player][2]Card{
+		ps[0]: {{Rank: Ace, Suit: Spades}, {Rank: Ace, Suit: Hearts}},
+		ps[1]: {{Rank: King, Suit: Spades}, {Rank: King, Suit: Hearts}},
+		ps[2]: {{Rank: Queen, Suit: Spades}, {Rank: Queen, Suit: Hearts}},
+	},
+		Card{Rank: Two, Suit: Clubs}, Card{Rank: Seven, Suit: Diamonds}, Card{Rank: Nine, Suit: Clubs},
+		Card{Rank: Four, Suit: Hearts}, Card{Rank: Three, Suit: Spades},
+	)
+
+	require.NoError(t, g.Raise(100))
+	require.NoError(t, g.Raise(300))
+	require.NoError(t, g.Call())
+
+	r := lastResult(t, g)
+	require.Len(t, r.Pots, 2)
+	assert.Equal(t, 300, r.Pots[0].Total)
+	assert.Equal(t, ps[0], r.Pots[0].Winners[0].Player)
+	assert.Equal(t, 400, r.Pots[1].Total)
+	assert.Equal(t, ps[1], r.Pots[1].Winners[0].Player)
+	assert.Equal(t, []int{300, 400, 200}, []int{ps[0].Chips, ps[1].Chips, ps[2].Chips})
+}
+
+func TestGameRake(t *testing.T) {
+	rule := &RakeRule{Percent: 5, Caps: []RakeCap{{Players: 2, Amount: 10}, {Players: 3, Amount: 30}}, NoFlopNoDrop: true}
+
+	// 没有发出翻牌时不抽水
+	g, ps := newTestGame(t, 500, 500, 500)
+	g.Rake, g.Debug = rule, true
+	require.NoError(t, g.StartHand())
+	require.NoError(t, g.Raise(30))
+	require.NoError(t, g.Fold())
+	require.NoError(t, g.Fold())
+	assert.Zero(t, lastResult(t, g).Rake)
+	assert.Equal(t, []int{515, 495, 490}, []int{ps[0].Chips, ps[1].Chips, ps[2].Chips})
+
+	g, ps = newTestGame(t, 100, 300, 500)
+	g.Rake = rule
+	require.NoError(t, g.StartHand())
+	setCards(g, map[*Player][2]Card{
+		ps[0]: {{Rank: Ace, Suit: Spades}, {Rank: Ace, Suit: Hearts}},
+		ps[1]: {{Rank: King, Suit: Spades}, {Rank: King, Suit: Hearts}},
+		ps[2]: {{Rank: Queen, Suit: Spades}, {Rank: Queen, Suit: Hearts}},
+	},
+		Card{Rank: Two, Suit: Clubs}, Card{Rank: Seven, Suit: Diamonds}, Card{Rank: Nine, Suit: Clubs},
+		Card{Rank: Four, Suit: Hearts}, Card{Rank: Three, Suit: Spades},
+	)
+	require.NoError(t, g.Raise(100))
+	require.NoError(t, g.Raise(300))
+	require.NoError(t, g.Call())
+
+	// 700 的 5% 超过三人桌的上限 30，按奖池金额比例从主池扣 13、从边池扣 17
+	r := lastResult(t, g)
+	assert.Equal(t, 30, r.Rake)
+	assert.Equal(t, []int{13, 17}, []int{r.Pots[0].Rake, r.Pots[1].Rake})
+	assert.Equal(t, []int{287, 383, 200}, []int{ps[0].Chips, ps[1].Chips, ps[2].Chips})
+
+	// 每个奖池的抽水按下注比例记到玩家名下
+	awarded := make([]PotAwarded, 0)
+	for _, e := range g.Events {
+		if e, ok := e.(PotAwarded); ok {
+			awarded = append(awarded, e)
+		}
+	}
+	require.Len(t, awarded, 2)
+	assert.Equal(t, []PotShare{{Seat: 0, Amount: 5}, {Seat: 1, Amount: 4}, {Seat: 2, Amount: 4}}, awarded[0].RakeFrom)
+	assert.Equal(t, []PotShare{{Seat: 1, Amount: 9}, {Seat: 2, Amount: 8}}, awarded[1].RakeFrom)
+
+	// 抽水离开牌桌，筹码守恒时需要扣除
+	assert.Equal(t, 900-30, g.chips)
+}
+
+func TestGameUncalledBet(t *testing.T) {
+	g, ps := newTestGame(t, 500, 500)
+	require.NoError(t, g.StartHand())
+	require.NoError(t, g.Raise(200))
+	require.NoError(t, g.Fold())
+
+	r := lastResult(t, g)
+	require.NotNil(t, r.Uncalled)
+	assert.Equal(t, 190, r.Uncalled.Total)
+	assert.Equal(t, 20, r.Pots[0].Total)
+	assert.Equal(t, []int{510, 490}, []int{ps[0].Chips, ps[1].Chips})
+}
+
+func TestGameDeadSidePot(t *testing.T) {
+	g, ps := newTestGame(t, 500, 500, 1)
+	require.NoError(t, g.SetBlinds(5, 2))
+	require.NoError(t, g.StartHand())
+	require.Equal(t, 0, ps[2].Chips)
+
+	// p3 只有 1 个筹码的前注，其余玩家都弃牌后，超出的前注也归 p3
+	require.NoError(t, g.Fold())
+	require.NoError(t, g.Fold())
+
+	r := lastResult(t, g)
+	require.Len(t, r.Pots, 1)
+	assert.Equal(t, SidePot{Players: []*Player{ps[2]}, Total: 5, MaxBet: 2}, r.Pots[0].SidePot)
+	assert.Equal(t, []int{498, 498, 5}, []int{ps[0].Chips, ps[1].Chips, ps[2].Chips})
+	assert.Equal(t, 0, g.Table.Pot.GetTotal())
+}
+
+func TestPotGetSidePots(t *testing.T) {
+	a, b, c := &Player{Id: "a"}, &Player{Id: "b"}, &Player{Id: "c", HasFolded: true}
+	pot := &Pot{Bets: map[*Player]int{a: 50, b: 200, c: 100}}
+
+	sidePots := pot.GetSidePots()
+	require.Len(t, sidePots, 2)
+	assert.Equal(t, SidePot{Players: []*Player{a, b}, Total: 150, MaxBet: 50}, sidePots[0])
+	assert.Equal(t, SidePot{Players: []*Player{b}, Total: 200, MaxBet: 200}, sidePots[1])
+}
+
+// resultRecorder 记录最近一手牌的结算结果
+type resultRecorder struct {
+	result *HandResult
+}
+
+func (r *resultRecorder) OnHandStart(g *Game)              {}
+func (r *resultRecorder) OnAction(g *Game, a Action)       {}
+func (r *resultRecorder) OnStage(g *Game, stage GameStage) {}
+func (r *resultRecorder) OnHandEnd(g *Game, hr *HandResult) {
+	r.result = hr
+}
+
+// lastResult 获取最近一手牌的结算结果
+func lastResult(t *testing.T, g *Game) *HandResult {
+	for _, o := range g.Observers {
+		if r, ok := o.(*resultRecorder); ok {
+			require.NotNil(t, r.result)
+			return r.result
+		}
+	}
+	t.Fatal("no result recorder")
+	return nil
+}
+
+func TestGameAntes(t *testing.T) {
+	g, ps := newTestGame(t, 500, 500, 2)
+	require.NoError(t, g.SetBlinds(5, 2))
+	require.NoError(t, g.StartHand())
+
+	// p3 在前注中全下，不再下大盲注，其他玩家仍需跟注完整的大盲注
+	assert.Equal(t, 0, ps[2].Chips)
+	assert.Equal(t, 2*3+5, g.Table.Pot.GetTotal())
+	assert.Equal(t, 10, g.BettingRound.CallAmount)
+	assert.Equal(t, ps[0], g.CurrentSeat.Player)
+
+	require.NoError(t, g.Call())
+	require.NoError(t, g.Call())
+	assert.Equal(t, GameStageFlop, g.Stage)
+	assert.Equal(t, 2*3+10*2, g.Table.Pot.GetTotal())
+}
+
+func TestGameLimit(t *testing.T) {
+	g, ps := newTestGame(t, 500, 500)
+	require.NoError(t, g.SetLimit(true))
+	require.NoError(t, g.SetBlinds(5, 0))
+	assert.True(t, g.Table.Limit)
+	require.NoError(t, g.StartHand())
+
+	// 翻牌前每次加注一个大盲注，加上大盲注最多下注四次
+	assert.Error(t, g.Raise(30))
+	require.NoError(t, g.Raise(20))
+	require.NoError(t, g.Raise(30))
+	require.NoError(t, g.Raise(40))
+	assert.Error(t, g.Raise(50))
+	assert.Equal(t, []LegalAction{
+		{Type: ActionFold, Min: 30, Max: 30},
+		{Type: ActionCall, Min: 40, Max: 40},
+	}, g.legalActions(ps[1]))
+	require.NoError(t, g.Call())
+
+	// 翻牌圈下注一个大盲注，转牌圈下注两个大盲注
+	assert.Equal(t, GameStageFlop, g.Stage)
+	require.NoError(t, g.Raise(10))
+	require.NoError(t, g.Call())
+	assert.Equal(t, GameStageTurn, g.Stage)
+	assert.Error(t, g.Raise(10))
+	assert.Equal(t, LegalAction{Type: ActionBet, Min: 20, Max: 20}, g.legalActions(ps[1])[1])
+	require.NoError(t, g.Raise(20))
+	require.NoError(t, g.Raise(40))
+	assert.Equal(t, 500-40-10-40, ps[0].Chips)
+}
+
+func TestPositions(t *testing.T) {
+	names := func(n int) string { return fmt.Sprint(Positions(n)) }
+	assert.Equal(t, "[]", names(1))
+	assert.Equal(t, "[BTN BB]", names(2))
+	assert.Equal(t, "[BTN SB BB]", names(3))
+	assert.Equal(t, "[BTN SB BB UTG]", names(4))
+	assert.Equal(t, "[BTN SB BB UTG CO]", names(5))
+	assert.Equal(t, "[BTN SB BB UTG HJ CO]", names(6))
+	assert.Equal(t, "[BTN SB BB UTG MP HJ CO]", names(7))
+	assert.Equal(t, "[BTN SB BB UTG UTG+1 MP HJ CO]", names(8))
+	assert.Equal(t, "[BTN SB BB UTG UTG+1 UTG+2 MP HJ CO]", names(9))
+	assert.Equal(t, "[BTN SB BB UTG UTG+1 UTG+2 UTG+3 MP HJ CO]", names(10))
+	assert.Equal(t, "[BTN SB BB UTG UTG+1 UTG+2 UTG+3 UTG+4 UTG+5 MP HJ CO]", names(12))
+	assert.Nil(t, Positions(1))
+
+	var p Position
+	require.NoError(t, p.UnmarshalText([]byte("UTG+1")))
+	assert.Equal(t, PositionUTG1, p)
+	require.NoError(t, p.UnmarshalText([]byte("UTG+5")))
+	assert.Equal(t, PositionUTGPlus(5), p)
+	assert.Error(t, p.UnmarshalText([]byte("LJ")))
+
+	// 九个座位中 p4 坐在 6 号座位，3 号到 5 号和 7、8 号座位为空
+	g := NewGameWithSeats(9)
+	for i, seat := range []int{0, 1, 2, 6} {
+		require.NoError(t, g.TakeSeat(g.Table.SeatAt(seat).Player.Id, fmt.Sprintf("p%d", i+1), 500, true))
+	}
+	assert.Equal(t, 6, g.Table.SeatAt(6).Index())
+	assert.Equal(t, PositionNone, g.Table.Position(g.Table.SeatAt(0)))
+	assert.Equal(t, 6, g.Table.NextActive(2).Index())
+	assert.Equal(t, 0, g.Table.NextActive(6).Index())
+	require.NoError(t, g.StartHand())
+
+	// p1 庄家，p2 小盲，p3 大盲，p4 枪口位首先行动
+	positions := map[int]Position{0: PositionButton, 1: PositionSmallBlind, 2: PositionBigBlind, 6: PositionUTG, 3: PositionNone, 8: PositionNone}
+	for seat, want := range positions {
+		assert.Equal(t, want, g.Table.Position(g.Table.SeatAt(seat)), seat)
+	}
+	left := func() []string {
+		names := make([]string, 0)
+		for _, p := range g.LeftToAct() {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"p4", "p1", "p2", "p3"}, left())
+
+	// p4 加注后 p1 弃牌，还剩两名盲注需要行动
+	require.NoError(t, g.Raise(60))
+	require.NoError(t, g.Fold())
+	assert.Equal(t, []string{"p2", "p3"}, left())
+	v, err := g.View(g.Table.SeatAt(6).Player.Id)
+	require.NoError(t, err)
+	assert.Equal(t, PositionUTG, v.Players[3].Position)
+	assert.Equal(t, PositionButton, g.Table.Position(g.Table.SeatAt(0)))
+
+	// 中途入座的玩家不参与这手牌，也没有位置
+	require.NoError(t, g.TakeSeat(g.Table.SeatAt(4).Player.Id, "p5", 500, true))
+	assert.Equal(t, PositionNone, g.Table.Position(g.Table.SeatAt(4)))
+	assert.Equal(t, PositionUTG, g.Table.Position(g.Table.SeatAt(6)))
+}
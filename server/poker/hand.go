@@ -35,8 +35,29 @@ func (h HandRank) String() string {
 // Hand 表示玩家手中的牌型。
 // 包括牌型等级和用于平局判定的牌点数列表
 type Hand struct {
-	Rank        HandRank   // 牌型等级
-	TieBreakers []CardRank // 用于平局判定的牌点数列表
+	Rank          HandRank   // 牌型等级
+	TieBreakers   []CardRank // 用于平局判定的牌点数列表
+	Cards         [5]Card    // 组成牌型的五张牌
+	HoleCardsUsed int        // 五张牌中用到的底牌数，由 GetBestHand 设置
+}
+
+// UsesBothHoleCards 两张底牌是否都用在了牌型中，常用于判断坏牌奖和最大牌型奖的资格
+func (h *Hand) UsesBothHoleCards() bool {
+	return h.HoleCardsUsed == 2
+}
+
+// AtLeast 牌型是否不小于给定的牌型和平局判定值，tieBreakers 只需给出需要比较的前几位。
+// 例如 AtLeast(FourOfAKind, Eight) 表示四条 8 或更大的牌型
+func (h *Hand) AtLeast(rank HandRank, tieBreakers ...CardRank) bool {
+	if h.Rank != rank {
+		return h.Rank > rank
+	}
+	for i, r := range tieBreakers {
+		if i >= len(h.TieBreakers) || h.TieBreakers[i] != r {
+			return i < len(h.TieBreakers) && h.TieBreakers[i] > r
+		}
+	}
+	return true
 }
 
 // CompareLess 比较当前手牌是否小于另一副手牌
@@ -69,6 +90,13 @@ func (h ByHand) Len() int           { return len(h) }
 func (h ByHand) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
 func (h ByHand) Less(i, j int) bool { return h[i].CompareLess(&h[j]) }
 
+// byRank 实现了牌点数的排序接口
+type byRank []CardRank
+
+func (r byRank) Len() int           { return len(r) }
+func (r byRank) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r byRank) Less(i, j int) bool { return r[i] < r[j] }
+
 // IsHand 是一个函数类型，用于检查特定的牌型
 type IsHand func(cs [5]Card) *Hand
 
@@ -264,6 +292,9 @@ func IsTwoPair(cs [5]Card) *Hand {
 		return nil
 	}
 
+	// 大的对子在前，保证平局判定的顺序稳定
+	sort.Sort(sort.Reverse(byRank(tieBreakers)))
+
 	// 添加单牌作为平局判定值
 	for _, c := range cs {
 		if rankCount[c.Rank] != 2 {
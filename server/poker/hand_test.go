@@ -0,0 +1,63 @@
+package poker
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBestHandHoleCards(t *testing.T) {
+	table := &Table{}
+	board := []Card{
+		{Rank: Eight, Suit: Clubs}, {Rank: Eight, Suit: Diamonds}, {Rank: Nine, Suit: Diamonds},
+		{Rank: Ten, Suit: Diamonds}, {Rank: Jack, Suit: Diamonds},
+	}
+	table.Flop = [3]*Card{&board[0], &board[1], &board[2]}
+	table.Turn, table.River = &board[3], &board[4]
+
+	// 两张 8 组成四条 8，两张底牌都用上了
+	p := &Player{HoleCards: [2]*Card{{Rank: Eight, Suit: Spades}, {Rank: Eight, Suit: Hearts}}}
+	h := GetBestHand(p, table)
+	assert.Equal(t, FourOfAKind, h.Rank)
+	assert.True(t, h.UsesBothHoleCards())
+	assert.True(t, h.AtLeast(FourOfAKind, Eight))
+	assert.False(t, h.AtLeast(FourOfAKind, Nine))
+	assert.True(t, h.AtLeast(FullHouse))
+
+	// 只用一张底牌组成同花顺
+	p = &Player{HoleCards: [2]*Card{{Rank: Queen, Suit: Diamonds}, {Rank: Two, Suit: Clubs}}}
+	h = GetBestHand(p, table)
+	assert.Equal(t, StraightFlush, h.Rank)
+	assert.Equal(t, 1, h.HoleCardsUsed)
+	assert.False(t, h.UsesBothHoleCards())
+	assert.Contains(t, h.Cards, Card{Rank: Queen, Suit: Diamonds})
+}
+
+func TestEvaluate(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	deck := NewDeck().Cards
+	for i := 0; i < 2000; i++ {
+		r.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+		a, b := deck[:7], deck[7:14]
+		table := &Table{Flop: [3]*Card{&a[2], &a[3], &a[4]}, Turn: &a[5], River: &a[6]}
+
+		// 与 GetBestHand 的牌型和比较结果一致
+		ha := GetBestHand(&Player{HoleCards: [2]*Card{&a[0], &a[1]}}, table)
+		hb := GetBestHand(&Player{HoleCards: [2]*Card{&b[0], &b[1]}}, table)
+		va := Evaluate(a...)
+		vb := Evaluate(append([]Card{b[0], b[1]}, a[2:]...)...)
+		require.Equal(t, ha.Rank, va.Rank(), "%v", a)
+
+		expected := CompareHand(ha, hb)
+		switch {
+		case va > vb:
+			require.Equal(t, GreaterThan, expected, "%v %v", a, b[:2])
+		case va < vb:
+			require.Equal(t, LessThan, expected, "%v %v", a, b[:2])
+		default:
+			require.Equal(t, EqualTo, expected, "%v %v", a, b[:2])
+		}
+	}
+}
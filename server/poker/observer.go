@@ -0,0 +1,10 @@
+package poker
+
+// Observer 牌局观察者。
+// 牌局推进到关键节点时，Game 会按注册顺序依次通知所有观察者
+type Observer interface {
+	OnHandStart(g *Game)              // 新一手牌开始，庄家和盲注位置已确定
+	OnAction(g *Game, a Action)       // 玩家完成一次动作（包括下盲注）
+	OnStage(g *Game, stage GameStage) // 发出新的公共牌，进入下一阶段
+	OnHandEnd(g *Game, r *HandResult) // 一手牌结束，奖池已分配
+}
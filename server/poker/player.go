@@ -159,7 +159,8 @@ func (p *Player) CanRaise(b *BettingRound) bool {
 	return (p.Status == PlayerActive && // 玩家处于活跃状态
 		!p.HasFolded && // 尚未弃牌
 		p.Chips > 0 && // 还有筹码
-		p.Chips >= b.CallAmount-b.Bets[p]) // 剩余筹码足够跟注
+		p.Chips >= b.CallAmount-b.Bets[p] && // 剩余筹码足够跟注
+		(b.Limit == 0 || b.Raises < limitBets)) // 限注时本轮的下注次数没有达到上限
 }
 
 // Raise 执行加注操作。
@@ -194,6 +195,16 @@ func (p *Player) Raise(t *Table, b *BettingRound, raiseAmount int) error {
 		return fmt.Errorf("%s does not have enough chips (%d) to %s (%d)", p.Name, p.Chips, actionLabel, chipsNeeded)
 	}
 
+	// 限注时每次只能加注固定的金额，筹码不足时全下
+	if b.Limit > 0 {
+		if b.Raises >= limitBets {
+			return fmt.Errorf("%s can't %s, the betting is capped at %d bets", p.Name, actionLabel, limitBets)
+		}
+		if limitTo := min(b.CallAmount+b.Limit, chipsInPot+p.Chips); raiseAmount != limitTo {
+			return fmt.Errorf("%s must %s to %d in a limit game", p.Name, actionLabel, limitTo)
+		}
+	}
+
 	// 计算最小加注额
 	minRaiseTo := b.CallAmount + b.RaiseByAmount
 
@@ -210,6 +221,7 @@ func (p *Player) Raise(t *Table, b *BettingRound, raiseAmount int) error {
 	// 更新游戏状态
 	b.CallAmount = raiseAmount
 	b.Raiser = p
+	b.Raises++
 	t.Pot.Bets[p] += chipsNeeded
 	b.Bets[p] += chipsNeeded
 	p.Chips -= chipsNeeded
@@ -10,30 +10,28 @@ type PlayerHand struct {
 }
 
 // FindWinningHands 找出拥有最佳手牌的玩家。
+// 先用 Evaluate 快速比较大小，只为赢家计算完整的手牌组合
 func FindWinningHands(players []*Player, t *Table) []PlayerHand {
-	winners := make([]PlayerHand, 0)
+	board := []Card{*t.Flop[0], *t.Flop[1], *t.Flop[2], *t.Turn, *t.River}
+	cards := make([]Card, 7)
+	copy(cards[2:], board)
 
-	// 找出拥有相同牌型的玩家
-	for i := range players {
-		bestHand := GetBestHand(players[i], t)
-
-		if len(winners) == 0 {
-			// 如果还没有赢家，将当前玩家设为默认赢家
-			winners = append(winners, PlayerHand{Hand: bestHand, Player: players[i]})
-		} else {
-			// 比较当前玩家的最佳手牌与已有的最佳手牌
-			result := CompareHand(bestHand, winners[0].Hand)
-			if result == GreaterThan {
-				// 如果找到更好的手牌，将当前玩家设为唯一赢家
-				winners = []PlayerHand{
-					{Hand: bestHand, Player: players[i]},
-				}
-			} else if result == EqualTo {
-				// 如果是平局，玩家将共享奖池
-				winners = append(winners, PlayerHand{Hand: bestHand, Player: players[i]})
-			}
+	// 找出牌最大的玩家，大小相同时共享奖池
+	winners := make([]PlayerHand, 0)
+	var best HandValue
+	for i, p := range players {
+		cards[0], cards[1] = *p.HoleCards[0], *p.HoleCards[1]
+		switch v := Evaluate(cards...); {
+		case i == 0 || v > best:
+			best = v
+			winners = []PlayerHand{{Player: p}}
+		case v == best:
+			winners = append(winners, PlayerHand{Player: p})
 		}
 	}
+	for i := range winners {
+		winners[i].Hand = GetBestHand(winners[i].Player, t)
+	}
 
 	// 按玩家筹码数排序
 	sort.SliceStable(winners, func(i, j int) bool {
@@ -43,9 +41,10 @@ func FindWinningHands(players []*Player, t *Table) []PlayerHand {
 }
 
 // GetBestHand 获取玩家的最佳手牌组合。
+// 用 Evaluate 比较 7 张牌中每种 5 张牌的组合，大小相同时优先使用底牌更多的组合
 func GetBestHand(p *Player, t *Table) *Hand {
 	// 收集所有可用的牌：2张手牌 + 5张公共牌
-	cards := []Card{
+	cards := [7]Card{
 		*p.HoleCards[0],
 		*p.HoleCards[1],
 		*t.Flop[0],
@@ -55,26 +54,35 @@ func GetBestHand(p *Player, t *Table) *Hand {
 		*t.River,
 	}
 
-	// 找出所有可能的5张牌组合
-	// endIndex 是排除法计算得出：总牌数(7) - 手牌数(5) + 1 = 3
-	cardCombos := FindCardCombinations(0, 3, cards)
-
-	var cardHand [5]Card
-	var bestHand *Hand
-	for _, cs := range cardCombos {
-		copy(cardHand[:], cs)
-		currentHand := CheckHand(cardHand)
-		if bestHand == nil {
-			// 如果是第一个组合，设为默认最佳手牌
-			bestHand = currentHand
-		} else {
-			result := CompareHand(currentHand, bestHand)
-			if result == GreaterThan {
-				// 如果当前组合更好，更新最佳手牌
-				bestHand = currentHand
+	// 每次去掉两张牌，共 21 种组合
+	var best [5]Card
+	var bestValue HandValue
+	bestUsed := -1
+	for i := 0; i < len(cards); i++ {
+		for j := i + 1; j < len(cards); j++ {
+			var hand [5]Card
+			k, used := 0, 0
+			for c := range cards {
+				if c == i || c == j {
+					continue
+				}
+				hand[k] = cards[c]
+				k++
+				if c < 2 {
+					used++
+				}
+			}
+
+			v := Evaluate(hand[:]...)
+			if bestUsed < 0 || v > bestValue || (v == bestValue && used > bestUsed) {
+				best, bestValue, bestUsed = hand, v, used
 			}
 		}
 	}
+
+	bestHand := CheckHand(best)
+	bestHand.Cards = best
+	bestHand.HoleCardsUsed = bestUsed
 	return bestHand
 }
 
@@ -0,0 +1,169 @@
+package poker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Position 玩家在一手牌中相对庄家的位置
+type Position int
+
+const (
+	PositionNone       Position = iota // 不在这手牌中
+	PositionButton                     // 庄家，单挑时庄家同时是小盲注
+	PositionSmallBlind                 // 小盲注
+	PositionBigBlind                   // 大盲注
+	PositionUTG                        // 枪口位，翻牌前第一个行动
+	PositionUTG1                       // 枪口位之后的第一个位置
+	PositionUTG2                       // 枪口位之后的第二个位置
+	PositionUTG3                       // 枪口位之后的第三个位置
+	PositionMP                         // 中间位置
+	PositionHJ                         // 劫位，关煞位的上一个位置
+	PositionCO                         // 关煞位，庄家的上一个位置
+)
+
+// PositionUTGPlus 获取枪口位之后第 k 个位置，k 为 0 时即枪口位。
+// 超过 UTG+3 的位置排在 CO 之后依次编号，用于十人以上的牌桌
+func PositionUTGPlus(k int) Position {
+	if k < 4 {
+		return PositionUTG + Position(k)
+	}
+	return PositionCO + Position(k-3)
+}
+
+// positionNames 位置的简称
+var positionNames = [...]string{"", "BTN", "SB", "BB", "UTG", "UTG+1", "UTG+2", "UTG+3", "MP", "HJ", "CO"}
+
+// String 返回位置的简称，例如 BTN、UTG+1，不在这手牌中时为空
+func (p Position) String() string {
+	switch {
+	case p < 0:
+		return fmt.Sprintf("Position(%d)", int(p))
+	case p > PositionCO:
+		return fmt.Sprintf("UTG+%d", int(p-PositionCO)+3)
+	}
+	return positionNames[p]
+}
+
+// MarshalText 序列化为位置的简称
+func (p Position) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText 从位置的简称反序列化
+func (p *Position) UnmarshalText(b []byte) error {
+	for i, name := range positionNames {
+		if name == string(b) {
+			*p = Position(i)
+			return nil
+		}
+	}
+	if rest, ok := strings.CutPrefix(string(b), "UTG+"); ok {
+		if k, err := strconv.Atoi(rest); err == nil && k >= 4 {
+			*p = PositionUTGPlus(k)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown position: %q", b)
+}
+
+// Positions 获取 n 名玩家参与时从庄家开始按顺时针排列的位置。
+// 大盲注和庄家之间的位置从后往前依次为 CO、HJ、MP，其余从 UTG 开始依次编号。
+// 少于 2 名玩家时返回 nil
+func Positions(n int) []Position {
+	switch {
+	case n < minPlayers:
+		return nil
+	case n == minPlayers:
+		return []Position{PositionButton, PositionBigBlind}
+	}
+
+	positions := []Position{PositionButton, PositionSmallBlind, PositionBigBlind}
+	between := n - 3 // 大盲注和庄家之间的玩家数
+	tail := []Position{PositionMP, PositionHJ, PositionCO}
+	tail = tail[len(tail)-max(0, min(between-1, len(tail))):]
+	for i := 0; i < between-len(tail); i++ {
+		positions = append(positions, PositionUTGPlus(i))
+	}
+	return append(positions, tail...)
+}
+
+// Position 获取座位上的玩家在最近一手牌中的位置，以庄家和这手牌发了底牌的玩家计算，
+// 包括已经弃牌的玩家。座位上的玩家没有参与这手牌时返回 PositionNone
+func (t *Table) Position(s *Seat) Position {
+	if t.Dealer == nil || !dealtIn(s.Player) {
+		return PositionNone
+	}
+
+	me, n := -1, 0
+	seat := t.Dealer
+	for i := 0; i < seat.Len(); i++ {
+		if dealtIn(seat.Player) {
+			if seat == s {
+				me = n
+			}
+			n++
+		}
+		seat = seat.Next()
+	}
+
+	positions := Positions(n)
+	if me < 0 || me >= len(positions) {
+		return PositionNone
+	}
+	return positions[me]
+}
+
+// dealtIn 玩家是否参与了最近一手牌
+func dealtIn(p *Player) bool {
+	return p != nil && p.Status == PlayerActive && p.HoleCards[0] != nil
+}
+
+// SeatAt 获取座位号为 i 的座位，从 0 开始
+func (t *Table) SeatAt(i int) *Seat {
+	s := t.Seats
+	for ; i > 0; i-- {
+		s = s.Next()
+	}
+	return s
+}
+
+// seatOf 获取玩家所在的座位，玩家不在牌桌上时返回 nil
+func (t *Table) seatOf(p *Player) *Seat {
+	s := t.Seats
+	for i := 0; i < s.Len(); i++ {
+		if s.Player == p {
+			return s
+		}
+		s = s.Next()
+	}
+	return nil
+}
+
+// NextActive 获取座位号为 i 的座位之后的第一个活跃玩家的座位，没有其他活跃玩家时返回 nil
+func (t *Table) NextActive(i int) *Seat {
+	s := t.SeatAt(i).NextActive()
+	if s.Index() == i {
+		return nil
+	}
+	return s
+}
+
+// LeftToAct 获取本轮下注中还需要行动的玩家，从当前行动的玩家开始按行动顺序排列
+func (g *Game) LeftToAct() []*Player {
+	players := make([]*Player, 0)
+	if !g.IsPlayerStage() || g.BettingRound == nil {
+		return players
+	}
+
+	actors := g.actors()
+	s := g.CurrentSeat
+	for i := 0; i < s.Len(); i++ {
+		if g.needsToAct(s.Player, actors) {
+			players = append(players, s.Player)
+		}
+		s = s.Next()
+	}
+	return players
+}
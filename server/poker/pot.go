@@ -1,5 +1,7 @@
 package poker
 
+import "sort"
+
 // Pot 表示当前游戏中的奖池。
 // 记录了所有玩家的下注情况，并支持边池的计算
 type Pot struct {
@@ -14,6 +16,23 @@ type SidePot struct {
 	MaxBet  int       // 该边池中每个玩家的最大下注额
 }
 
+// PotResult 记录一个边池的分配结果
+type PotResult struct {
+	SidePot              // 被分配的边池
+	Winners []PlayerHand // 赢得该边池的玩家，ChipsWon 为各自分得的筹码
+	Rake    int          // 从该边池中抽取的筹码
+	Drops   []Drop       // 从该边池中为促销活动抽取的筹码
+}
+
+// HandResult 记录一手牌结束时的结算结果
+type HandResult struct {
+	Pots     []PotResult // 主池和边池的分配结果，主池在前
+	Uncalled *PlayerBet  // 无人跟注而退还的下注，没有则为 nil
+	Showdown bool        // 是否进行了摊牌
+	Rake     int         // 本手牌的抽水总额
+	Drops    []Drop      // 本手牌为促销活动抽取的筹码
+}
+
 // GetTotal 计算奖池的总金额
 func (p *Pot) GetTotal() int {
 	total := 0
@@ -23,6 +42,69 @@ func (p *Pot) GetTotal() int {
 	return total
 }
 
+// GetSidePots 按玩家的下注额将奖池拆分为主池和边池。
+//
+// 每个下注额度形成一层，每层的金额由所有玩家在该层内的下注组成，
+// 只有未弃牌且下注达到该层上限的玩家才有资格赢取该层。
+// 参与者完全相同的相邻层会被合并，返回结果中主池在前
+func (p *Pot) GetSidePots() []SidePot {
+	bets := make([]PlayerBet, 0, len(p.Bets))
+	for player, total := range p.Bets {
+		if player != nil && total > 0 {
+			bets = append(bets, PlayerBet{Player: player, Total: total})
+		}
+	}
+	sort.Sort(ByPlayerBet(bets))
+
+	sidePots := make([]SidePot, 0)
+	level := 0
+	for i := range bets {
+		if bets[i].Total == level {
+			continue
+		}
+
+		// 收集本层的金额和参与者
+		sidePot := SidePot{MaxBet: bets[i].Total}
+		for _, bet := range bets {
+			if bet.Total > level {
+				sidePot.Total += min(bet.Total, sidePot.MaxBet) - level
+			}
+			if bet.Total >= sidePot.MaxBet && !bet.Player.HasFolded {
+				sidePot.Players = append(sidePot.Players, bet.Player)
+			}
+		}
+		level = sidePot.MaxBet
+
+		// 参与者与上一层相同时合并到上一层
+		if n := len(sidePots); n > 0 && samePlayers(sidePots[n-1].Players, sidePot.Players) {
+			sidePots[n-1].Total += sidePot.Total
+			sidePots[n-1].MaxBet = sidePot.MaxBet
+			continue
+		}
+		sidePots = append(sidePots, sidePot)
+	}
+
+	return sidePots
+}
+
+// samePlayers 判断两个玩家列表是否包含相同的玩家
+func samePlayers(a, b []*Player) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	set := make(map[*Player]bool, len(a))
+	for _, p := range a {
+		set[p] = true
+	}
+	for _, p := range b {
+		if !set[p] {
+			return false
+		}
+	}
+	return true
+}
+
 // NewPot 创建一个新的奖池
 func NewPot() *Pot {
 	return &Pot{Bets: make(map[*Player]int)}
@@ -0,0 +1,116 @@
+package poker
+
+import "sort"
+
+// RakeCap 按发牌时的玩家数设置的抽水上限
+type RakeCap struct {
+	Players int `json:"players"` // 发牌时的玩家数不少于此数时适用
+	Amount  int `json:"amount"`  // 每手牌的抽水上限
+}
+
+// RakeRule 抽水规则。
+// 每手牌按奖池总额的比例抽水，退还的无人跟注下注不计入；
+// 抽水总额按金额比例从主池和各个边池中扣除，再按下注比例记到每名玩家名下
+type RakeRule struct {
+	Percent      float64   `json:"percent"`         // 抽水比例，单位为百分比
+	Caps         []RakeCap `json:"caps,omitempty"`  // 抽水上限，使用玩家数满足条件的最高一档，没有适用的上限时不封顶
+	NoFlopNoDrop bool      `json:"no_flop_no_drop"` // 没有发出翻牌时不抽水
+}
+
+// Amount 计算一手牌的抽水总额
+func (r *RakeRule) Amount(pot, players int, flop bool) int {
+	if r == nil || pot <= 0 || (r.NoFlopNoDrop && !flop) {
+		return 0
+	}
+
+	// 加上一个很小的数，避免浮点误差使整数结果少算一个筹码
+	rake := int(float64(pot)*r.Percent/100 + 1e-9)
+	var limit *RakeCap
+	for i, c := range r.Caps {
+		if players >= c.Players && (limit == nil || c.Players > limit.Players) {
+			limit = &r.Caps[i]
+		}
+	}
+	if limit != nil {
+		rake = min(rake, limit.Amount)
+	}
+	return max(rake, 0)
+}
+
+// DropRule 每手牌为促销活动（如坏牌奖、最大牌型奖）从合格的奖池中抽取固定的筹码
+type DropRule struct {
+	Name   string `json:"name"`    // 促销活动名称
+	Amount int    `json:"amount"`  // 每手牌抽取的筹码
+	MinPot int    `json:"min_pot"` // 奖池达到此数并且发出了翻牌时才抽取
+}
+
+// Drop 为一项促销活动抽取的筹码
+type Drop struct {
+	Name   string `json:"name"`   // 促销活动名称
+	Amount int    `json:"amount"` // 抽取的筹码
+}
+
+// drops 按规则计算一手牌为各项促销活动抽取的筹码，总额不超过 limit
+func (g *Game) drops(pot, limit int) []Drop {
+	if g.Table.Flop[0] == nil {
+		return nil
+	}
+
+	var drops []Drop
+	for _, rule := range g.Drops {
+		if rule.Amount > 0 && pot >= rule.MinPot && rule.Amount <= limit {
+			drops = append(drops, Drop{Name: rule.Name, Amount: rule.Amount})
+			limit -= rule.Amount
+		}
+	}
+	return drops
+}
+
+// splitRake 将抽水总额按金额比例分到各个奖池，无法整除的部分从主池中扣除
+func splitRake(rake int, pots []SidePot) []int {
+	rakes := make([]int, len(pots))
+	total := 0
+	for _, pot := range pots {
+		total += pot.Total
+	}
+	if rake == 0 || total == 0 {
+		return rakes
+	}
+
+	taken := 0
+	for i, pot := range pots {
+		rakes[i] = rake * pot.Total / total
+		taken += rakes[i]
+	}
+	rakes[0] += rake - taken
+	return rakes
+}
+
+// rakeFrom 按玩家在当前这一层奖池中的下注比例分摊该奖池的抽水，用于返水统计。
+// 无法整除的部分按座位顺序依次分摊
+func (g *Game) rakeFrom(rake, level, maxBet int) []PotShare {
+	if rake == 0 {
+		return nil
+	}
+
+	shares := make([]PotShare, 0)
+	total := 0
+	for p, bet := range g.Table.Pot.Bets {
+		if in := min(bet, maxBet-level); in > 0 {
+			shares = append(shares, PotShare{Seat: g.seatOf(p), Amount: in})
+			total += in
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].Seat < shares[j].Seat })
+
+	taken := 0
+	for i := range shares {
+		shares[i].Amount = rake * shares[i].Amount / total
+		taken += shares[i].Amount
+	}
+	for i := 0; taken < rake; i++ {
+		shares[i%len(shares)].Amount++
+		taken++
+	}
+	return shares
+}
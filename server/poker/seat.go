@@ -12,9 +12,18 @@ import "container/ring"
 // - 每个座位可以为空，也可以坐着一个玩家
 type Seat struct {
 	node   *ring.Ring // 循环链表节点，用于实现座位的循环访问
+	index  int        // 座位号，从 0 开始，创建后不再改变
 	Player *Player    // 座位上的玩家，如果座位为空则为 nil
 }
 
+// Index 获取座位号，从 0 开始，与事件中的座位号一致。座位为 nil 时返回 -1
+func (s *Seat) Index() int {
+	if s == nil {
+		return -1
+	}
+	return s.index
+}
+
 // Next 获取桌上的下一个座位
 func (s *Seat) Next() *Seat {
 	return s.node.Next().Value.(*Seat)
@@ -29,7 +38,7 @@ func (s *Seat) Len() int {
 func NewSeat(n int) *Seat {
 	node := ring.New(n)
 	for i := 0; i < node.Len(); i++ {
-		node.Value = &Seat{node: node}
+		node.Value = &Seat{node: node, index: i}
 		node = node.Next()
 	}
 	return node.Value.(*Seat)
@@ -46,3 +55,19 @@ func (s *Seat) GetActivePlayers() []*Player {
 	}
 	return activePlayers
 }
+
+// NextActive 获取之后的第一个活跃玩家的座位，没有其他活跃玩家时绕一圈回到自己
+func (s *Seat) NextActive() *Seat {
+	for i := 0; i < s.Len(); i++ {
+		s = s.Next()
+		if s.Player != nil && s.Player.Status == PlayerActive {
+			return s
+		}
+	}
+	return s
+}
+
+// prev 获取桌上的上一个座位
+func (s *Seat) prev() *Seat {
+	return s.node.Prev().Value.(*Seat)
+}
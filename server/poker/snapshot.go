@@ -0,0 +1,380 @@
+package poker
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SnapshotVersion 当前快照格式的版本号，格式发生不兼容的变化时递增
+const SnapshotVersion = 1
+
+// Snapshot 牌局在某一时刻的完整状态，包括牌堆顺序，可以序列化为 JSON。
+//
+// 玩家、座位和下注都以座位号表示（从 0 开始），不包含指针。
+// 监听者、观察者和 DeckSource 不属于牌局状态，恢复后需要重新设置
+type Snapshot struct {
+	Version      int              `json:"version"`                 // 快照格式的版本号
+	HandId       int              `json:"hand_id"`                 // 当前手牌编号
+	Stage        GameStage        `json:"stage"`                   // 游戏阶段
+	MinBet       int              `json:"min_bet"`                 // 小盲注金额
+	Ante         int              `json:"ante"`                    // 前注金额
+	Limit        bool             `json:"limit,omitempty"`         // 是否为固定限注
+	Players      []PlayerSnapshot `json:"players"`                 // 按座位顺序排列的玩家
+	Dealer       int              `json:"dealer"`                  // 庄家座位号，没有则为 -1
+	SmallBlind   int              `json:"small_blind"`             // 小盲注座位号，没有则为 -1
+	BigBlind     int              `json:"big_blind"`               // 大盲注座位号，没有则为 -1
+	CurrentSeat  int              `json:"current_seat"`            // 当前座位号
+	Deck         []Card           `json:"deck"`                    // 牌堆中所有牌的顺序
+	DeckIndex    int              `json:"deck_index"`              // 下一张要发的牌在牌堆中的位置
+	Board        []Card           `json:"board"`                   // 已经发出的公共牌
+	PotBets      []int            `json:"pot_bets"`                // 每个座位在本手牌中的下注总额
+	BettingRound *RoundSnapshot   `json:"betting_round,omitempty"` // 当前下注轮次，没有则为 nil
+	Result       *ResultSnapshot  `json:"result,omitempty"`        // 当前或最近一手牌的结算结果
+	Rake         *RakeRule        `json:"rake,omitempty"`          // 抽水规则，没有则为 nil
+	Drops        []DropRule       `json:"drops,omitempty"`         // 为促销活动抽取筹码的规则
+}
+
+// PlayerSnapshot 一个座位上玩家的状态
+type PlayerSnapshot struct {
+	Id        string       `json:"id"`                   // 玩家唯一标识
+	Name      string       `json:"name"`                 // 玩家名称
+	Chips     int          `json:"chips"`                // 持有的筹码数
+	Status    PlayerStatus `json:"status"`               // 玩家状态
+	IsHuman   bool         `json:"is_human"`             // 是否是人类玩家
+	HasFolded bool         `json:"has_folded"`           // 是否已弃牌
+	HoleCards []Card       `json:"hole_cards,omitempty"` // 底牌，没有发牌时为空
+}
+
+// RoundSnapshot 下注轮次的状态
+type RoundSnapshot struct {
+	Bets          []int  `json:"bets"`             // 每个座位在本轮的下注金额
+	CallAmount    int    `json:"call_amount"`      // 当前需要跟注的金额
+	Raiser        int    `json:"raiser"`           // 最后一个加注的玩家的座位号，没有则为 -1
+	RaiseByAmount int    `json:"raise_by_amount"`  // 最小加注金额
+	Acted         []bool `json:"acted"`            // 每个座位在本轮是否已经行动过
+	Limit         int    `json:"limit,omitempty"`  // 限注时每次下注和加注的固定金额
+	Raises        int    `json:"raises,omitempty"` // 本轮下注和加注的次数
+}
+
+// ResultSnapshot 结算结果，奖池和退还的下注复用对应的事件
+type ResultSnapshot struct {
+	Pots     []PotAwarded `json:"pots"`               // 主池和边池的分配结果
+	Uncalled *BetReturned `json:"uncalled,omitempty"` // 无人跟注而退还的下注
+	Showdown bool         `json:"showdown"`           // 是否进行了摊牌
+}
+
+// Snapshot 获取牌局当前状态的快照
+func (g *Game) Snapshot() *Snapshot {
+	t := g.Table
+	n := t.Seats.Len()
+	s := &Snapshot{
+		Version:     SnapshotVersion,
+		HandId:      g.HandId,
+		Stage:       g.Stage,
+		MinBet:      t.MinBet,
+		Ante:        t.Ante,
+		Limit:       t.Limit,
+		Players:     make([]PlayerSnapshot, n),
+		Dealer:      t.Dealer.Index(),
+		SmallBlind:  t.SmallBlind.Index(),
+		BigBlind:    t.BigBlind.Index(),
+		CurrentSeat: g.CurrentSeat.Index(),
+		Deck:        append([]Card(nil), g.Deck.Cards...),
+		DeckIndex:   g.Deck.CurrentCardIndex,
+		Board:       t.GetBoard(),
+		PotBets:     make([]int, n),
+		Rake:        g.Rake,
+		Drops:       g.Drops,
+	}
+
+	for i := 0; i < n; i++ {
+		p := t.SeatAt(i).Player
+		s.Players[i] = PlayerSnapshot{
+			Id:        p.Id,
+			Name:      p.Name,
+			Chips:     p.Chips,
+			Status:    p.Status,
+			IsHuman:   p.IsHuman,
+			HasFolded: p.HasFolded,
+		}
+		if p.HoleCards[0] != nil && p.HoleCards[1] != nil {
+			s.Players[i].HoleCards = []Card{*p.HoleCards[0], *p.HoleCards[1]}
+		}
+		s.PotBets[i] = t.Pot.Bets[p]
+	}
+
+	if b := g.BettingRound; b != nil {
+		round := &RoundSnapshot{
+			Bets:          make([]int, n),
+			CallAmount:    b.CallAmount,
+			Raiser:        -1,
+			RaiseByAmount: b.RaiseByAmount,
+			Acted:         make([]bool, n),
+			Limit:         b.Limit,
+			Raises:        b.Raises,
+		}
+		if b.Raiser != nil {
+			round.Raiser = g.seatOf(b.Raiser)
+		}
+		for i := 0; i < n; i++ {
+			p := t.SeatAt(i).Player
+			round.Bets[i] = b.Bets[p]
+			round.Acted[i] = b.Acted[p]
+		}
+		s.BettingRound = round
+	}
+
+	if r := g.Result; r != nil {
+		result := &ResultSnapshot{Pots: make([]PotAwarded, 0, len(r.Pots)), Showdown: r.Showdown}
+		for _, pot := range r.Pots {
+			e := PotAwarded{Amount: pot.Total, MaxBet: pot.MaxBet, Rake: pot.Rake, Drops: pot.Drops}
+			for _, p := range pot.Players {
+				e.Seats = append(e.Seats, g.seatOf(p))
+			}
+			for _, w := range pot.Winners {
+				e.Winners = append(e.Winners, PotShare{Seat: g.seatOf(w.Player), Amount: w.ChipsWon})
+			}
+			result.Pots = append(result.Pots, e)
+		}
+		if r.Uncalled != nil {
+			result.Uncalled = &BetReturned{Seat: g.seatOf(r.Uncalled.Player), Amount: r.Uncalled.Total}
+		}
+		s.Result = result
+	}
+
+	return s
+}
+
+// MarshalSnapshot 获取牌局当前状态的快照并序列化为 JSON
+func (g *Game) MarshalSnapshot() ([]byte, error) {
+	return json.Marshal(g.Snapshot())
+}
+
+// UnmarshalSnapshot 从 JSON 中反序列化快照并恢复牌局
+func UnmarshalSnapshot(b []byte) (*Game, error) {
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return Restore(&s)
+}
+
+// Restore 根据快照恢复牌局。
+// 恢复后的事件日志为空，之后产生的事件从快照的状态开始记录
+func Restore(s *Snapshot) (*Game, error) {
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d, expected %d", s.Version, SnapshotVersion)
+	}
+
+	n := len(s.Players)
+	if n < minPlayers {
+		return nil, fmt.Errorf("a game needs at least %d seats, got %d", minPlayers, n)
+	}
+	if len(s.PotBets) != n {
+		return nil, fmt.Errorf("expected pot bets for %d seats, got %d", n, len(s.PotBets))
+	}
+	if s.Stage < GameStageWaiting || s.Stage > GameStageShowdown {
+		return nil, fmt.Errorf("invalid stage %d", s.Stage)
+	}
+
+	deck, err := NewDeckFromCards(s.Deck)
+	if err != nil {
+		return nil, err
+	}
+	if s.DeckIndex < 0 || s.DeckIndex > DeckSize {
+		return nil, fmt.Errorf("invalid deck index %d", s.DeckIndex)
+	}
+	deck.CurrentCardIndex = s.DeckIndex
+
+	// 按座位顺序放置玩家
+	seats := NewSeat(n)
+	playerMap := make(map[string]*Player, n)
+	for i, ps := range s.Players {
+		if _, ok := playerMap[ps.Id]; ok {
+			return nil, fmt.Errorf("duplicate player id %q", ps.Id)
+		}
+		if len(ps.HoleCards) != 0 && len(ps.HoleCards) != 2 {
+			return nil, fmt.Errorf("seat %d must have 0 or 2 hole cards, got %d", i, len(ps.HoleCards))
+		}
+
+		p := &Player{
+			Id:        ps.Id,
+			Name:      ps.Name,
+			Chips:     ps.Chips,
+			Status:    ps.Status,
+			IsHuman:   ps.IsHuman,
+			HasFolded: ps.HasFolded,
+		}
+		if len(ps.HoleCards) == 2 {
+			first, second := ps.HoleCards[0], ps.HoleCards[1]
+			p.HoleCards = [2]*Card{&first, &second}
+		}
+		seats.Player = p
+		playerMap[p.Id] = p
+		seats = seats.Next()
+	}
+
+	t := NewTable(NewPot(), seats)
+	t.MinBet = s.MinBet
+	t.Ante = s.Ante
+	t.Limit = s.Limit
+	g := &Game{
+		HandId:    s.HandId,
+		Stage:     s.Stage,
+		Deck:      deck,
+		Table:     t,
+		PlayerMap: playerMap,
+		Rake:      s.Rake,
+		Drops:     s.Drops,
+	}
+
+	// 座位号为 -1 时表示没有对应的座位
+	seatAt := func(name string, i int) (*Seat, error) {
+		if i == -1 {
+			return nil, nil
+		}
+		if i < 0 || i >= n {
+			return nil, fmt.Errorf("invalid %s seat %d", name, i)
+		}
+		return t.SeatAt(i), nil
+	}
+	if t.Dealer, err = seatAt("dealer", s.Dealer); err != nil {
+		return nil, err
+	}
+	if t.SmallBlind, err = seatAt("small blind", s.SmallBlind); err != nil {
+		return nil, err
+	}
+	if t.BigBlind, err = seatAt("big blind", s.BigBlind); err != nil {
+		return nil, err
+	}
+	if g.CurrentSeat, err = seatAt("current", s.CurrentSeat); err != nil {
+		return nil, err
+	}
+	if g.CurrentSeat == nil {
+		return nil, fmt.Errorf("the snapshot has no current seat")
+	}
+
+	// 公共牌
+	switch len(s.Board) {
+	case 5:
+		river := s.Board[4]
+		t.River = &river
+		fallthrough
+	case 4:
+		turn := s.Board[3]
+		t.Turn = &turn
+		fallthrough
+	case 3:
+		for i := range t.Flop {
+			card := s.Board[i]
+			t.Flop[i] = &card
+		}
+	case 0:
+	default:
+		return nil, fmt.Errorf("invalid board size %d", len(s.Board))
+	}
+
+	for i, bet := range s.PotBets {
+		if bet > 0 {
+			t.Pot.Bets[t.SeatAt(i).Player] = bet
+		}
+	}
+
+	// 牌桌上的筹码总数为所有玩家的筹码加上奖池
+	g.chips = t.Pot.GetTotal()
+	for _, p := range playerMap {
+		g.chips += p.Chips
+	}
+
+	if r := s.BettingRound; r != nil {
+		if len(r.Bets) != n || len(r.Acted) != n {
+			return nil, fmt.Errorf("expected betting round state for %d seats", n)
+		}
+
+		b := &BettingRound{
+			Bets:          make(map[*Player]int, n),
+			CallAmount:    r.CallAmount,
+			RaiseByAmount: r.RaiseByAmount,
+			Acted:         make(map[*Player]bool),
+			Limit:         r.Limit,
+			Raises:        r.Raises,
+		}
+		if raiser, err := seatAt("raiser", r.Raiser); err != nil {
+			return nil, err
+		} else if raiser != nil {
+			b.Raiser = raiser.Player
+		}
+		for i := 0; i < n; i++ {
+			p := t.SeatAt(i).Player
+			b.Bets[p] = r.Bets[i]
+			if r.Acted[i] {
+				b.Acted[p] = true
+			}
+		}
+		g.BettingRound = b
+	}
+
+	if r := s.Result; r != nil {
+		if g.Result, err = g.restoreResult(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// restoreResult 根据快照恢复结算结果，摊牌时重新计算赢家的牌型
+func (g *Game) restoreResult(r *ResultSnapshot) (*HandResult, error) {
+	result := &HandResult{Showdown: r.Showdown}
+	for _, pot := range r.Pots {
+		pr := PotResult{SidePot: SidePot{Total: pot.Amount, MaxBet: pot.MaxBet}, Rake: pot.Rake, Drops: pot.Drops}
+		result.Rake += pot.Rake
+		result.Drops = append(result.Drops, pot.Drops...)
+		for _, seat := range pot.Seats {
+			p, err := g.playerAt(seat)
+			if err != nil {
+				return nil, err
+			}
+			pr.Players = append(pr.Players, p)
+		}
+		for _, share := range pot.Winners {
+			p, err := g.playerAt(share.Seat)
+			if err != nil {
+				return nil, err
+			}
+
+			winner := PlayerHand{ChipsWon: share.Amount, Player: p}
+			if r.Showdown {
+				winner.Hand = GetBestHand(p, g.Table)
+			}
+			pr.Winners = append(pr.Winners, winner)
+		}
+		result.Pots = append(result.Pots, pr)
+	}
+
+	if r.Uncalled != nil {
+		p, err := g.playerAt(r.Uncalled.Seat)
+		if err != nil {
+			return nil, err
+		}
+		result.Uncalled = &PlayerBet{Player: p, Total: r.Uncalled.Amount}
+	}
+	return result, nil
+}
+
+// RebuildFrom 从快照恢复牌局，再按顺序应用快照之后的事件。
+// 重建的过程不会通知监听者和观察者
+func RebuildFrom(s *Snapshot, events []Event) (*Game, error) {
+	g, err := Restore(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range events {
+		if err := g.apply(e); err != nil {
+			return nil, fmt.Errorf("event %d (%s): %w", i, e.EventType(), err)
+		}
+		g.Events = append(g.Events, e)
+	}
+	return g, nil
+}
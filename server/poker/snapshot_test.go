@@ -0,0 +1,60 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRestoreMidHand(t *testing.T) {
+	g, _ := newTestGame(t, 500, 300, 500)
+	g.Rake = &RakeRule{Percent: 5, Caps: []RakeCap{{Players: 2, Amount: 30}}}
+	g.Drops = []DropRule{{Name: "bad beat", Amount: 2, MinPot: 100}}
+	require.NoError(t, g.StartHand())
+	require.NoError(t, g.Raise(30))
+	require.NoError(t, g.Call())
+	require.NoError(t, g.Call())
+	require.NoError(t, g.Raise(40))
+	require.Equal(t, GameStageFlop, g.Stage)
+
+	b, err := g.MarshalSnapshot()
+	require.NoError(t, err)
+	restored, err := UnmarshalSnapshot(b)
+	require.NoError(t, err)
+
+	// 恢复后的快照与原快照完全一致
+	assert.Equal(t, g.Snapshot(), restored.Snapshot())
+	assert.Empty(t, restored.Events)
+
+	// 两局继续进行相同的动作，全下后直接发完公共牌，结果一致
+	for _, game := range []*Game{g, restored} {
+		require.NoError(t, game.Raise(470))
+		require.NoError(t, game.Call())
+		require.NoError(t, game.Fold())
+		require.Equal(t, GameStageShowdown, game.Stage)
+	}
+	for id, p := range g.PlayerMap {
+		assert.Equal(t, p.Chips, restored.PlayerMap[id].Chips)
+	}
+	// 恢复后的牌局仍按原来的规则抽水和为促销活动抽取筹码
+	assert.Equal(t, 30, restored.Result.Rake)
+	assert.Equal(t, []Drop{{Name: "bad beat", Amount: 2}}, restored.Result.Drops)
+	assert.Equal(t, g.Snapshot(), restored.Snapshot())
+
+	// 快照加上之后的事件可以重建出最终的牌局
+	s, err := UnmarshalSnapshot(b)
+	require.NoError(t, err)
+	rebuilt, err := RebuildFrom(s.Snapshot(), restored.Events)
+	require.NoError(t, err)
+	assert.Equal(t, restored.Snapshot(), rebuilt.Snapshot())
+}
+
+func TestRestoreRejectsUnknownVersion(t *testing.T) {
+	g, _ := newTestGame(t, 500, 500)
+	s := g.Snapshot()
+	s.Version = SnapshotVersion + 1
+
+	_, err := Restore(s)
+	assert.Error(t, err)
+}
@@ -10,6 +10,8 @@ type Table struct {
 	SmallBlind *Seat    // 小盲注座位，位于庄家的下一个位置
 	BigBlind   *Seat    // 大盲注座位，位于小盲注的下一个位置
 	MinBet     int      // 最小下注额，通常等于大盲注的金额
+	Ante       int      // 前注，每手牌开始时每名玩家都需要下的筹码，不计入本轮下注
+	Limit      bool     // 是否为固定限注，翻牌前和翻牌圈每次下注一个大盲注，转牌圈和河牌圈每次两个大盲注
 	Pot        *Pot     // 当前奖池，记录所有玩家的下注金额
 	Flop       [3]*Card // 公共牌：翻牌，游戏中首先发出的三张公共牌
 	Turn       *Card    // 公共牌：转牌，第四张公共牌
@@ -41,14 +43,15 @@ func (t *Table) DealHands(d *Deck) {
 	}
 }
 
-// TakeSmallBlind 收取小盲注
+// TakeSmallBlind 收取小盲注。
+// 筹码不足小盲注的玩家以全部筹码全下
 func (t *Table) TakeSmallBlind(b *BettingRound) error {
 	p := t.SmallBlind.Player
-	if p.Chips < t.MinBet {
+	if p.Chips <= 0 {
 		return fmt.Errorf("%s does not have enough chips to play", p.Name)
 	}
 
-	smallBlind := t.MinBet
+	smallBlind := min(t.MinBet, p.Chips)
 	p.Chips -= smallBlind
 	t.Pot.Bets[p] += smallBlind
 	b.Bets[p] = smallBlind
@@ -56,19 +59,44 @@ func (t *Table) TakeSmallBlind(b *BettingRound) error {
 	return nil
 }
 
-// TakeBigBlind 收取大盲注
+// TakeBigBlind 收取大盲注。
+// 筹码不足大盲注的玩家以全部筹码全下，其他玩家仍需跟注完整的大盲注
 func (t *Table) TakeBigBlind(b *BettingRound) error {
 	p := t.BigBlind.Player
-	if p.Chips < t.MinBet {
+	if p.Chips <= 0 {
 		return fmt.Errorf("%s does not have enough chips to play", p.Name)
 	}
 
-	bigBlind := t.MinBet * 2
+	bigBlind := min(t.MinBet*2, p.Chips)
 	p.Chips -= bigBlind
 	t.Pot.Bets[p] += bigBlind
 	b.Bets[p] = bigBlind
-	b.CallAmount = bigBlind
-	b.RaiseByAmount = bigBlind
+	b.CallAmount = t.MinBet * 2
+	b.RaiseByAmount = t.MinBet * 2
+	return nil
+}
+
+// LimitBet 限注时指定阶段每次下注和加注的固定金额，不限注时为 0
+func (t *Table) LimitBet(stage GameStage) int {
+	if !t.Limit {
+		return 0
+	}
+	if stage >= GameStageTurn {
+		return t.MinBet * 4
+	}
+	return t.MinBet * 2
+}
+
+// TakeAnte 收取玩家的前注。
+// 前注直接进入奖池，不计入本轮的下注，筹码不足的玩家以全部筹码全下
+func (t *Table) TakeAnte(p *Player) error {
+	if p.Chips <= 0 {
+		return fmt.Errorf("%s does not have enough chips to play", p.Name)
+	}
+
+	ante := min(t.Ante, p.Chips)
+	p.Chips -= ante
+	t.Pot.Bets[p] += ante
 	return nil
 }
 
@@ -91,3 +119,22 @@ func (t *Table) DealRiver(d *Deck) {
 	card, _ := d.GetNextCard()
 	t.River = card
 }
+
+// GetBoard 获取已经发出的公共牌，按发牌顺序排列
+func (t *Table) GetBoard() []Card {
+	board := make([]Card, 0, 5)
+	for _, card := range append(t.Flop[:], t.Turn, t.River) {
+		if card != nil {
+			board = append(board, *card)
+		}
+	}
+	return board
+}
+
+// ClearBoard 清空公共牌和奖池，为新的一手牌做准备
+func (t *Table) ClearBoard() {
+	t.Pot = NewPot()
+	t.Flop = [3]*Card{}
+	t.Turn = nil
+	t.River = nil
+}
@@ -0,0 +1,101 @@
+package poker
+
+import "fmt"
+
+// Validate 校验牌局状态的不变量，返回发现的第一个问题：
+//   - 筹码守恒：所有玩家的筹码加上奖池等于带入牌桌的筹码总数
+//   - 筹码和下注都不能为负数，本轮下注不能超过玩家在奖池中的下注
+//   - 玩家阶段的当前座位上是未弃牌的活跃玩家，一手牌结束后奖池已全部分配
+//   - 玩家的底牌、公共牌和牌堆中剩余的牌没有重复
+func (g *Game) Validate() error {
+	t := g.Table
+	if t == nil {
+		return fmt.Errorf("the game has not been created yet")
+	}
+
+	// 筹码守恒
+	total := 0
+	for _, p := range g.PlayerMap {
+		if p.Chips < 0 {
+			return fmt.Errorf("%s has a negative stack (%d)", p.Name, p.Chips)
+		}
+		total += p.Chips
+	}
+	for p, bet := range t.Pot.Bets {
+		if bet < 0 {
+			return fmt.Errorf("%s has a negative bet (%d) in the pot", p.Name, bet)
+		}
+		total += bet
+	}
+	if total != g.chips {
+		return fmt.Errorf("there are %d chips on the table, expected %d", total, g.chips)
+	}
+
+	if b := g.BettingRound; b != nil {
+		for p, bet := range b.Bets {
+			if bet < 0 {
+				return fmt.Errorf("%s has a negative bet (%d) in the betting round", p.Name, bet)
+			}
+			if bet > t.Pot.Bets[p] {
+				return fmt.Errorf("%s has bet %d this round but only %d in the pot", p.Name, bet, t.Pot.Bets[p])
+			}
+		}
+	}
+
+	if g.IsPlayerStage() {
+		if g.BettingRound == nil {
+			return fmt.Errorf("there is no betting round during the %s stage", g.Stage)
+		}
+		if g.CurrentSeat == nil || g.CurrentSeat.Player == nil {
+			return fmt.Errorf("there is no current seat during the %s stage", g.Stage)
+		}
+		if p := g.CurrentSeat.Player; p.Status != PlayerActive || p.HasFolded {
+			return fmt.Errorf("it is the turn of %s, who is not in the hand", p.Name)
+		}
+	}
+	if g.Stage == GameStageShowdown && t.Pot.GetTotal() != 0 {
+		return fmt.Errorf("%d chips are left in the pot after the hand ended", t.Pot.GetTotal())
+	}
+
+	return g.validateCards()
+}
+
+// validateCards 校验牌堆是一副完整的扑克牌，且底牌、公共牌和未发出的牌互不重复
+func (g *Game) validateCards() error {
+	d := g.Deck
+	if _, err := NewDeckFromCards(d.Cards); err != nil {
+		return err
+	}
+	if d.CurrentCardIndex < 0 || d.CurrentCardIndex > DeckSize {
+		return fmt.Errorf("invalid deck index %d", d.CurrentCardIndex)
+	}
+
+	seen := make(map[Card]string)
+	for _, c := range d.Cards[d.CurrentCardIndex:] {
+		seen[c] = "the deck"
+	}
+
+	use := func(c Card, owner string) error {
+		if other, ok := seen[c]; ok {
+			return fmt.Errorf("%s is held by both %s and %s", c.Symbol(), other, owner)
+		}
+		seen[c] = owner
+		return nil
+	}
+	for _, p := range g.PlayerMap {
+		for _, c := range p.HoleCards {
+			if c == nil {
+				continue
+			}
+			if err := use(*c, p.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, c := range g.Table.GetBoard() {
+		if err := use(c, "the board"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
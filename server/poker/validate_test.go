@@ -0,0 +1,87 @@
+package poker
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDetectsChipLeak(t *testing.T) {
+	g, ps := newTestGame(t, 500, 500)
+	require.NoError(t, g.StartHand())
+	require.NoError(t, g.Validate())
+
+	ps[0].Chips += 10
+	assert.ErrorContains(t, g.Validate(), "chips on the table")
+}
+
+// FuzzGame 用随机的合法动作进行多手牌，每个动作之后校验牌局状态
+func FuzzGame(f *testing.F) {
+	f.Add(int64(1), uint8(2), uint8(5))
+	f.Add(int64(2), uint8(3), uint8(10))
+	f.Add(int64(3), uint8(6), uint8(20))
+
+	f.Fuzz(func(t *testing.T, seed int64, seats uint8, hands uint8) {
+		r := rand.New(rand.NewSource(seed))
+		g := NewGameWithSeats(minPlayers + int(seats)%(numPlayers-minPlayers+1))
+		g.Debug = true
+		require.NoError(t, g.SetBlinds(defaultMinBet, r.Intn(3)))
+		g.DeckSource = func() *Deck {
+			d := NewDeck()
+			r.Shuffle(len(d.Cards), func(i, j int) { d.Cards[i], d.Cards[j] = d.Cards[j], d.Cards[i] })
+			return d
+		}
+
+		s := g.Table.Seats
+		for i := 0; i < s.Len(); i++ {
+			require.NoError(t, g.TakeSeat(s.Player.Id, s.Player.Id, 1+r.Intn(1000), false))
+			s = s.Next()
+		}
+
+		for hand := 0; hand <= int(hands)%50; hand++ {
+			// 有筹码的玩家不足时无法再开始新的一手牌
+			funded := 0
+			for _, p := range g.Table.Seats.GetActivePlayers() {
+				if p.Chips > 0 {
+					funded++
+				}
+			}
+			if funded < minPlayers {
+				return
+			}
+			require.NoError(t, g.StartHand())
+			for g.IsPlayerStage() {
+				require.NoError(t, randomAction(g, r))
+			}
+			require.NoError(t, g.Validate())
+		}
+	})
+}
+
+// randomAction 让当前玩家执行一个随机的合法动作
+func randomAction(g *Game, r *rand.Rand) error {
+	p, b := g.CurrentSeat.Player, g.BettingRound
+
+	actions := make([]func() error, 0)
+	if p.CanFold(b) {
+		actions = append(actions, g.Fold)
+	}
+	if p.CanCheck(b) {
+		actions = append(actions, g.Check)
+	}
+	if p.CanCall(b) {
+		actions = append(actions, g.Call)
+	}
+
+	// 下注额在最小加注额和全下之间，筹码不足最小加注额时只能全下
+	if allIn := b.Bets[p] + p.Chips; allIn > b.CallAmount {
+		minRaise := min(b.CallAmount+b.RaiseByAmount, allIn)
+		actions = append(actions, func() error {
+			return g.Raise(minRaise + r.Intn(allIn-minRaise+1))
+		})
+	}
+
+	return actions[r.Intn(len(actions))]()
+}
@@ -0,0 +1,67 @@
+package promo
+
+import (
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// HighHand 最大牌型奖，如每小时最大牌型奖。
+// 每手合格的牌局从主池中抽取固定的筹码累积到奖池，每个时段结束时奖池发给该时段摊牌时满足资格的最大手牌，
+// 没有合格手牌时奖池留到下一个时段
+type HighHand struct {
+	Name      string           // 促销活动名称，与牌局抽取规则的名称一致
+	Qualifier Qualifier        // 手牌的资格
+	Period    time.Duration    // 每个时段的长度
+	Pool      int              // 当前奖池
+	Best      *Award           // 当前时段的最大手牌，Amount 在发放时才确定
+	Awards    []Award          // 已经发放的奖金
+	Now       func() time.Time // 获取当前时间
+
+	start time.Time // 当前时段开始的时间
+}
+
+// NewHighHand 创建最大牌型奖，从现在开始第一个时段
+func NewHighHand(name string, q Qualifier, period time.Duration) *HighHand {
+	h := &HighHand{Name: name, Qualifier: q, Period: period, Now: time.Now}
+	h.start = h.Now()
+	return h
+}
+
+// OnEvent 累积抽取的筹码，一手牌摊牌结束时记录满足资格的最大手牌。
+// 时段结束后的第一手牌会先结算上一个时段
+func (h *HighHand) OnEvent(g *poker.Game, e poker.Event) {
+	switch e := e.(type) {
+	case poker.PotAwarded:
+		h.Pool += dropped(e, h.Name)
+
+	case poker.HandEnded:
+		if h.Period > 0 && h.Now().Sub(h.start) >= h.Period {
+			h.Settle()
+		}
+		if !e.Showdown {
+			return
+		}
+		for _, ph := range shown(g) {
+			if !h.Qualifier.Qualifies(ph.Hand) {
+				continue
+			}
+			if h.Best == nil || h.Best.Hand.CompareLess(ph.Hand) {
+				h.Best = &Award{Promotion: h.Name, Name: ph.Player.Name, Role: RoleHighHand, HandId: g.HandId, Hand: ph.Hand}
+			}
+		}
+	}
+}
+
+// Settle 结算当前时段，将奖池发给最大手牌并开始新的时段，返回发放的奖金，没有合格手牌时返回 nil
+func (h *HighHand) Settle() *Award {
+	h.start = h.Now()
+	best := h.Best
+	if best == nil {
+		return nil
+	}
+
+	best.Amount, h.Pool, h.Best = h.Pool, 0, nil
+	h.Awards = append(h.Awards, *best)
+	return best
+}
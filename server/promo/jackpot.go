@@ -0,0 +1,89 @@
+package promo
+
+import "github.com/lllllan02/pocker/poker"
+
+// Jackpot 坏牌奖。
+// 每手合格的牌局从主池中抽取固定的筹码累积到奖池，满足资格的手牌在摊牌时输掉主池即触发，
+// 按比例分给输家、赢家和同桌参与这手牌的其他玩家，未分完的筹码留在奖池中作为下一次的底金
+type Jackpot struct {
+	Name        string    // 促销活动名称，与牌局抽取规则的名称一致
+	Qualifier   Qualifier // 输家手牌的资格
+	LoserShare  int       // 输家分得的比例，单位为百分比
+	WinnerShare int       // 赢家分得的比例，多名赢家平分
+	TableShare  int       // 同桌其他玩家分得的比例，按人数平分
+	Pool        int       // 当前奖池
+	Awards      []Award   // 已经发放的奖金
+}
+
+// NewJackpot 创建坏牌奖，三项比例之和不能超过 100
+func NewJackpot(name string, q Qualifier, loser, winner, table int) (*Jackpot, error) {
+	if err := validateShares(loser, winner, table); err != nil {
+		return nil, err
+	}
+	return &Jackpot{Name: name, Qualifier: q, LoserShare: loser, WinnerShare: winner, TableShare: table}, nil
+}
+
+// OnEvent 累积抽取的筹码，一手牌摊牌结束时检查是否触发坏牌奖
+func (j *Jackpot) OnEvent(g *poker.Game, e poker.Event) {
+	switch e := e.(type) {
+	case poker.PotAwarded:
+		j.Pool += dropped(e, j.Name)
+
+	case poker.HandEnded:
+		if e.Showdown && g.Result != nil && len(g.Result.Pots) > 0 {
+			j.check(g, g.Result.Pots[0])
+		}
+	}
+}
+
+// check 在主池的输家中找出满足资格的最大手牌，找到时发放奖金
+func (j *Jackpot) check(g *poker.Game, pot poker.PotResult) {
+	won := make(map[*poker.Player]bool)
+	for _, w := range pot.Winners {
+		won[w.Player] = true
+	}
+
+	var loser *poker.PlayerHand
+	for _, h := range shown(g) {
+		h := h
+		if won[h.Player] || !j.Qualifier.Qualifies(h.Hand) {
+			continue
+		}
+		if loser == nil || loser.Hand.CompareLess(h.Hand) {
+			loser = &h
+		}
+	}
+	if loser == nil || len(pot.Winners) == 0 {
+		return
+	}
+
+	pool := j.Pool
+	j.pay(g, loser.Player, RoleLoser, pool*j.LoserShare/100, loser.Hand)
+
+	share := pool * j.WinnerShare / 100 / len(pot.Winners)
+	for _, w := range pot.Winners {
+		j.pay(g, w.Player, RoleWinner, share, w.Hand)
+	}
+
+	table := make([]*poker.Player, 0)
+	for _, p := range g.Table.Seats.GetActivePlayers() {
+		if p != loser.Player && !won[p] && p.HoleCards[0] != nil {
+			table = append(table, p)
+		}
+	}
+	if len(table) > 0 {
+		share := pool * j.TableShare / 100 / len(table)
+		for _, p := range table {
+			j.pay(g, p, RoleTable, share, nil)
+		}
+	}
+}
+
+// pay 从奖池中发放一笔奖金
+func (j *Jackpot) pay(g *poker.Game, p *poker.Player, role Role, amount int, h *poker.Hand) {
+	if amount <= 0 {
+		return
+	}
+	j.Pool -= amount
+	j.Awards = append(j.Awards, Award{Promotion: j.Name, Name: p.Name, Role: role, Amount: amount, HandId: g.HandId, Hand: h})
+}
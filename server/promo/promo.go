@@ -0,0 +1,84 @@
+package promo
+
+import (
+	"fmt"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Role 获奖者在促销活动中的身份
+type Role string
+
+const (
+	RoleLoser    Role = "loser"     // 坏牌奖中输掉底池的合格手牌
+	RoleWinner   Role = "winner"    // 坏牌奖中赢下底池的玩家
+	RoleTable    Role = "table"     // 坏牌奖中同桌参与这手牌的其他玩家
+	RoleHighHand Role = "high_hand" // 最大牌型奖的获得者
+)
+
+// Qualifier 参与促销活动的手牌资格
+type Qualifier struct {
+	Rank          poker.HandRank   `json:"rank"`            // 最小的牌型
+	TieBreakers   []poker.CardRank `json:"tie_breakers"`    // 最小牌型的平局判定值，如四条 8 为 [Eight]
+	BothHoleCards bool             `json:"both_hole_cards"` // 两张底牌是否都必须用在牌型中
+}
+
+// Qualifies 手牌是否满足资格
+func (q Qualifier) Qualifies(h *poker.Hand) bool {
+	if h == nil || !h.AtLeast(q.Rank, q.TieBreakers...) {
+		return false
+	}
+	return !q.BothHoleCards || h.UsesBothHoleCards()
+}
+
+// Award 促销活动的一笔奖金
+type Award struct {
+	Promotion string      `json:"promotion"`      // 促销活动名称
+	Name      string      `json:"name"`           // 获奖玩家名称
+	Role      Role        `json:"role"`           // 获奖者的身份
+	Amount    int         `json:"amount"`         // 奖金
+	HandId    int         `json:"hand_id"`        // 触发奖金的手牌编号
+	Hand      *poker.Hand `json:"hand,omitempty"` // 获奖的手牌，同桌奖金没有手牌
+}
+
+// shown 获取一手牌摊牌时亮出的手牌，按座位顺序排列，没有发完公共牌时返回 nil
+func shown(g *poker.Game) []poker.PlayerHand {
+	t := g.Table
+	if t.River == nil {
+		return nil
+	}
+
+	hands := make([]poker.PlayerHand, 0)
+	for _, p := range t.Seats.GetActivePlayers() {
+		if !p.HasFolded && p.HoleCards[0] != nil {
+			hands = append(hands, poker.PlayerHand{Player: p, Hand: poker.GetBestHand(p, t)})
+		}
+	}
+	return hands
+}
+
+// dropped 获取奖池分配事件中为促销活动抽取的筹码
+func dropped(e poker.PotAwarded, name string) int {
+	total := 0
+	for _, d := range e.Drops {
+		if d.Name == name {
+			total += d.Amount
+		}
+	}
+	return total
+}
+
+// validateShares 检查奖金比例，单位为百分比
+func validateShares(shares ...int) error {
+	total := 0
+	for _, s := range shares {
+		if s < 0 {
+			return fmt.Errorf("shares cannot be negative, got %d", s)
+		}
+		total += s
+	}
+	if total > 100 {
+		return fmt.Errorf("shares cannot add up to more than 100, got %d", total)
+	}
+	return nil
+}
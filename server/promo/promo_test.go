@@ -0,0 +1,86 @@
+package promo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stackDeck 将指定的牌依次放到牌堆顶部
+func stackDeck(cards ...poker.Card) *poker.Deck {
+	d := poker.NewDeck()
+	for i, c := range cards {
+		for j := range d.Cards {
+			if d.Cards[j] == c {
+				d.Cards[i], d.Cards[j] = d.Cards[j], d.Cards[i]
+			}
+		}
+	}
+	return d
+}
+
+func TestPromotions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	g := poker.NewGame()
+	g.Debug = true
+	g.Drops = []poker.DropRule{{Name: "bad beat", Amount: 2}, {Name: "high hand", Amount: 1}}
+
+	jackpot, err := NewJackpot("bad beat", Qualifier{Rank: poker.FourOfAKind, TieBreakers: []poker.CardRank{poker.Eight}, BothHoleCards: true}, 50, 25, 25)
+	require.NoError(t, err)
+	jackpot.Pool = 1000
+	highHand := NewHighHand("high hand", Qualifier{Rank: poker.FourOfAKind}, time.Hour)
+	highHand.Now = func() time.Time { return now }
+	highHand.Settle()
+	g.Listeners = append(g.Listeners, jackpot, highHand)
+
+	players := make([]*poker.Player, 0)
+	s := g.Table.Seats
+	for _, name := range []string{"p1", "p2", "p3"} {
+		require.NoError(t, g.TakeSeat(s.Player.Id, name, 500, true))
+		players = append(players, s.Player)
+		s = s.Next()
+	}
+
+	// p2 的四条 8 用上了两张底牌，输给了 p3 的同花顺
+	g.DeckSource = func() *poker.Deck {
+		return stackDeck(
+			poker.Card{Rank: poker.Two, Suit: poker.Clubs}, poker.Card{Rank: poker.Eight, Suit: poker.Spades}, poker.Card{Rank: poker.Queen, Suit: poker.Diamonds},
+			poker.Card{Rank: poker.Three, Suit: poker.Hearts}, poker.Card{Rank: poker.Eight, Suit: poker.Hearts}, poker.Card{Rank: poker.King, Suit: poker.Diamonds},
+			poker.Card{Rank: poker.Eight, Suit: poker.Clubs}, poker.Card{Rank: poker.Eight, Suit: poker.Diamonds}, poker.Card{Rank: poker.Nine, Suit: poker.Diamonds},
+			poker.Card{Rank: poker.Ten, Suit: poker.Diamonds}, poker.Card{Rank: poker.Jack, Suit: poker.Diamonds},
+		)
+	}
+	require.NoError(t, g.StartHand())
+	require.NoError(t, g.Fold())
+	require.NoError(t, g.Call())
+	for g.IsPlayerStage() {
+		require.NoError(t, g.Check())
+	}
+
+	// 主池 20 中抽取 3 个筹码给两项促销活动
+	assert.Equal(t, []poker.Drop{{Name: "bad beat", Amount: 2}, {Name: "high hand", Amount: 1}}, g.Result.Drops)
+	assert.Equal(t, []int{500, 490, 507}, []int{players[0].Chips, players[1].Chips, players[2].Chips})
+
+	require.Len(t, jackpot.Awards, 3)
+	assert.Equal(t, []Role{RoleLoser, RoleWinner, RoleTable}, []Role{jackpot.Awards[0].Role, jackpot.Awards[1].Role, jackpot.Awards[2].Role})
+	assert.Equal(t, []string{"p2", "p3", "p1"}, []string{jackpot.Awards[0].Name, jackpot.Awards[1].Name, jackpot.Awards[2].Name})
+	assert.Equal(t, []int{501, 250, 250}, []int{jackpot.Awards[0].Amount, jackpot.Awards[1].Amount, jackpot.Awards[2].Amount})
+	assert.Equal(t, 1, jackpot.Pool)
+
+	// 一小时后的下一手牌结算最大牌型奖
+	require.NotNil(t, highHand.Best)
+	assert.Equal(t, "p3", highHand.Best.Name)
+	now = now.Add(time.Hour)
+	require.NoError(t, g.StartHand())
+	for g.IsPlayerStage() {
+		require.NoError(t, g.Fold())
+	}
+	require.Len(t, highHand.Awards, 1)
+	assert.Equal(t, 1, highHand.Awards[0].Amount)
+	assert.Equal(t, poker.StraightFlush, highHand.Awards[0].Hand.Rank)
+	assert.Nil(t, highHand.Best)
+	assert.Len(t, jackpot.Awards, 3)
+}
@@ -1,13 +1,17 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/lllllan02/pocker/bot"
 	"github.com/lllllan02/pocker/poker"
+	"github.com/lllllan02/pocker/tournament"
 	"github.com/spf13/cast"
 )
 
@@ -35,6 +39,7 @@ var upgrader = websocket.Upgrader{
 
 // Client WebSocket 连接和游戏中心（Hub）之间的中间层
 type Client struct {
+	mu       sync.Mutex      // 保护用户名、牌桌和座位，游戏中心在自己的协程中为换桌的客户端修改它们
 	id       string          // 客户端唯一标识
 	username string          // 用户名
 	playerId string          // 玩家唯一标识
@@ -118,6 +123,27 @@ func (c *Client) writePump() {
 	}
 }
 
+// name 获取用户名
+func (c *Client) name() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.username
+}
+
+// table 获取客户端所在的牌桌和座位，没有入座时座位为空
+func (c *Client) table() (*poker.Game, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.game, c.playerId
+}
+
+// sit 将客户端转到牌桌的座位上，座位为空时只观看这张牌桌
+func (c *Client) sit(g *poker.Game, playerId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.game, c.playerId = g, playerId
+}
+
 // disconnectPlayer 断开玩家连接
 func (c *Client) disconnectPlayer() {}
 
@@ -141,7 +167,42 @@ func (c *Client) processEvent(e Event) {
 	// 入座请求
 	case EventActionTakeSeat:
 		seatId := cast.ToString(e.Params["seat_id"])
-		err = c.handleTakeSeat(seatId)
+		buyIn := cast.ToInt(e.Params["buy_in"])
+		err = c.handleTakeSeat(seatId, buyIn)
+
+	// 补充筹码
+	case EventActionTopUp:
+		amount := cast.ToInt(e.Params["amount"])
+		err = c.handleTopUp(amount)
+
+	// 用机器人补充座位
+	case EventActionAddBot:
+		seatId := cast.ToString(e.Params["seat_id"])
+		level := cast.ToString(e.Params["level"])
+		buyIn := cast.ToInt(e.Params["buy_in"])
+		err = c.handleAddBot(seatId, level, buyIn)
+
+	// 训练模式中查询全下或弃牌的均衡
+	case EventActionPushFold:
+		players := cast.ToInt(e.Params["players"])
+		stack := cast.ToInt(e.Params["stack"])
+		smallBlind := cast.ToInt(e.Params["small_blind"])
+		ante := cast.ToInt(e.Params["ante"])
+		hand := cast.ToString(e.Params["hand"])
+		err = c.handlePushFold(players, stack, smallBlind, ante, hand)
+
+	// 查询牌桌上玩家的统计
+	case EventActionHUD:
+		err = c.handleHUD()
+
+	// 查询玩家最近一段牌局的输赢和运气，默认为自己
+	case EventActionSession:
+		name := cast.ToString(e.Params["name"])
+		err = c.handleSession(name)
+
+	// 离座请求
+	case EventActionLeaveSeat:
+		err = c.handleLeaveSeat()
 
 	// 静音请求
 	case EventActionMute:
@@ -154,6 +215,16 @@ func (c *Client) processEvent(e Event) {
 		message := cast.ToString(e.Params["message"])
 		err = c.handleSendMessage(username, message)
 
+	// 提出分奖金协议
+	case EventActionProposeDeal:
+		kind := tournament.DealKind(cast.ToString(e.Params["kind"]))
+		err = c.handleProposeDeal(kind)
+
+	// 表决分奖金协议
+	case EventActionVoteDeal:
+		accept := cast.ToBool(e.Params["accept"])
+		err = c.handleVoteDeal(accept)
+
 	// 发送信号
 	case EventActionSendSignal:
 		peerId := cast.ToString(e.Params["peer_id"])
@@ -161,16 +232,20 @@ func (c *Client) processEvent(e Event) {
 		signalData := e.Params["signal_data"]
 		err = c.handleSendSignal(peerId, stream, signalData)
 
-	// 处理游戏动作
+	// 处理游戏动作，执行动作和监听者处理事件期间都持有牌桌的锁
 	default:
+		c.hub.mu.Lock()
+		defer c.hub.mu.Unlock()
+
 		// 如果当前不是玩家回合，则返回错误
-		if !c.game.IsPlayerStage() {
-			err = fmt.Errorf("you cannot move during the %s stage", c.game.Stage)
+		game, playerId := c.table()
+		if !game.IsPlayerStage() {
+			err = fmt.Errorf("you cannot move during the %s stage", game.Stage)
 			return
 		}
 
 		// 如果当前不是玩家回合，则返回错误
-		if !c.game.IsPlayerTurn(c.playerId) {
+		if !game.IsPlayerTurn(playerId) {
 			err = fmt.Errorf("you cannot move out of turn")
 			return
 		}
@@ -199,6 +274,11 @@ func (c *Client) processEvent(e Event) {
 			err = fmt.Errorf("invalid action: %s", e.Action)
 
 		}
+
+		// 动作和随后的机器人动作都完成后再保存快照，快照不会停在结算奖池的中途
+		if err == nil && game == c.hub.game {
+			c.hub.save()
+		}
 	}
 
 }
@@ -206,7 +286,9 @@ func (c *Client) processEvent(e Event) {
 // handleJoin 处理加入游戏请求
 func (c *Client) handleJoin(username string) error {
 	// 设置用户名
+	c.mu.Lock()
 	c.username = username
+	c.mu.Unlock()
 
 	// 发送加入成功事件
 	c.send <- createOnJoinEvent(c.id, username)
@@ -216,14 +298,110 @@ func (c *Client) handleJoin(username string) error {
 	c.hub.broadcast <- NewBroadcastEvent(newMessage)
 
 	// 更新并广播游戏状态
+	c.hub.mu.Lock()
 	updateGame := createUpdateGameEvent(c, false)
+	c.hub.mu.Unlock()
 	c.hub.broadcast <- NewBroadcastEvent(updateGame)
 
 	return nil
 }
 
-func (c *Client) handleTakeSeat(seatId string) error {
+// handleTakeSeat 入座并买入筹码
+func (c *Client) handleTakeSeat(seatId string, buyIn int) error {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
 
+	if err := c.hub.cash.Sit(seatId, c.name(), buyIn, true); err != nil {
+		return err
+	}
+	c.hub.save()
+	c.sit(c.hub.game, seatId)
+	return nil
+}
+
+// handleAddBot 按难度创建启发式机器人，坐到空座位上并买入筹码
+func (c *Client) handleAddBot(seatId, level string, buyIn int) error {
+	b, err := bot.New(level, time.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+
+	b.Stats = c.hub.stats
+
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	c.hub.bots++
+	name := fmt.Sprintf("%s bot %d", level, c.hub.bots)
+	if err := c.hub.cash.SitBot(seatId, name, buyIn, b); err != nil {
+		return err
+	}
+	c.hub.save()
+	return nil
+}
+
+// handlePushFold 求解全下或弃牌的均衡并只发给请求的客户端。
+// 求解条件和机器人一样取整到大盲注并限制深度，相同深度的查询会复用缓存的结果
+func (c *Client) handlePushFold(players, stack, smallBlind, ante int, hand string) error {
+	opts, err := bot.NormalizePushFold(players, stack, smallBlind*2, ante)
+	if err != nil {
+		return err
+	}
+	chart, err := bot.CachedPushFold(opts)
+	if err != nil {
+		return err
+	}
+	event, err := createPushFoldEvent(chart, hand)
+	if err != nil {
+		return err
+	}
+	c.send <- event
+	return nil
+}
+
+// handleHUD 将所在牌桌上玩家的统计只发给请求的客户端
+func (c *Client) handleHUD() error {
+	c.hub.mu.Lock()
+	game, _ := c.table()
+	hud := createHUDEvent(game, c.hub.stats)
+	c.hub.mu.Unlock()
+	c.send <- hud
+	return nil
+}
+
+// handleSession 将玩家最近一段牌局的实际和全下期望输赢只发给请求的客户端
+func (c *Client) handleSession(name string) error {
+	if name == "" {
+		name = c.name()
+	}
+	s, ok := c.hub.stats.Session(name)
+	if !ok {
+		return fmt.Errorf("no sessions for %s", name)
+	}
+	c.send <- createSessionEvent(name, s)
+	return nil
+}
+
+// handleTopUp 在两手牌之间补充筹码
+func (c *Client) handleTopUp(amount int) error {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	if err := c.hub.cash.TopUp(c.name(), amount); err != nil {
+		return err
+	}
+	c.hub.save()
+	return nil
+}
+
+// handleLeaveSeat 带着筹码离座
+func (c *Client) handleLeaveSeat() error {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+
+	if err := c.hub.cash.Leave(c.name()); err != nil {
+		return err
+	}
+	c.hub.save()
+	c.sit(c.hub.game, "")
 	return nil
 }
 
@@ -242,22 +420,71 @@ func (c *Client) handleSendSignal(peerId string, stream string, signalData any)
 	return nil
 }
 
-func (c *Client) handleFold() error {
+// handleProposeDeal 提出分奖金协议，并广播给所有客户端表决
+func (c *Client) handleProposeDeal(kind tournament.DealKind) error {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+
+	t := c.hub.tournament
+	if t == nil {
+		return fmt.Errorf("deals can only be made in a tournament")
+	}
 
+	deal, err := t.ProposeDeal(kind)
+	if err != nil {
+		return err
+	}
+	c.hub.broadcast <- NewBroadcastEvent(createDealEvent("proposed", deal, nil))
 	return nil
 }
 
-func (c *Client) handleCheck() error {
+// handleVoteDeal 表决分奖金协议，所有玩家同意后广播比赛的最终名次
+func (c *Client) handleVoteDeal(accept bool) error {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+
+	t := c.hub.tournament
+	if t == nil {
+		return fmt.Errorf("deals can only be made in a tournament")
+	}
+
+	deal := t.Deal
+	switch err := t.Vote(c.name(), accept); {
+	case errors.Is(err, tournament.ErrDealRejected):
+		c.hub.broadcast <- NewBroadcastEvent(createDealEvent("rejected", deal, nil))
+	case err != nil:
+		return err
+	case deal.Accepted():
+		c.hub.broadcast <- NewBroadcastEvent(createDealEvent("accepted", deal, t.Standings()))
+	default:
+		c.hub.broadcast <- NewBroadcastEvent(createDealEvent("voted", deal, nil))
+	}
 
+	// 表决结束后牌桌继续开始下一手牌
+	c.hub.nextHands()
 	return nil
 }
 
-func (c *Client) handleCall() error {
+// handleFold 弃牌
+func (c *Client) handleFold() error {
+	game, _ := c.table()
+	return game.Fold()
+}
 
-	return nil
+// handleCheck 过牌
+func (c *Client) handleCheck() error {
+	game, _ := c.table()
+	return game.Check()
 }
 
-func (c *Client) handleRaise(amount int) error {
+// handleCall 跟注
+func (c *Client) handleCall() error {
+	game, _ := c.table()
+	return game.Call()
+}
 
-	return nil
+// handleRaise 下注或加注到 amount
+func (c *Client) handleRaise(amount int) error {
+	game, _ := c.table()
+	return game.Raise(amount)
 }
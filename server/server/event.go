@@ -2,7 +2,10 @@ package server
 
 import (
 	"github.com/google/uuid"
+	"github.com/lllllan02/pocker/bot"
 	"github.com/lllllan02/pocker/poker"
+	"github.com/lllllan02/pocker/stats"
+	"github.com/lllllan02/pocker/tournament"
 )
 
 const (
@@ -10,9 +13,17 @@ const (
 
 	EventActionJoin        = "join"         // 加入请求
 	EventActionTakeSeat    = "take_seat"    // 入座请求
+	EventActionTopUp       = "top_up"       // 补充筹码
+	EventActionLeaveSeat   = "leave_seat"   // 离座请求
 	EventActionMute        = "mute"         // 禁言请求
 	EventActionSendMessage = "send_message" // 发送消息
 	EventActionSendSignal  = "send_signal"  // 发送信号
+	EventActionProposeDeal = "propose_deal" // 提出分奖金协议
+	EventActionVoteDeal    = "vote_deal"    // 表决分奖金协议
+	EventActionAddBot      = "add_bot"      // 用机器人补充座位
+	EventActionPushFold    = "push_fold"    // 训练模式中查询全下或弃牌的均衡
+	EventActionHUD         = "hud"          // 查询牌桌上玩家的统计
+	EventActionSession     = "session"      // 查询玩家最近一段牌局的输赢和运气
 
 	// 客户端发给服务端的游戏动作
 
@@ -23,10 +34,16 @@ const (
 
 	// 服务端发给客户端
 
-	EventActionError      = "error"       // 错误事件
-	EventActionOnJoin     = "on_join"     // 加入成功
-	EventActionNewMessage = "new_message" // 新消息
-	EventActionUpdateGame = "update_game" // 更新游戏
+	EventActionError         = "error"           // 错误事件
+	EventActionOnJoin        = "on_join"         // 加入成功
+	EventActionNewMessage    = "new_message"     // 新消息
+	EventActionUpdateGame    = "update_game"     // 更新游戏
+	EventActionGameEvent     = "game_event"      // 牌局事件
+	EventActionTableMoved    = "table_moved"     // 比赛中换桌
+	EventActionDeal          = "deal"            // 分奖金协议的状态
+	EventActionPushFoldChart = "push_fold_chart" // 全下或弃牌的均衡
+	EventActionHUDStats      = "hud_stats"       // 牌桌上玩家的统计
+	EventActionSessionStats  = "session_stats"   // 玩家最近一段牌局的输赢和运气
 )
 
 type Event struct {
@@ -68,7 +85,7 @@ func createNewMessageEvent(username, message string) Event {
 }
 
 func createUpdateGameEvent(c *Client, showCards bool) Event {
-	game := c.game
+	game, _ := c.table()
 	seats := game.Table.Seats
 	players := make([]map[string]any, 0)
 	var actionBar map[string]any
@@ -78,6 +95,7 @@ func createUpdateGameEvent(c *Client, showCards bool) Event {
 			players = append(players, map[string]any{
 				"id":         seats.Player.Id,              // 玩家 id
 				"name":       seats.Player.Name,            // 玩家名称
+				"position":   game.Table.Position(seats),   // 上一手牌中相对庄家的位置
 				"status":     seats.Player.Status.String(), // 玩家状态
 				"is_active":  false,                        // 是否活跃
 				"is_dealer":  false,                        // 是否庄家
@@ -109,6 +127,7 @@ func createUpdateGameEvent(c *Client, showCards bool) Event {
 				players = append(players, map[string]interface{}{
 					"id":         seats.Player.Id,
 					"name":       seats.Player.Name,
+					"position":   game.Table.Position(seats),
 					"status":     seats.Player.Status.String(),
 					"isActive":   seats.Player.Id == activePlayer.Id,
 					"isDealer":   seats.Player.Id == game.Table.Dealer.Player.Id,
@@ -167,9 +186,123 @@ func createUpdateGameEvent(c *Client, showCards bool) Event {
 	}
 }
 
+// 创建牌局事件，隐藏牌堆顺序和玩家的底牌
+func createGameEvent(e poker.Event) Event {
+	var data any = e
+	switch e := e.(type) {
+	case poker.HandStarted:
+		e.Deck = nil
+		data = e
+	case poker.CardsDealt:
+		seats := make([]int, len(e.Hands))
+		for i, hand := range e.Hands {
+			seats[i] = hand.Seat
+		}
+		data = map[string]any{"seats": seats}
+	}
+
+	return Event{
+		Action: EventActionGameEvent,
+		Params: map[string]any{
+			"type": e.EventType(),
+			"data": data,
+		},
+	}
+}
+
+// 创建换桌事件
+func createTableMovedEvent(m tournament.Move) Event {
+	return Event{
+		Action: EventActionTableMoved,
+		Params: map[string]any{
+			"from_table": m.From.Id,
+			"table":      m.To.Id,
+			"seat_id":    m.ToSeat,
+		},
+	}
+}
+
+// 创建分奖金协议事件，status 为 proposed、voted、rejected 或 accepted
+func createDealEvent(status string, deal *tournament.Deal, results []tournament.Result) Event {
+	return Event{
+		Action: EventActionDeal,
+		Params: map[string]any{
+			"status":  status,
+			"deal":    deal,
+			"results": results,
+		},
+	}
+}
+
+// 创建全下或弃牌均衡事件，hand 不为空时附带这手起手牌在每个局面下的频率和期望
+func createPushFoldEvent(chart *bot.PushFoldChart, hand string) (Event, error) {
+	ranges := make(map[bot.Spot]any)
+	for spot, r := range chart.Ranges {
+		ranges[spot] = map[string]any{
+			"percent": r.Percent(),
+			"hands":   r.Hands(),
+		}
+	}
+	params := map[string]any{
+		"options": chart.Options,
+		"ranges":  ranges,
+	}
+
+	if hand != "" {
+		h, err := bot.ParseStartingHand(hand)
+		if err != nil {
+			return Event{}, err
+		}
+		spots := make(map[bot.Spot]any)
+		for spot, r := range chart.Ranges {
+			spots[spot] = map[string]any{
+				"frequency": r[h.Index()],
+				"gain":      chart.Gains[spot][h.Index()],
+			}
+		}
+		params["hand"] = h.String()
+		params["spots"] = spots
+	}
+	return Event{Action: EventActionPushFoldChart, Params: params}, nil
+}
+
+// 创建牌桌上玩家的统计事件，每个有玩家的座位附带该玩家的长期统计
+func createHUDEvent(g *poker.Game, t *stats.Tracker) Event {
+	seats := g.Table.Seats
+	players := make([]map[string]any, 0)
+	for i := 0; i < seats.Len(); i++ {
+		if p := seats.Player; p.Status != poker.PlayerVacated {
+			players = append(players, map[string]any{
+				"id":       p.Id,
+				"seat":     seats.Index(),
+				"position": g.Table.Position(seats),
+				"name":     p.Name,
+				"stats":    t.Stats(p.Name).HUD(),
+			})
+		}
+		seats = seats.Next()
+	}
+	return Event{
+		Action: EventActionHUDStats,
+		Params: map[string]any{"players": players},
+	}
+}
+
+// 创建玩家最近一段牌局的输赢事件
+func createSessionEvent(name string, s stats.Session) Event {
+	return Event{
+		Action: EventActionSessionStats,
+		Params: map[string]any{
+			"name":    name,
+			"session": newSessionReport(s),
+		},
+	}
+}
+
 type BroadcastEvent struct {
 	Event          Event           // 要广播的事件
 	ExcludeClients map[string]bool // 排除的客户端列表
+	Game           *poker.Game     // 只发给这张牌桌上的客户端，为 nil 时发给所有客户端
 }
 
 // NewBroadcastEvent 创建广播事件
@@ -33,7 +33,8 @@ func GetActions(g *poker.Game) []string {
 func createClientPlayerMap(clients map[string]*Client) map[string]string {
 	clientPlayerMap := make(map[string]string)
 	for _, c := range clients {
-		clientPlayerMap[c.id] = c.playerId
+		_, playerId := c.table()
+		clientPlayerMap[c.id] = playerId
 	}
 	return clientPlayerMap
 }
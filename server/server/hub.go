@@ -1,18 +1,71 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lllllan02/pocker/bot"
+	"github.com/lllllan02/pocker/cash"
+	"github.com/lllllan02/pocker/history"
 	"github.com/lllllan02/pocker/poker"
+	"github.com/lllllan02/pocker/stats"
+	"github.com/lllllan02/pocker/tournament"
 )
 
+// 手牌记录设置
+const (
+	tableName      = "Pocker"         // 牌桌名称
+	handHistoryDir = "hand_histories" // 手牌记录的存放目录
+	snapshotFile   = "game.snapshot"  // 牌局快照文件，服务重启后从中恢复牌局
+	tableFile      = "table.snapshot" // 现金桌状态文件，服务重启后从中恢复牌局快照之外的账目、离桌记录和机器人
+)
+
+// tournamentHandDelay 比赛中一手牌结束后到开始下一手牌的间隔，让客户端看清摊牌的结果
+const tournamentHandDelay = 3 * time.Second
+
+// tableState 现金桌在牌局快照之外需要恢复的状态
+type tableState struct {
+	Cash     *cash.State       `json:"cash"`      // 现金桌的账目和离桌记录
+	Bots     map[string]string `json:"bots"`      // 座位上的机器人的难度，key 为机器人名称
+	BotCount int               `json:"bot_count"` // 已经补充的机器人数量
+}
+
 type Hub struct {
+	// 牌桌的锁
+	// 修改牌局、买入规则或比赛的动作从开始到监听者处理完事件都持有这把锁，
+	// 快照、统计和机器人等监听者因此不会在不同客户端的协程中并发运行。
+	// Run 不获取这把锁，持有锁时可以向游戏中心的通道发送事件
+	mu sync.Mutex
+
 	// 游戏
 	game *poker.Game
 
+	// 现金桌的买入规则
+	cash *cash.Table
+
+	// 已经补充的机器人数量，用于给机器人命名
+	bots int
+
+	// 从牌局事件统计的每名玩家的打法，供机器人建模对手和 HUD 显示
+	stats *stats.Tracker
+
+	// 正在进行的比赛，为 nil 时为现金桌
+	tournament *tournament.Tournament
+
+	// 比赛中一手牌结束后到开始下一手牌的间隔
+	handDelay time.Duration
+
 	// 所有连接的客户端
 	clients map[string]*Client
 
@@ -25,19 +78,314 @@ type Hub struct {
 
 	// 注销客户端的通道
 	unregister chan *Client
+
+	// 比赛中玩家换桌的通道
+	// 换桌的玩家对应的客户端会被转到新的牌桌
+	moves chan tournament.Move
+
+	// 开始主持比赛的通道
+	// 已经加入的客户端按用户名被转到比赛中的牌桌和座位
+	hosted chan map[string]tournament.Seat
 }
 
+// ErrTournamentRunning 游戏中心已经在主持一场没有结束的比赛
+var ErrTournamentRunning = errors.New("a tournament is already running")
+
 // NewHub 创建新的游戏中心
 func NewHub() *Hub {
-	return &Hub{
-		game:       poker.NewGame(),
+	// 优先从快照恢复上次未结束的牌局
+	game, err := loadSnapshot(snapshotFile)
+	if err != nil {
+		log.Printf("failed to restore the game from %s: %v", snapshotFile, err)
+	}
+	if game == nil {
+		game = poker.NewGame()
+	}
+
+	// 调试模式下每个动作之后都校验牌局状态
+	game.Debug = gin.IsDebugging()
+
+	// 每手牌结束后写入手牌记录
+	recorder := history.NewRecorder(tableName, history.NewRotatingFile(handHistoryDir, tableName, ".txt", 0))
+	recorder.JSONWriter = history.NewRotatingFile(handHistoryDir, tableName, ".jsonl", 0)
+	recorder.Heroes = history.NewPlayerFiles(filepath.Join(handHistoryDir, "players"), ".txt", 0).Writer
+	game.Observers = append(game.Observers, recorder)
+
+	// 现金桌的买入、补码和离桌都经过买入规则校验，账目和离桌记录与牌局一起恢复
+	table, err := cash.NewTable(game, cash.Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	state, err := loadTableState(tableFile)
+	if err != nil {
+		log.Printf("failed to restore the cash table from %s: %v", tableFile, err)
+	}
+	if state == nil {
+		state = &tableState{}
+	}
+	if state.Cash != nil {
+		table.Restore(state.Cash)
+	}
+
+	hub := &Hub{
+		game:       game,
+		cash:       table,
 		clients:    make(map[string]*Client),
 		broadcast:  make(chan BroadcastEvent),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		moves:      make(chan tournament.Move),
+		hosted:     make(chan map[string]tournament.Seat),
+		stats:      stats.NewTracker(),
+		bots:       state.BotCount,
+		handDelay:  tournamentHandDelay,
+	}
+
+	// 长期统计从已有的手牌记录中恢复，牌桌上的机器人共用同一份对手统计
+	loadStats(hub.stats, handHistoryDir)
+	game.Listeners = append(game.Listeners, hub.stats)
+
+	// 将牌局事件实时推送给所有客户端
+	game.Listeners = append(game.Listeners, hub)
+
+	// 从快照恢复的机器人座位重新接入机器人
+	go hub.resumeBots(state.Bots)
+	return hub
+}
+
+// resumeBots 按保存的难度为从快照恢复的机器人座位重新接入机器人，没有记录难度的机器人离座，
+// 然后让轮到行动的机器人继续行动。牌局事件要推送给客户端，游戏中心运行之后才能完成
+func (h *Hub) resumeBots(levels map[string]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	g := h.game
+	s := g.Table.Seats
+	for i := 0; i < s.Len(); i++ {
+		p := s.Player
+		s = s.Next()
+		if p.Status == poker.PlayerVacated || p.IsHuman || g.Bots[p.Name] != nil {
+			continue
+		}
+
+		b, err := bot.New(levels[p.Name], time.Now().UnixNano())
+		if err != nil {
+			log.Printf("failed to restore %s: %v", p.Name, err)
+			if err := h.cash.Leave(p.Name); err != nil {
+				log.Printf("failed to remove %s from the table: %v", p.Name, err)
+			}
+			continue
+		}
+		b.Stats = h.stats
+		if g.Bots == nil {
+			g.Bots = make(map[string]poker.Bot)
+		}
+		g.Bots[p.Name] = b
+	}
+
+	if err := g.RunBots(); err != nil {
+		log.Printf("failed to resume the bots: %v", err)
+	}
+	h.save()
+}
+
+// Host 在游戏中心主持比赛，推送所有牌桌的牌局事件，将参赛玩家的客户端转到比赛中的座位，
+// 之后换桌的客户端也会被转到新的牌桌。每张牌桌在上一手牌结束后自动开始下一手牌，
+// 直到比赛结束。已有比赛没有结束时返回 ErrTournamentRunning
+func (h *Hub) Host(t *tournament.Tournament) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tournament != nil && !h.tournament.Done() {
+		return ErrTournamentRunning
+	}
+
+	h.tournament = t
+	t.Listeners = append(t.Listeners, h)
+	for _, tb := range t.Tables {
+		h.Watch(tb.Game)
+	}
+	h.hosted <- t.Seats()
+	h.nextHands()
+	return nil
+}
+
+// nextHands 为比赛中没有在进行手牌的牌桌开始下一手牌，调用时需要持有牌桌的锁。
+// 人数不足的牌桌等到有玩家移来，有正在表决的分奖金协议时等到表决结束，休息时间结束后再继续
+func (h *Hub) nextHands() {
+	t := h.tournament
+	if t == nil || t.Done() {
+		return
+	}
+
+	for _, tb := range append([]*tournament.Table(nil), t.Tables...) {
+		if tb.Game.IsPlayerStage() {
+			continue
+		}
+
+		switch err := t.StartHand(tb.Id); {
+		case errors.Is(err, tournament.ErrOnBreak):
+			time.AfterFunc(t.BreakRemaining(), h.resumeTournament)
+			return
+		case err == nil, errors.Is(err, tournament.ErrTableClosed), errors.Is(err, tournament.ErrWaiting),
+			errors.Is(err, tournament.ErrDealPending), errors.Is(err, tournament.ErrFinished):
+		default:
+			log.Printf("failed to start a hand on table %d: %v", tb.Id, err)
+		}
 	}
 }
 
+// resumeTournament 在其他协程中获取牌桌的锁，为比赛中空闲的牌桌开始下一手牌
+func (h *Hub) resumeTournament() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextHands()
+}
+
+// OnTableOpened 推送比赛新开牌桌的牌局事件，并在新牌桌上开始一手牌
+func (h *Hub) OnTableOpened(tb *tournament.Table) {
+	h.Watch(tb.Game)
+	go h.resumeTournament()
+}
+
+// Watch 将比赛中其他牌桌的牌局事件也推送给坐在这些牌桌上的客户端，并记入玩家的统计
+func (h *Hub) Watch(g *poker.Game) {
+	g.Listeners = append(g.Listeners, h.stats, h)
+}
+
+// Stats 获取玩家的长期统计
+func (h *Hub) Stats(name string) stats.Stats {
+	return h.stats.Stats(name)
+}
+
+// AllStats 获取所有玩家的长期统计，key 为玩家名称
+func (h *Hub) AllStats() map[string]stats.Stats {
+	return h.stats.All()
+}
+
+// OnEvent 向同一牌桌的客户端广播牌局事件，每手牌结束后再广播更新的统计。
+// 现金桌的一手牌结束时所有奖池都已分配，此时保存牌局快照
+func (h *Hub) OnEvent(g *poker.Game, e poker.Event) {
+	event := NewBroadcastEvent(createGameEvent(e))
+	event.Game = g
+	h.broadcast <- event
+
+	if _, ok := e.(poker.HandEnded); ok {
+		// 比赛的牌桌在间隔之后开始下一手牌，不在结算这手牌的事件中开始
+		if g == h.game {
+			h.save()
+		} else if h.tournament != nil {
+			time.AfterFunc(h.handDelay, h.resumeTournament)
+		}
+
+		hud := NewBroadcastEvent(createHUDEvent(g, h.stats))
+		hud.Game = g
+		h.broadcast <- hud
+	}
+}
+
+// OnPlayerMoved 将换桌玩家的客户端转到新的牌桌，新的牌桌在等待玩家时开始一手牌
+func (h *Hub) OnPlayerMoved(m tournament.Move) {
+	h.moves <- m
+	go h.resumeTournament()
+}
+
+// save 保存现金桌的牌局快照和账目。
+// 只在牌局处于一致的状态时调用：修改现金桌的动作返回之后，以及一手牌结束时，
+// 不能在结算一手牌的事件之间调用，否则恢复后部分奖池已经分配而其余筹码留在下注中
+func (h *Hub) save() {
+	if err := saveSnapshot(snapshotFile, h.game); err != nil {
+		log.Printf("failed to save the game snapshot: %v", err)
+	}
+	state := &tableState{Cash: h.cash.State(), Bots: make(map[string]string), BotCount: h.bots}
+	for name, b := range h.game.Bots {
+		if b, ok := b.(*bot.Heuristic); ok {
+			state.Bots[name] = b.Config.Name
+		}
+	}
+	if err := saveTableState(tableFile, state); err != nil {
+		log.Printf("failed to save the cash table: %v", err)
+	}
+}
+
+// loadStats 按文件名顺序读取目录中所有 JSON 格式的手牌记录并记入统计，无法读取的记录只打印日志
+func loadStats(t *stats.Tracker, dir string) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		log.Printf("failed to list hand histories: %v", err)
+		return
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Printf("failed to load stats from %s: %v", name, err)
+			continue
+		}
+		if _, err := t.Load(f); err != nil {
+			log.Printf("failed to load stats from %s: %v", name, err)
+		}
+		f.Close()
+	}
+}
+
+// loadSnapshot 从快照文件恢复牌局，文件不存在时返回 nil
+func loadSnapshot(name string) (*poker.Game, error) {
+	b, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return poker.UnmarshalSnapshot(b)
+}
+
+// saveSnapshot 将牌局快照写入文件
+func saveSnapshot(name string, g *poker.Game) error {
+	b, err := g.MarshalSnapshot()
+	if err != nil {
+		return err
+	}
+	return writeFile(name, b)
+}
+
+// loadTableState 从文件读取现金桌的状态，文件不存在时返回 nil
+func loadTableState(name string) (*tableState, error) {
+	b, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s tableState
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// saveTableState 将现金桌的状态写入文件
+func saveTableState(name string, s *tableState) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFile(name, b)
+}
+
+// writeFile 写入文件。
+// 先写入临时文件再重命名，避免进程中途退出时留下不完整的文件
+func writeFile(name string, b []byte) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
 // Run 运行游戏中心
 func (h *Hub) Run() {
 	for {
@@ -60,6 +408,9 @@ func (h *Hub) Run() {
 				if _, ok := e.ExcludeClients[id]; ok {
 					continue
 				}
+				if game, _ := client.table(); e.Game != nil && game != e.Game {
+					continue
+				}
 
 				// 尝试向客户端发送事件
 				select {
@@ -73,6 +424,30 @@ func (h *Hub) Run() {
 				}
 			}
 
+		// 开始主持比赛，参赛玩家的客户端转到比赛中的座位
+		case seats := <-h.hosted:
+			for _, client := range h.clients {
+				if seat, ok := seats[client.name()]; ok {
+					client.sit(seat.Table.Game, seat.PlayerId)
+				}
+			}
+
+		// 玩家换桌
+		case m := <-h.moves:
+			for id, client := range h.clients {
+				if game, playerId := client.table(); game != m.From.Game || playerId != m.FromSeat {
+					continue
+				}
+
+				client.sit(m.To.Game, m.ToSeat)
+				select {
+				case client.send <- createTableMovedEvent(m):
+				default:
+					delete(h.clients, id)
+					close(client.send)
+				}
+			}
+
 		}
 	}
 }
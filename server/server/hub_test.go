@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/lllllan02/pocker/stats"
+	"github.com/lllllan02/pocker/tournament"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allIn 总是全下的机器人，能让比赛很快结束
+var allIn = poker.BotFunc(func(ctx context.Context, v *poker.View) (poker.Decision, error) {
+	for _, a := range v.Legal {
+		if a.Type == poker.ActionRaise {
+			return poker.Decision{Type: poker.ActionRaise, Amount: a.Max}, nil
+		}
+	}
+	return poker.Decision{Type: poker.ActionCall}, nil
+})
+
+func TestHubHostsTournament(t *testing.T) {
+	h := &Hub{
+		game:       poker.NewGame(),
+		clients:    make(map[string]*Client),
+		broadcast:  make(chan BroadcastEvent),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		moves:      make(chan tournament.Move),
+		hosted:     make(chan map[string]tournament.Seat),
+		stats:      stats.NewTracker(),
+	}
+	go h.Run()
+
+	names := make([]string, 12)
+	for i := range names {
+		names[i] = fmt.Sprintf("p%d", i+1)
+	}
+	tour, err := tournament.New(tournament.Config{
+		StartingStack: 200,
+		TableSize:     6,
+		Rand:          rand.New(rand.NewSource(1)),
+	}, names)
+	require.NoError(t, err)
+
+	// 所有参赛玩家都由机器人代打
+	for _, tb := range tour.Tables {
+		tb.Game.Bots = make(map[string]poker.Bot)
+		for _, name := range names {
+			tb.Game.Bots[name] = allIn
+		}
+		for _, p := range tb.Game.Table.Seats.GetActivePlayers() {
+			p.IsHuman = false
+		}
+	}
+
+	// 每名参赛玩家都有一个客户端，记录收到的换桌事件
+	var mu sync.Mutex
+	moved := make(map[string]int)
+	clients := make(map[string]*Client)
+	for _, name := range names {
+		c := &Client{id: name, username: name, game: h.game, hub: h, send: make(chan Event, 1024)}
+		clients[name] = c
+		h.register <- c
+		go func(name string) {
+			for e := range c.send {
+				if e.Action == EventActionTableMoved {
+					mu.Lock()
+					moved[name]++
+					mu.Unlock()
+				}
+			}
+		}(name)
+	}
+
+	require.NoError(t, h.Host(tour))
+	assert.ErrorIs(t, h.Host(tour), ErrTournamentRunning)
+
+	// 牌桌自动开始下一手牌，拆桌后直到比赛结束
+	done := func() bool {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return tour.Done()
+	}
+	require.Eventually(t, done, 10*time.Second, 10*time.Millisecond)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	require.Len(t, tour.Tables, 1)
+	mu.Lock()
+	assert.NotEmpty(t, moved)
+	mu.Unlock()
+
+	// 换桌的客户端被转到决赛桌上自己的座位
+	for name, seat := range tour.Seats() {
+		game, playerId := clients[name].table()
+		assert.Equal(t, seat.Table.Game, game, name)
+		assert.Equal(t, seat.PlayerId, playerId, name)
+	}
+}
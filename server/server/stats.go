@@ -0,0 +1,84 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/lllllan02/pocker/stats"
+)
+
+// Profile 个人主页展示的玩家统计
+type Profile struct {
+	Name  string      `json:"name"`  // 玩家名称
+	Stats stats.Stats `json:"stats"` // 累计的计数
+	HUD   stats.HUD   `json:"hud"`   // 由计数计算的比例和胜率
+}
+
+// SessionReport 一段牌局的实际和全下期望输赢，以及每手牌之后的累计输赢曲线
+type SessionReport struct {
+	Start time.Time     `json:"start"`  // 第一手牌开始的时间
+	End   time.Time     `json:"end"`    // 最后一手牌开始的时间
+	Hands int           `json:"hands"`  // 手数
+	Net   int           `json:"net"`    // 实际输赢
+	EVNet float64       `json:"ev_net"` // 全下期望输赢
+	Luck  float64       `json:"luck"`   // 实际输赢超出期望的部分
+	Graph []stats.Point `json:"graph"`  // 每手牌之后的累计输赢
+}
+
+// newSessionReport 根据一段牌局创建报告
+func newSessionReport(s stats.Session) SessionReport {
+	return SessionReport{
+		Start: s.Start,
+		End:   s.End,
+		Hands: len(s.Hands),
+		Net:   s.Net,
+		EVNet: s.EVNet,
+		Luck:  s.Luck(),
+		Graph: s.Graph(),
+	}
+}
+
+// ServeStats 以 JSON 返回所有玩家的统计，key 为玩家名称
+func ServeStats(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	all := hub.AllStats()
+	huds := make(map[string]stats.HUD, len(all))
+	for name, s := range all {
+		huds[name] = s.HUD()
+	}
+	writeJSON(w, http.StatusOK, huds)
+}
+
+// ServeProfile 以 JSON 返回一名玩家的统计，没有这名玩家的记录时返回 404
+func ServeProfile(hub *Hub, w http.ResponseWriter, r *http.Request, name string) {
+	s := hub.Stats(name)
+	if s.Hands == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no stats for " + name})
+		return
+	}
+	writeJSON(w, http.StatusOK, Profile{Name: name, Stats: s, HUD: s.HUD()})
+}
+
+// ServeSessions 以 JSON 返回一名玩家按时间顺序的所有牌局，没有这名玩家的记录时返回 404
+func ServeSessions(hub *Hub, w http.ResponseWriter, r *http.Request, name string) {
+	sessions := hub.stats.Sessions(name)
+	if len(sessions) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no sessions for " + name})
+		return
+	}
+	reports := make([]SessionReport, len(sessions))
+	for i, s := range sessions {
+		reports[i] = newSessionReport(s)
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// writeJSON 写出 JSON 格式的响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write the response: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/lllllan02/pocker/tournament"
+)
+
+// TournamentRequest 开始比赛的请求
+type TournamentRequest struct {
+	Players       []string `json:"players"`        // 参赛玩家的名称
+	StartingStack int      `json:"starting_stack"` // 每名玩家的起始筹码
+	BuyIn         int      `json:"buy_in"`         // 每名玩家的买入
+	TableSize     int      `json:"table_size"`     // 每张牌桌的座位数，为 0 时使用 9 人桌
+}
+
+// TournamentSeat 参赛玩家被安排的牌桌和座位
+type TournamentSeat struct {
+	Table    int    `json:"table"`     // 牌桌编号
+	PlayerId string `json:"player_id"` // 座位，即牌桌上的玩家标识
+}
+
+// ServeTournament 按请求创建比赛并由游戏中心主持，以 JSON 返回每名玩家的牌桌和座位。
+// 请求不合法时返回 400，已有比赛没有结束时返回 409
+func ServeTournament(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	var req TournamentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	t, err := tournament.New(tournament.Config{
+		StartingStack: req.StartingStack,
+		BuyIn:         req.BuyIn,
+		TableSize:     req.TableSize,
+	}, req.Players)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	seats := make(map[string]TournamentSeat, len(req.Players))
+	for name, s := range t.Seats() {
+		seats[name] = TournamentSeat{Table: s.Table.Id, PlayerId: s.PlayerId}
+	}
+
+	switch err := hub.Host(t); {
+	case errors.Is(err, ErrTournamentRunning):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusCreated, seats)
+	}
+}
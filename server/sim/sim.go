@@ -0,0 +1,220 @@
+// Package sim 在没有 WebSocket 的情况下让机器人互相对局，统计每个机器人的胜率。
+//
+// 每手牌都在新的牌桌上用固定的筹码开始，牌堆和机器人由随机种子和发牌的编号决定，结果可以复现，与并行的对局数无关。
+// 复式模式下同一副牌会按座位轮换让每个机器人都坐一遍每个位置，抵消牌运带来的方差
+package sim
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Entry 参与模拟的机器人
+type Entry struct {
+	Name string                     // 机器人的名称，不能重复
+	New  func(seed int64) poker.Bot // 创建机器人，每副牌各自创建一个
+}
+
+// Config 模拟的设置
+type Config struct {
+	Entries    []Entry // 参与的机器人，2 到 6 个
+	Hands      int     // 手牌数，复式模式下会向上取整为机器人个数的倍数
+	Seed       int64   // 随机种子
+	Stack      int     // 每手牌开始时的筹码，为 0 时为 100 个大盲注
+	SmallBlind int     // 小盲注，为 0 时为 5
+	Ante       int     // 前注
+	Duplicate  bool    // 是否使用复式模式
+	Workers    int     // 并行的对局数，为 0 时为 CPU 的个数
+}
+
+// Stats 一个机器人的统计结果
+type Stats struct {
+	Name   string  `json:"name"`    // 机器人的名称
+	Hands  int     `json:"hands"`   // 参与的手牌数
+	Won    float64 `json:"won"`     // 一共赢得的大盲注
+	BB100  float64 `json:"bb100"`   // 每一百手牌赢得的大盲注
+	CI95   float64 `json:"ci95"`    // bb/100 的 95% 置信区间的半径
+	StdDev float64 `json:"std_dev"` // 每手牌输赢的标准差，以大盲注计，复式模式下按一副牌的平均值计算
+}
+
+// Report 模拟的结果
+type Report struct {
+	Hands     int     `json:"hands"`     // 一共进行的手牌数
+	Deals     int     `json:"deals"`     // 使用的牌堆数
+	Duplicate bool    `json:"duplicate"` // 是否使用复式模式
+	Stats     []Stats `json:"stats"`     // 按设置中的顺序排列的每个机器人的统计结果
+}
+
+// accumulator 按发牌累计的输赢，一副牌为一个样本
+type accumulator struct {
+	sum, squares []float64
+	deals        int
+}
+
+// Run 进行模拟，ctx 结束时返回已经完成的部分和 ctx 的错误
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	n := len(cfg.Entries)
+	if n < 2 || n > 6 {
+		return nil, fmt.Errorf("a simulation needs 2 to 6 bots, got %d", n)
+	}
+	names := make(map[string]bool)
+	for _, e := range cfg.Entries {
+		if e.Name == "" || names[e.Name] || e.New == nil {
+			return nil, fmt.Errorf("invalid or duplicate bot %q", e.Name)
+		}
+		names[e.Name] = true
+	}
+	if cfg.Hands <= 0 {
+		return nil, fmt.Errorf("the number of hands must be positive, got %d", cfg.Hands)
+	}
+	if cfg.SmallBlind <= 0 {
+		cfg.SmallBlind = 5
+	}
+	if cfg.Stack <= 0 {
+		cfg.Stack = cfg.SmallBlind * 2 * 100
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+
+	// 复式模式下每副牌打 n 手，每手牌轮换一次座位
+	rotations, deals := 1, cfg.Hands
+	if cfg.Duplicate {
+		rotations, deals = n, (cfg.Hands+n-1)/n
+	}
+	cfg.Workers = min(cfg.Workers, deals)
+
+	// 每副牌的输赢按发牌的编号保存，最后按顺序累加，浮点数的求和顺序与并行的对局数无关
+	results := make([][]float64, deals)
+	errs := make([]error, cfg.Workers)
+	var wg sync.WaitGroup
+	for w := range errs {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			errs[w] = work(ctx, cfg, w, rotations, results)
+		}(w)
+	}
+	wg.Wait()
+
+	total := &accumulator{sum: make([]float64, n), squares: make([]float64, n)}
+	for _, won := range results {
+		if won == nil {
+			continue
+		}
+		for i, v := range won {
+			total.sum[i] += v
+			total.squares[i] += v * v
+		}
+		total.deals++
+	}
+	report := total.report(cfg, rotations)
+
+	for _, err := range errs {
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, ctx.Err()
+}
+
+// work 依次进行编号为 w、w+Workers、w+2*Workers …… 的发牌，
+// 把每副牌平均每手赢得的大盲注写入 results 中对应的位置。
+// 机器人按发牌的编号创建，每副牌的结果与由哪个对局进行无关
+func work(ctx context.Context, cfg Config, w, rotations int, results [][]float64) error {
+	n := len(cfg.Entries)
+	for d := w; d < len(results); d += cfg.Workers {
+		if err := ctx.Err(); err != nil {
+			return nil
+		}
+
+		bots := make([]poker.Bot, n)
+		for i, e := range cfg.Entries {
+			bots[i] = e.New(cfg.Seed + int64(d)*int64(n) + int64(i))
+		}
+		deck := poker.NewDeckWithRand(rand.New(rand.NewSource(cfg.Seed + int64(d))))
+		won := make([]float64, n)
+		for r := 0; r < rotations; r++ {
+			// 非复式模式下按发牌的编号轮换座位，让庄家位置轮流转动
+			rotation := r
+			if rotations == 1 {
+				rotation = d % n
+			}
+			chips, err := play(cfg, bots, deck, rotation)
+			if err != nil {
+				return fmt.Errorf("deal %d: %w", d, err)
+			}
+			for i, c := range chips {
+				won[i] += float64(c-cfg.Stack) / float64(cfg.SmallBlind*2)
+			}
+		}
+
+		for i := range won {
+			won[i] /= float64(rotations)
+		}
+		results[d] = won
+	}
+	return nil
+}
+
+// play 在新的牌桌上用指定的牌堆打一手牌，座位 s 上坐的是第 (s+rotation)%n 个机器人，
+// 返回每个机器人在这手牌结束时的筹码
+func play(cfg Config, bots []poker.Bot, deck *poker.Deck, rotation int) ([]int, error) {
+	n := len(bots)
+	g := poker.NewGameWithSeats(n)
+	g.BotTimeout, g.HandEvents = -1, true
+	g.DeckSource = func() *poker.Deck {
+		return &poker.Deck{Cards: append([]poker.Card(nil), deck.Cards...)}
+	}
+	if err := g.SetBlinds(cfg.SmallBlind, cfg.Ante); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, n)
+	s := g.Table.Seats
+	for seat := 0; seat < n; seat++ {
+		i := (seat + rotation) % n
+		ids[i] = s.Player.Id
+		if err := g.AddBot(s.Player.Id, cfg.Entries[i].Name, cfg.Stack, bots[i]); err != nil {
+			return nil, err
+		}
+		s = s.Next()
+	}
+	if err := g.StartHand(); err != nil {
+		return nil, err
+	}
+	if g.IsPlayerStage() {
+		return nil, fmt.Errorf("the hand did not finish, waiting for %s", g.CurrentSeat.Player.Name)
+	}
+
+	chips := make([]int, n)
+	for i, id := range ids {
+		chips[i] = g.PlayerMap[id].Chips
+	}
+	return chips, nil
+}
+
+// report 计算每个机器人的 bb/100 和置信区间
+func (a *accumulator) report(cfg Config, rotations int) *Report {
+	r := &Report{Hands: a.deals * rotations, Deals: a.deals, Duplicate: cfg.Duplicate}
+	for i, e := range cfg.Entries {
+		s := Stats{Name: e.Name, Hands: a.deals * rotations, Won: a.sum[i] * float64(rotations)}
+		if a.deals > 0 {
+			mean := a.sum[i] / float64(a.deals)
+			s.BB100 = mean * 100
+			if a.deals > 1 {
+				variance := (a.squares[i] - a.sum[i]*mean) / float64(a.deals-1)
+				s.StdDev = math.Sqrt(max(variance, 0))
+				s.CI95 = 1.96 * s.StdDev / math.Sqrt(float64(a.deals)) * 100
+			}
+		}
+		r.Stats = append(r.Stats, s)
+	}
+	return r
+}
@@ -0,0 +1,97 @@
+package sim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lllllan02/pocker/bot"
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixed 创建总是选择第一个可选动作的机器人，按顺序优先选择 types 中的动作
+func fixed(types ...poker.ActionType) func(int64) poker.Bot {
+	return func(int64) poker.Bot {
+		return poker.BotFunc(func(ctx context.Context, v *poker.View) (poker.Decision, error) {
+			for _, t := range types {
+				for _, a := range v.Legal {
+					if a.Type == t {
+						return poker.Decision{Type: t, Amount: a.Min}, nil
+					}
+				}
+			}
+			return poker.Decision{Type: v.Legal[0].Type, Amount: v.Legal[0].Min}, nil
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	_, err := Run(context.Background(), Config{Entries: []Entry{{Name: "a", New: fixed()}}, Hands: 10})
+	assert.Error(t, err)
+	_, err = Run(context.Background(), Config{Entries: []Entry{{Name: "a", New: fixed()}, {Name: "a", New: fixed()}}, Hands: 10})
+	assert.Error(t, err)
+
+	// 总是加注的机器人对总是弃牌的机器人，弃牌的一方作为小盲注输 0.5 个大盲注，作为大盲注输 1 个
+	entries := []Entry{
+		{Name: "raiser", New: fixed(poker.ActionRaise, poker.ActionBet, poker.ActionCall)},
+		{Name: "folder", New: fixed(poker.ActionCheck, poker.ActionFold)},
+	}
+	for _, duplicate := range []bool{false, true} {
+		report, err := Run(context.Background(), Config{Entries: entries, Hands: 100, Seed: 1, Duplicate: duplicate, Workers: 3})
+		require.NoError(t, err)
+		assert.Equal(t, 100, report.Hands)
+		assert.InDelta(t, 75, report.Stats[0].BB100, 1e-9)
+		assert.InDelta(t, -75, report.Stats[1].BB100, 1e-9)
+		assert.InDelta(t, -75, report.Stats[1].Won, 1e-9)
+		if duplicate {
+			assert.Equal(t, 50, report.Deals)
+			assert.InDelta(t, 0, report.Stats[1].CI95, 1e-9)
+		} else {
+			assert.Greater(t, report.Stats[1].CI95, 0.0)
+		}
+	}
+}
+
+func TestRunReproducible(t *testing.T) {
+	level := func(name string) func(int64) poker.Bot {
+		return func(seed int64) poker.Bot {
+			b, err := bot.New(name, seed)
+			require.NoError(t, err)
+			b.Config.Trials = 50
+			return b
+		}
+	}
+	cfg := Config{
+		Entries:   []Entry{{Name: "hard", New: level("hard")}, {Name: "easy", New: level("easy")}, {Name: "medium", New: level("medium")}},
+		Hands:     60,
+		Seed:      3,
+		Duplicate: true,
+		Workers:   2,
+	}
+
+	// 相同的设置得到相同的结果，筹码守恒
+	a, err := Run(context.Background(), cfg)
+	require.NoError(t, err)
+	b, err := Run(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	// 结果与并行的对局数无关
+	for _, workers := range []int{1, 3} {
+		cfg := cfg
+		cfg.Workers = workers
+		c, err := Run(context.Background(), cfg)
+		require.NoError(t, err)
+		assert.Equal(t, a, c, workers)
+	}
+	total := 0.0
+	for _, s := range a.Stats {
+		total += s.Won
+	}
+	assert.InDelta(t, 0, total, 1e-9)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, cfg)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,291 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// defaultMemoryLimit 累计遗憾值和平均策略默认最多占用的内存
+const defaultMemoryLimit = 1 << 30
+
+// exploitabilityInterval 设置了目标时，每隔多少轮迭代计算一次可剥削度
+const exploitabilityInterval = 25
+
+// Spot 需要求解的转牌或河牌局面，不在位的玩家 0 先行动
+type Spot struct {
+	Board  []poker.Card `json:"board"`  // 公共牌，4 张时求解转牌和河牌，5 张时只求解河牌
+	Pot    int          `json:"pot"`    // 当前的底池
+	Stack  int          `json:"stack"`  // 双方剩余的有效筹码
+	Ranges [2]Range     `json:"ranges"` // 不在位和在位玩家的范围
+	Sizes  BetSizes     `json:"sizes"`  // 下注尺寸
+}
+
+// Solver 使用 CFR+ 求解双人转牌或河牌局面的均衡。
+// 每轮迭代交替更新双方的遗憾值，遗憾值小于 0 时归零，平均策略按迭代轮数线性加权
+type Solver struct {
+	Spot       Spot  // 求解的局面
+	Iterations int   // 已经完成的迭代次数
+	Memory     int64 // 累计遗憾值和平均策略占用的内存
+
+	root    *node
+	ranges  [2]Range     // 去掉与公共牌冲突之后的范围
+	weights [2][]float64 // 每种组合的初始权重
+	cards   [2][][2]int  // 每种组合两张牌的编号
+	same    [2][]int     // 对手范围中相同组合的下标，没有时为 -1
+	with    [2][52][]int // 包含某张牌的组合的下标
+	boards  []*board     // 所有摊牌节点上的公共牌
+}
+
+// New 构造博弈树并分配内存，超过 memoryLimit 字节时返回错误，memoryLimit 为 0 时使用默认的 1 GiB
+func New(spot Spot, memoryLimit int64) (*Solver, error) {
+	if len(spot.Board) != 4 && len(spot.Board) != 5 {
+		return nil, fmt.Errorf("the board must have 4 or 5 cards, got %d", len(spot.Board))
+	}
+	for i, c := range spot.Board {
+		if c.Rank < poker.Two || c.Rank > poker.Ace || c.Suit < poker.Clubs || c.Suit > poker.Spades || contains(spot.Board[:i], c) {
+			return nil, fmt.Errorf("invalid board %v", codes(spot.Board))
+		}
+	}
+	if spot.Pot <= 0 || spot.Stack < 0 {
+		return nil, fmt.Errorf("invalid pot %d and stack %d", spot.Pot, spot.Stack)
+	}
+	if memoryLimit <= 0 {
+		memoryLimit = defaultMemoryLimit
+	}
+
+	s := &Solver{Spot: spot}
+	for p, r := range spot.Ranges {
+		for _, c := range r {
+			if c.Weight > 0 && !contains(spot.Board, c.Cards[0]) && !contains(spot.Board, c.Cards[1]) {
+				s.ranges[p] = append(s.ranges[p], c)
+			}
+		}
+		if len(s.ranges[p]) == 0 {
+			return nil, fmt.Errorf("player %d has no combo left on board %v", p, codes(spot.Board))
+		}
+		for i, c := range s.ranges[p] {
+			a, b := cardIndex(c.Cards[0]), cardIndex(c.Cards[1])
+			s.weights[p] = append(s.weights[p], c.Weight)
+			s.cards[p] = append(s.cards[p], [2]int{a, b})
+			s.with[p][a] = append(s.with[p][a], i)
+			s.with[p][b] = append(s.with[p][b], i)
+		}
+	}
+	for p := range s.same {
+		index := make(map[[2]poker.Card]int)
+		for i, c := range s.ranges[1-p] {
+			index[sorted(c.Cards)] = i
+		}
+		for _, c := range s.ranges[p] {
+			i, ok := index[sorted(c.Cards)]
+			if !ok {
+				i = -1
+			}
+			s.same[p] = append(s.same[p], i)
+		}
+	}
+
+	// 构造博弈树，估算内存之后再分配
+	b := &builder{sizes: spot.Sizes, start: spot.Pot, boards: make(map[[5]poker.Card]*board)}
+	for suit := poker.Clubs; suit <= poker.Spades; suit++ {
+		for rank := poker.Two; rank <= poker.Ace; rank++ {
+			if c := (poker.Card{Rank: rank, Suit: suit}); !contains(spot.Board, c) {
+				b.deck = append(b.deck, c)
+			}
+		}
+	}
+	s.root = b.build(street{board: spot.Board, pot: spot.Pot, stack: spot.Stack})
+	s.walk(s.root, func(n *node) {
+		if n.kind == nodeAction {
+			s.Memory += int64(len(n.actions)*len(s.ranges[n.player])) * 2 * 4
+		}
+	})
+	if s.Memory > memoryLimit {
+		return nil, fmt.Errorf("the tree needs %d MiB, more than the limit of %d MiB", s.Memory>>20, memoryLimit>>20)
+	}
+	s.walk(s.root, func(n *node) {
+		if n.kind == nodeAction {
+			n.regrets = make([]float32, len(n.actions)*len(s.ranges[n.player]))
+			n.strategy = make([]float32, len(n.actions)*len(s.ranges[n.player]))
+		}
+	})
+	for _, bd := range b.boards {
+		bd.prepare(s.ranges)
+	}
+	return s, nil
+}
+
+// Run 迭代 iterations 轮，target 大于 0 时可剥削度降到底池的 target 百分比以下后提前停止。
+// 返回本次完成的迭代次数，ctx 结束时返回 ctx 的错误
+func (s *Solver) Run(ctx context.Context, iterations int, target float64) (int, error) {
+	for done := 0; done < iterations; done++ {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		s.Iterations++
+		for p := 0; p < 2; p++ {
+			s.cfr(s.root, p, [2][]float64{s.weights[0], s.weights[1]})
+		}
+		if target > 0 && (done+1)%exploitabilityInterval == 0 && s.Exploitability()/float64(s.Spot.Pot)*100 <= target {
+			return done + 1, nil
+		}
+	}
+	return iterations, nil
+}
+
+// cfr 更新玩家 p 的遗憾值和平均策略，返回 p 每种组合按对手到达概率加权的反事实收益
+func (s *Solver) cfr(n *node, p int, reach [2][]float64) []float64 {
+	// 对手到达不了的子树收益都为 0，不再向下遍历
+	if !reachable(reach[1-p]) {
+		return make([]float64, len(s.ranges[p]))
+	}
+
+	switch n.kind {
+	case nodeFold, nodeShowdown:
+		return s.terminal(n, p, reach[1-p])
+	case nodeChance:
+		return s.chance(n, p, reach, func(child *node, reach [2][]float64) []float64 { return s.cfr(child, p, reach) })
+	}
+
+	q, nc := n.player, len(s.ranges[n.player])
+	sigma := n.current(nc)
+	values := make([]float64, len(s.ranges[p]))
+
+	// 对手行动时按对手当前的策略分配到达概率
+	if q != p {
+		for a, child := range n.children {
+			next := reach
+			next[q] = scale(reach[q], sigma[a*nc:(a+1)*nc])
+			for i, v := range s.cfr(child, p, next) {
+				values[i] += v
+			}
+		}
+		return values
+	}
+
+	children := make([][]float64, len(n.children))
+	for a, child := range n.children {
+		next := reach
+		next[p] = scale(reach[p], sigma[a*nc:(a+1)*nc])
+		children[a] = s.cfr(child, p, next)
+		for i, v := range children[a] {
+			values[i] += float64(sigma[a*nc+i]) * v
+		}
+	}
+
+	// 遗憾值小于 0 时归零，平均策略按迭代轮数线性加权
+	weight := float64(s.Iterations)
+	for a := range n.children {
+		for i := 0; i < nc; i++ {
+			k := a*nc + i
+			n.regrets[k] = float32(max(float64(n.regrets[k])+children[a][i]-values[i], 0))
+			n.strategy[k] += float32(weight * reach[p][i] * float64(sigma[k]))
+		}
+	}
+	return values
+}
+
+// chance 依次发出每一张可能的公共牌，去掉与之冲突的组合后计算子节点的收益，再按发牌的概率平均。
+// 每张牌对应的子树互不相交，并行计算
+func (s *Solver) chance(n *node, p int, reach [2][]float64, visit func(*node, [2][]float64) []float64) []float64 {
+	children := make([][]float64, len(n.cards))
+	var wg sync.WaitGroup
+	for k, c := range n.cards {
+		next := reach
+		for q := range next {
+			next[q] = append([]float64(nil), reach[q]...)
+			for _, i := range s.with[q][cardIndex(c)] {
+				next[q][i] = 0
+			}
+		}
+
+		wg.Add(1)
+		go func(k int, c poker.Card) {
+			defer wg.Done()
+			child := visit(n.children[k], next)
+			for _, i := range s.with[p][cardIndex(c)] {
+				child[i] = 0
+			}
+			children[k] = child
+		}(k, c)
+	}
+	wg.Wait()
+
+	// 双方的底牌已知时，剩下的牌中还有四张不可能发出
+	values := make([]float64, len(s.ranges[p]))
+	prob := 1 / float64(len(n.cards)-4)
+	for _, child := range children {
+		for i, v := range child {
+			values[i] += v * prob
+		}
+	}
+	return values
+}
+
+// reachable 是否有组合的到达概率大于 0
+func reachable(reach []float64) bool {
+	for _, r := range reach {
+		if r > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// current 按累计遗憾值的正数部分计算当前策略，遗憾值都为 0 时平均选择每个动作
+func (n *node) current(nc int) []float32 {
+	return normalize(n.regrets, len(n.actions), nc)
+}
+
+// average 按累计平均策略计算平均策略
+func (n *node) average(nc int) []float32 {
+	return normalize(n.strategy, len(n.actions), nc)
+}
+
+// normalize 把按动作排列的非负数归一化为每种组合的动作频率
+func normalize(values []float32, actions, nc int) []float32 {
+	sigma := make([]float32, len(values))
+	for i := 0; i < nc; i++ {
+		total := float32(0)
+		for a := 0; a < actions; a++ {
+			total += values[a*nc+i]
+		}
+		for a := 0; a < actions; a++ {
+			if total > 0 {
+				sigma[a*nc+i] = values[a*nc+i] / total
+			} else {
+				sigma[a*nc+i] = 1 / float32(actions)
+			}
+		}
+	}
+	return sigma
+}
+
+// scale 到达概率乘上每种组合选择某个动作的频率
+func scale(reach []float64, sigma []float32) []float64 {
+	next := make([]float64, len(reach))
+	for i, r := range reach {
+		next[i] = r * float64(sigma[i])
+	}
+	return next
+}
+
+// walk 遍历博弈树的所有节点
+func (s *Solver) walk(n *node, visit func(*node)) {
+	visit(n)
+	for _, child := range n.children {
+		s.walk(child, visit)
+	}
+}
+
+// codes 返回牌的两字符代码
+func codes(cards []poker.Card) []string {
+	s := make([]string, len(cards))
+	for i := range cards {
+		s[i] = cards[i].Code()
+	}
+	return s
+}
@@ -0,0 +1,144 @@
+package solver
+
+import "sync"
+
+// Strategy 导出的博弈树节点
+type Strategy struct {
+	Kind      string               `json:"kind"`                // action 为玩家行动，chance 为发出下一张公共牌
+	Player    int                  `json:"player"`              // 行动的玩家
+	Actions   []Action             `json:"actions,omitempty"`   // 可以选择的动作
+	Frequency map[string][]float64 `json:"frequency,omitempty"` // 每种组合选择每个动作的频率
+	EV        map[string]float64   `json:"ev,omitempty"`        // 行动的玩家每种组合的期望收益，到达不了的组合不记录
+	Children  map[string]*Strategy `json:"children,omitempty"`  // 按动作或者发出的牌索引的子节点，不包括弃牌和摊牌
+}
+
+// Result 导出的求解结果
+type Result struct {
+	Spot           Spot                  `json:"spot"`           // 求解的局面
+	Iterations     int                   `json:"iterations"`     // 迭代次数
+	Exploitability float64               `json:"exploitability"` // 可剥削度
+	EV             [2]map[string]float64 `json:"ev"`             // 双方每种组合在开始时的期望收益
+	Root           *Strategy             `json:"root"`           // 博弈树
+}
+
+// Exploitability 双方分别对对手的平均策略做出最优反应时，多赢的筹码的平均值，均衡时为 0
+func (s *Solver) Exploitability() float64 {
+	best := 0.0
+	for p := 0; p < 2; p++ {
+		values := s.evaluate(s.root, p, [2][]float64{s.weights[0], s.weights[1]}, true, nil)
+		for i, v := range values {
+			best += s.weights[p][i] * v
+		}
+	}
+	return (best/s.pairs() - float64(s.Spot.Pot)) / 2
+}
+
+// Export 导出双方的平均策略，以及每个节点上行动的玩家每种组合的期望收益
+func (s *Solver) Export() *Result {
+	evs := make(map[*node]map[string]float64)
+	var mu sync.Mutex
+	result := &Result{Spot: s.Spot, Iterations: s.Iterations, Exploitability: s.Exploitability()}
+	for p := 0; p < 2; p++ {
+		values := s.evaluate(s.root, p, [2][]float64{s.weights[0], s.weights[1]}, false, func(n *node, values, reach []float64) {
+			ev := s.expected(n.player, values, reach)
+			mu.Lock()
+			defer mu.Unlock()
+			evs[n] = ev
+		})
+		result.EV[p] = s.expected(p, values, s.compatible(p, s.weights[1-p]))
+	}
+	result.Root = s.export(s.root, evs)
+	return result
+}
+
+// export 导出一个行动或发牌节点
+func (s *Solver) export(n *node, evs map[*node]map[string]float64) *Strategy {
+	st := &Strategy{Kind: "action", Player: n.player, Children: make(map[string]*Strategy)}
+	if n.kind == nodeChance {
+		st.Kind, st.Player = "chance", -1
+		for k, c := range n.cards {
+			if child := n.children[k]; child.kind == nodeAction {
+				st.Children[c.Code()] = s.export(child, evs)
+			}
+		}
+		return st
+	}
+
+	nc := len(s.ranges[n.player])
+	sigma := n.average(nc)
+	st.Actions, st.Frequency, st.EV = n.actions, make(map[string][]float64), evs[n]
+	for i, c := range s.ranges[n.player] {
+		freq := make([]float64, len(n.actions))
+		for a := range n.actions {
+			freq[a] = float64(sigma[a*nc+i])
+		}
+		st.Frequency[c.String()] = freq
+	}
+	for a, child := range n.children {
+		if child.kind == nodeAction || child.kind == nodeChance {
+			st.Children[n.actions[a].String()] = s.export(child, evs)
+		}
+	}
+	return st
+}
+
+// expected 把反事实收益除以对手的到达概率，得到每种组合的期望收益
+func (s *Solver) expected(p int, values, reach []float64) map[string]float64 {
+	ev := make(map[string]float64)
+	for i, c := range s.ranges[p] {
+		if reach[i] > 0 {
+			ev[c.String()] = values[i] / reach[i]
+		}
+	}
+	return ev
+}
+
+// evaluate 按双方的平均策略计算玩家 p 每种组合的反事实收益，best 为 true 时 p 改为做出最优反应。
+// record 不为 nil 时记录 p 行动的每个节点上 p 每种组合的反事实收益和对手的到达概率
+func (s *Solver) evaluate(n *node, p int, reach [2][]float64, best bool, record func(n *node, values, reach []float64)) []float64 {
+	switch n.kind {
+	case nodeFold, nodeShowdown:
+		return s.terminal(n, p, reach[1-p])
+	case nodeChance:
+		return s.chance(n, p, reach, func(child *node, reach [2][]float64) []float64 {
+			return s.evaluate(child, p, reach, best, record)
+		})
+	}
+
+	q, nc := n.player, len(s.ranges[n.player])
+	sigma := n.average(nc)
+	values := make([]float64, len(s.ranges[p]))
+	for a, child := range n.children {
+		next := reach
+		next[q] = scale(reach[q], sigma[a*nc:(a+1)*nc])
+		if q == p && best {
+			next[q] = reach[q]
+		}
+		for i, v := range s.evaluate(child, p, next, best, record) {
+			switch {
+			case q != p:
+				values[i] += v
+			case best && a == 0:
+				values[i] = v
+			case best:
+				values[i] = max(values[i], v)
+			default:
+				values[i] += float64(sigma[a*nc+i]) * v
+			}
+		}
+	}
+
+	if q == p && record != nil {
+		record(n, values, s.compatible(p, reach[1-p]))
+	}
+	return values
+}
+
+// pairs 双方初始范围中互不冲突的组合对的总权重
+func (s *Solver) pairs() float64 {
+	total := 0.0
+	for i, r := range s.compatible(0, s.weights[1]) {
+		total += s.weights[0][i] * r
+	}
+	return total
+}
@@ -0,0 +1,112 @@
+package solver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lllllan02/pocker/bot"
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Combo 范围中一种具体花色的底牌组合
+type Combo struct {
+	Cards  [2]poker.Card `json:"cards"`  // 两张底牌
+	Weight float64       `json:"weight"` // 在范围中的权重，0 到 1
+}
+
+// String 返回组合的记法，例如："AsKd"
+func (c Combo) String() string {
+	return c.Cards[0].Code() + c.Cards[1].Code()
+}
+
+// Range 玩家在当前局面下可能持有的底牌组合
+type Range []Combo
+
+// ParseRange 解析用逗号分隔的范围，每一项可以是 AKs、T9o、77 这样的起手牌，
+// 也可以是 AsKd 这样的具体组合，后面可以用冒号跟上权重，例如："AA,AKs:0.5,QhJh"
+func ParseRange(s string) (Range, error) {
+	weights := make(map[[2]poker.Card]float64)
+	order := make([][2]poker.Card, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		weight := 1.0
+		if name, w, ok := strings.Cut(item, ":"); ok {
+			f, err := strconv.ParseFloat(w, 64)
+			if err != nil || f < 0 || f > 1 {
+				return nil, fmt.Errorf("invalid weight in %q", item)
+			}
+			item, weight = name, f
+		}
+
+		combos, err := parseItem(item)
+		if err != nil {
+			return nil, err
+		}
+		for _, cards := range combos {
+			cards = sorted(cards)
+			if _, ok := weights[cards]; !ok {
+				order = append(order, cards)
+			}
+			weights[cards] = weight
+		}
+	}
+
+	r := make(Range, 0, len(order))
+	for _, cards := range order {
+		if weights[cards] > 0 {
+			r = append(r, Combo{Cards: cards, Weight: weights[cards]})
+		}
+	}
+	if len(r) == 0 {
+		return nil, fmt.Errorf("empty range %q", s)
+	}
+	return r, nil
+}
+
+// RangeOf 把起手牌范围展开为具体的组合，频率作为组合的权重
+func RangeOf(r *bot.HandRange) Range {
+	combos := make(Range, 0)
+	for i, f := range r {
+		if f <= 0 {
+			continue
+		}
+		for _, cards := range bot.StartingHandAt(i).Combos() {
+			combos = append(combos, Combo{Cards: sorted(cards), Weight: f})
+		}
+	}
+	return combos
+}
+
+// parseItem 解析范围中的一项，返回所有具体的组合
+func parseItem(item string) ([][2]poker.Card, error) {
+	if len(item) == 4 {
+		a, errA := poker.ParseCard(item[:2])
+		b, errB := poker.ParseCard(item[2:])
+		if errA == nil && errB == nil && a != b {
+			return [][2]poker.Card{{a, b}}, nil
+		}
+	}
+	h, err := bot.ParseStartingHand(item)
+	if err != nil {
+		return nil, err
+	}
+	return h.Combos(), nil
+}
+
+// sorted 按牌的编号排列两张底牌，同一种组合只有一种写法
+func sorted(cards [2]poker.Card) [2]poker.Card {
+	if cardIndex(cards[0]) < cardIndex(cards[1]) {
+		cards[0], cards[1] = cards[1], cards[0]
+	}
+	return cards
+}
+
+// cardIndex 牌在 0 到 51 之间的编号
+func cardIndex(c poker.Card) int {
+	return int(c.Suit)*13 + int(c.Rank)
+}
@@ -0,0 +1,120 @@
+package solver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cards(t *testing.T, codes ...string) []poker.Card {
+	cs := make([]poker.Card, len(codes))
+	for i, code := range codes {
+		c, err := poker.ParseCard(code)
+		require.NoError(t, err)
+		cs[i] = c
+	}
+	return cs
+}
+
+func TestParseRange(t *testing.T) {
+	r, err := ParseRange("AA, AKs:0.5, QhJh, AsAh:0.25")
+	require.NoError(t, err)
+	assert.Len(t, r, 11)
+
+	weights := make(map[string]float64)
+	for _, c := range r {
+		weights[c.String()] = c.Weight
+	}
+	assert.Equal(t, 0.25, weights["AsAh"])
+	assert.Equal(t, 1.0, weights["AhAd"])
+	assert.Equal(t, 0.5, weights["AsKs"])
+	assert.Equal(t, 1.0, weights["QhJh"])
+
+	for _, s := range []string{"", "AK", "AA:2", "AsAs", "AKs:x"} {
+		_, err := ParseRange(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSolveRiver(t *testing.T) {
+	// 经典的极化模型：在位玩家一半坚果一半空气牌，不在位玩家只有抓诈唬的牌。
+	// 下注一倍底池时，在位玩家用一半的空气牌诈唬，不在位玩家跟注一半
+	oop, err := ParseRange("QQ")
+	require.NoError(t, err)
+	ip, err := ParseRange("AA,6c5c,6d5d,6h5h")
+	require.NoError(t, err)
+	s, err := New(Spot{
+		Board:  cards(t, "As", "Ks", "7d", "4c", "2h"),
+		Pot:    100,
+		Stack:  100,
+		Ranges: [2]Range{oop, ip},
+		Sizes:  BetSizes{Bets: []float64{1}},
+	}, 0)
+	require.NoError(t, err)
+
+	_, err = s.Run(context.Background(), 5000, 0.1)
+	require.NoError(t, err)
+	r := s.Export()
+	assert.Less(t, r.Exploitability, 0.1)
+
+	check := r.Root.Children["check"]
+	require.NotNil(t, check)
+	assert.Equal(t, []Action{{Type: poker.ActionCheck}, {Type: poker.ActionBet, Amount: 100}}, check.Actions)
+	assert.InDelta(t, 0.5, check.Frequency["6c5c"][1], 0.05)
+	assert.InDelta(t, 1, check.Frequency["AhAd"][1], 0.01)
+	assert.InDelta(t, 0.5, check.Children["bet 100"].Frequency["QsQh"][1], 0.05)
+
+	// 不在位玩家赢下四分之一的底池，双方的期望之和等于底池
+	assert.InDelta(t, 25, r.EV[0]["QsQh"], 1)
+	assert.InDelta(t, 150, r.EV[1]["AhAd"], 1)
+	assert.InDelta(t, 0, r.EV[1]["6c5c"], 1)
+}
+
+func TestSolveTurn(t *testing.T) {
+	oop, err := ParseRange("AA,KK,QQ,AKs,T9s")
+	require.NoError(t, err)
+	ip, err := ParseRange("JJ,TT,AQs,KQs,87s")
+	require.NoError(t, err)
+	spot := Spot{
+		Board:  cards(t, "Ah", "Td", "6c", "3s"),
+		Pot:    100,
+		Stack:  200,
+		Ranges: [2]Range{oop, ip},
+		Sizes:  BetSizes{Bets: []float64{0.5}, AllIn: true, MaxRaises: 1},
+	}
+
+	_, err = New(spot, 1024)
+	assert.Error(t, err)
+
+	s, err := New(spot, 0)
+	require.NoError(t, err)
+	_, err = s.Run(context.Background(), 10, 0)
+	require.NoError(t, err)
+	before := s.Exploitability()
+	_, err = s.Run(context.Background(), 90, 0)
+	require.NoError(t, err)
+	assert.Less(t, s.Exploitability(), before)
+	assert.Less(t, s.Exploitability(), 2.0)
+
+	// 导出的博弈树中包括发出河牌之后的策略，与公共牌冲突的组合不在范围之内
+	r := s.Export()
+	river := r.Root.Children["check"].Children["check"]
+	require.NotNil(t, river)
+	assert.Equal(t, "chance", river.Kind)
+	assert.Len(t, river.Children, 48)
+	assert.NotContains(t, river.Children, "Ah")
+	assert.NotContains(t, r.EV[0], "AhAd")
+	assert.Contains(t, r.EV[0], "AsAd")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done, err := s.Run(ctx, 10, 0)
+	assert.Equal(t, 0, done)
+	assert.Error(t, err)
+
+	_, err = New(Spot{Board: cards(t, "Ah", "Td", "6c"), Pot: 100, Ranges: spot.Ranges}, 0)
+	assert.Error(t, err)
+}
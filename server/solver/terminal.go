@@ -0,0 +1,118 @@
+package solver
+
+import (
+	"sort"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// board 摊牌时的五张公共牌，以及双方每种组合在这组公共牌上的大小
+type board struct {
+	cards  [5]poker.Card
+	values [2][]poker.HandValue // 每种组合的大小，与公共牌冲突的组合为 0
+	order  [2][]int             // 按大小从小到大排列的组合下标
+}
+
+// prepare 使用 poker 包的估值函数计算双方每种组合的大小
+func (b *board) prepare(ranges [2]Range) {
+	cards := make([]poker.Card, 7)
+	copy(cards, b.cards[:])
+	for p, r := range ranges {
+		b.values[p] = make([]poker.HandValue, len(r))
+		b.order[p] = make([]int, len(r))
+		for i, c := range r {
+			b.order[p][i] = i
+			if contains(b.cards[:], c.Cards[0]) || contains(b.cards[:], c.Cards[1]) {
+				continue
+			}
+			cards[5], cards[6] = c.Cards[0], c.Cards[1]
+			b.values[p][i] = poker.Evaluate(cards...)
+		}
+		values := b.values[p]
+		sort.Slice(b.order[p], func(i, j int) bool { return values[b.order[p][i]] < values[b.order[p][j]] })
+	}
+}
+
+// terminal 计算玩家 p 每种组合在弃牌或摊牌节点上按对手到达概率加权的收益。
+// 收益按求解开始时的筹码计算，赢下的奖池包括开始时的底池
+func (s *Solver) terminal(n *node, p int, opp []float64) []float64 {
+	values := make([]float64, len(s.ranges[p]))
+	start := float64(s.Spot.Pot)
+
+	compatible := s.compatible(p, opp)
+
+	if n.kind == nodeFold {
+		payoff := start + float64(n.invested)
+		if n.player == p {
+			payoff = -float64(n.invested)
+		}
+		for i := range values {
+			values[i] = payoff * compatible[i]
+		}
+		return values
+	}
+
+	// 摊牌时按大小排序后扫描，分别累计比自己小和比自己大的对手组合
+	b, q := n.board, 1-p
+	win, lose := make([]float64, len(values)), make([]float64, len(values))
+	sweep := func(result []float64, less func(a, b poker.HandValue) bool, forward bool) {
+		var sum float64
+		var card [52]float64
+		mine, theirs := b.order[p], b.order[q]
+		for k := range mine {
+			i := mine[k]
+			if !forward {
+				i = mine[len(mine)-1-k]
+			}
+			for len(theirs) > 0 {
+				j := theirs[0]
+				if !forward {
+					j = theirs[len(theirs)-1]
+				}
+				if !less(b.values[q][j], b.values[p][i]) {
+					break
+				}
+				sum += opp[j]
+				card[s.cards[q][j][0]] += opp[j]
+				card[s.cards[q][j][1]] += opp[j]
+				if forward {
+					theirs = theirs[1:]
+				} else {
+					theirs = theirs[:len(theirs)-1]
+				}
+			}
+			c := s.cards[p][i]
+			result[i] = sum - card[c[0]] - card[c[1]]
+		}
+	}
+	sweep(win, func(a, b poker.HandValue) bool { return a < b }, true)
+	sweep(lose, func(a, b poker.HandValue) bool { return a > b }, false)
+
+	invested := float64(n.invested)
+	for i := range values {
+		tie := compatible[i] - win[i] - lose[i]
+		values[i] = win[i]*(start+invested) + tie*start/2 - lose[i]*invested
+	}
+	return values
+}
+
+// compatible 玩家 p 的每种组合对应的对手到达概率之和，对手与自己的组合不能有相同的牌。
+// 按牌分别累计后用容斥原理去掉冲突的组合
+func (s *Solver) compatible(p int, opp []float64) []float64 {
+	var total float64
+	var byCard [52]float64
+	for j, r := range opp {
+		total += r
+		byCard[s.cards[1-p][j][0]] += r
+		byCard[s.cards[1-p][j][1]] += r
+	}
+
+	reach := make([]float64, len(s.ranges[p]))
+	for i, c := range s.cards[p] {
+		reach[i] = total - byCard[c[0]] - byCard[c[1]]
+		if j := s.same[p][i]; j >= 0 {
+			reach[i] += opp[j]
+		}
+	}
+	return reach
+}
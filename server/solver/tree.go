@@ -0,0 +1,184 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// BetSizes 每条街上可以选择的下注尺寸
+type BetSizes struct {
+	Bets      []float64 `json:"bets"`       // 首次下注占底池的比例
+	Raises    []float64 `json:"raises"`     // 加注时跟注之后再加上底池的多少比例
+	AllIn     bool      `json:"all_in"`     // 是否总是可以全下
+	MaxRaises int       `json:"max_raises"` // 每条街最多加注的次数，为 0 时不限制
+}
+
+// DefaultBetSizes 默认的下注尺寸：三分之一、三分之二和一倍底池的下注，一倍底池的加注以及全下
+var DefaultBetSizes = BetSizes{Bets: []float64{0.33, 0.67, 1}, Raises: []float64{1}, AllIn: true, MaxRaises: 3}
+
+// Action 博弈树上的一个动作
+type Action struct {
+	Type   poker.ActionType `json:"type"`   // 动作类型
+	Amount int              `json:"amount"` // 下注或加注后本轮的下注总额
+}
+
+// String 返回动作的简短描述，例如："check"、"bet 50"
+func (a Action) String() string {
+	switch a.Type {
+	case poker.ActionFold:
+		return "fold"
+	case poker.ActionCheck:
+		return "check"
+	case poker.ActionCall:
+		return "call"
+	case poker.ActionBet:
+		return fmt.Sprintf("bet %d", a.Amount)
+	}
+	return fmt.Sprintf("raise %d", a.Amount)
+}
+
+// nodeKind 博弈树节点的类型
+type nodeKind int
+
+const (
+	nodeAction   nodeKind = iota // 玩家行动
+	nodeChance                   // 发出下一张公共牌
+	nodeFold                     // 有玩家弃牌
+	nodeShowdown                 // 摊牌
+)
+
+// node 博弈树的节点
+type node struct {
+	kind     nodeKind
+	player   int          // 行动的玩家，弃牌节点上为弃牌的玩家
+	invested int          // 每名玩家在求解的局面中投入的筹码，弃牌节点上为弃牌的玩家投入的筹码
+	actions  []Action     // 可以选择的动作
+	children []*node      // 每个动作或每张公共牌对应的子节点
+	cards    []poker.Card // 发牌节点上可能发出的牌
+	board    *board       // 摊牌节点上的公共牌
+
+	regrets  []float32 // 按动作排列的每种组合的累计遗憾值
+	strategy []float32 // 按动作排列的每种组合的累计平均策略
+}
+
+// street 构造博弈树时一条街的状态
+type street struct {
+	board   []poker.Card // 公共牌
+	pot     int          // 本轮开始时的底池
+	bets    [2]int       // 本轮双方的下注
+	stack   int          // 本轮开始时双方剩余的筹码
+	player  int          // 轮到行动的玩家
+	raises  int          // 本轮加注的次数
+	checked bool         // 本轮是否已经有人过牌
+}
+
+// builder 构造博弈树
+type builder struct {
+	sizes  BetSizes
+	start  int                      // 求解开始时的底池
+	deck   []poker.Card             // 发牌节点可以发出的牌
+	boards map[[5]poker.Card]*board // 相同公共牌的摊牌节点共用手牌的大小
+}
+
+// build 从一条街的状态构造博弈树
+func (b *builder) build(s street) *node {
+	invested := (s.pot-b.start)/2 + s.bets[s.player]
+	n := &node{kind: nodeAction, player: s.player, invested: invested}
+	me, opp := s.bets[s.player], s.bets[1-s.player]
+	allIn := s.stack
+
+	// 面对下注时可以弃牌、跟注或加注，否则可以过牌或下注
+	if opp > me {
+		n.add(Action{Type: poker.ActionFold}, &node{kind: nodeFold, player: s.player, invested: invested})
+		n.add(Action{Type: poker.ActionCall}, b.next(s, opp))
+		if opp < allIn && (b.sizes.MaxRaises == 0 || s.raises < b.sizes.MaxRaises) {
+			pot := s.pot + 2*opp
+			for _, amount := range b.amounts(b.sizes.Raises, opp, pot, opp*2, allIn) {
+				t := s
+				t.bets[s.player], t.player, t.raises = amount, 1-s.player, s.raises+1
+				n.add(Action{Type: poker.ActionRaise, Amount: amount}, b.build(t))
+			}
+		}
+		return n
+	}
+
+	if s.checked && s.player == 1 {
+		n.add(Action{Type: poker.ActionCheck}, b.next(s, me))
+	} else {
+		t := s
+		t.player, t.checked = 1-s.player, true
+		n.add(Action{Type: poker.ActionCheck}, b.build(t))
+	}
+	for _, amount := range b.amounts(b.sizes.Bets, 0, s.pot, 1, allIn) {
+		t := s
+		t.bets[s.player], t.player = amount, 1-s.player
+		n.add(Action{Type: poker.ActionBet, Amount: amount}, b.build(t))
+	}
+	return n
+}
+
+// amounts 按底池比例计算下注后本轮的下注总额，去掉重复和过小的尺寸，超过全下时改为全下
+func (b *builder) amounts(fractions []float64, base, pot, least, allIn int) []int {
+	seen := make(map[int]bool)
+	amounts := make([]int, 0, len(fractions)+1)
+	for _, f := range fractions {
+		amount := min(base+int(math.Round(f*float64(pot))), allIn)
+		if (amount >= least || amount == allIn) && amount > base && !seen[amount] {
+			seen[amount] = true
+			amounts = append(amounts, amount)
+		}
+	}
+	if b.sizes.AllIn && allIn > base && !seen[allIn] {
+		amounts = append(amounts, allIn)
+	}
+	sort.Ints(amounts)
+	return amounts
+}
+
+// next 双方下注相等后结束这条街，进入下一条街或者摊牌
+func (b *builder) next(s street, bet int) *node {
+	pot, stack := s.pot+2*bet, s.stack-bet
+	invested := (pot - b.start) / 2
+	if len(s.board) == 5 {
+		key := [5]poker.Card(s.board)
+		if b.boards[key] == nil {
+			b.boards[key] = &board{cards: key}
+		}
+		return &node{kind: nodeShowdown, invested: invested, board: b.boards[key]}
+	}
+
+	// 发出下一张公共牌，有人全下后不再有行动，直接发完公共牌
+	n := &node{kind: nodeChance, invested: invested}
+	for _, c := range b.deck {
+		if contains(s.board, c) {
+			continue
+		}
+		t := street{board: append(append(make([]poker.Card, 0, 5), s.board...), c), pot: pot, stack: stack}
+		n.cards = append(n.cards, c)
+		if stack == 0 {
+			n.children = append(n.children, b.next(t, 0))
+		} else {
+			n.children = append(n.children, b.build(t))
+		}
+	}
+	return n
+}
+
+// add 添加一个动作和对应的子节点
+func (n *node) add(a Action, child *node) {
+	n.actions = append(n.actions, a)
+	n.children = append(n.children, child)
+}
+
+// contains 牌是否在其中
+func contains(cards []poker.Card, c poker.Card) bool {
+	for _, card := range cards {
+		if card == c {
+			return true
+		}
+	}
+	return false
+}
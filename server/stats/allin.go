@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"math/rand"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// allInTrials 还有三张以上公共牌没有发出时模拟的次数，剩下一两张时枚举所有发牌
+const allInTrials = 2000
+
+// pot 分配给赢家的一个奖池
+type pot struct {
+	amount int   // 赢家分得的筹码，不包括抽水
+	seats  []int // 有资格赢取该奖池且没有弃牌的座位
+}
+
+// allInEV 按全下时的胜率计算每个座位从奖池中应得的筹码。
+// board 为全下时已经发出的公共牌，live 为没有弃牌的座位的底牌，平分的奖池按人数折算
+func allInEV(board []poker.Card, live map[int][2]poker.Card, pots []pot, seed int64) map[int]float64 {
+	used := make(map[poker.Card]bool)
+	for _, c := range board {
+		used[c] = true
+	}
+	for _, cards := range live {
+		used[cards[0]], used[cards[1]] = true, true
+	}
+	deck := make([]poker.Card, 0, poker.DeckSize)
+	for s := poker.Clubs; s <= poker.Spades; s++ {
+		for rank := poker.Two; rank <= poker.Ace; rank++ {
+			if c := (poker.Card{Rank: rank, Suit: s}); !used[c] {
+				deck = append(deck, c)
+			}
+		}
+	}
+
+	ev := make(map[int]float64, len(live))
+	runouts := 0
+	values := make(map[int]poker.HandValue, len(live))
+	cards := make([]poker.Card, 7)
+	score := func(rest []poker.Card) {
+		runouts++
+		copy(cards, board)
+		copy(cards[len(board):], rest)
+		for seat, hole := range live {
+			cards[5], cards[6] = hole[0], hole[1]
+			values[seat] = poker.Evaluate(cards...)
+		}
+
+		for _, p := range pots {
+			var winners []int
+			for _, seat := range p.seats {
+				switch {
+				case len(winners) == 0 || values[seat] > values[winners[0]]:
+					winners = append(winners[:0], seat)
+				case values[seat] == values[winners[0]]:
+					winners = append(winners, seat)
+				}
+			}
+			for _, seat := range winners {
+				ev[seat] += float64(p.amount) / float64(len(winners))
+			}
+		}
+	}
+
+	switch n := 5 - len(board); n {
+	case 0:
+		score(nil)
+	case 1:
+		for _, c := range deck {
+			score([]poker.Card{c})
+		}
+	case 2:
+		for i := range deck {
+			for j := i + 1; j < len(deck); j++ {
+				score([]poker.Card{deck[i], deck[j]})
+			}
+		}
+	default:
+		r := rand.New(rand.NewSource(seed))
+		for i := 0; i < allInTrials; i++ {
+			for j := 0; j < n; j++ {
+				k := j + r.Intn(len(deck)-j)
+				deck[j], deck[k] = deck[k], deck[j]
+			}
+			score(deck[:n])
+		}
+	}
+
+	for seat := range ev {
+		ev[seat] /= float64(runouts)
+	}
+	return ev
+}
@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lllllan02/pocker/history"
+	"github.com/lllllan02/pocker/poker"
+)
+
+// AddHand 重现一手牌的记录，并将这手牌的事件按记录的开始时间记入统计
+func (t *Tracker) AddHand(h *history.Hand) error {
+	g, err := history.Replay(h)
+	if err != nil {
+		return err
+	}
+
+	start := 0
+	for i, e := range g.Events {
+		if _, ok := e.(poker.HandStarted); ok {
+			start = i
+		}
+	}
+	for _, e := range g.Events[start:] {
+		t.record(g, e, h.Time)
+	}
+	return nil
+}
+
+// Load 读取每行一手牌的 JSON 记录并记入统计，返回记入的手数。
+// 无法重现的手牌会被跳过，全部读完后返回遇到的第一个错误
+func (t *Tracker) Load(r io.Reader) (int, error) {
+	hands, err := history.ReadJSON(r)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	var first error
+	for _, h := range hands {
+		if err := t.AddHand(h); err != nil {
+			if first == nil {
+				first = fmt.Errorf("hand #%d: %w", h.Id, err)
+			}
+			continue
+		}
+		n++
+	}
+	return n, first
+}
@@ -0,0 +1,106 @@
+package stats
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// SessionGap 两手牌之间超过这段时间后开始新的牌局
+const SessionGap = 30 * time.Minute
+
+// HandResult 一名玩家一手牌的输赢
+type HandResult struct {
+	HandId   int            `json:"hand_id"`   // 手牌编号
+	Time     time.Time      `json:"time"`      // 开始的时间
+	Position poker.Position `json:"position"`  // 相对庄家的位置
+	BigBlind int            `json:"big_blind"` // 大盲注金额
+	AllIn    bool           `json:"all_in"`    // 是否在全下后发完公共牌，只有这样的手牌期望输赢与实际不同
+	Net      int            `json:"net"`       // 实际输赢
+	EVNet    float64        `json:"ev_net"`    // 按全下时的胜率分配奖池的期望输赢
+}
+
+// Luck 这手牌实际输赢超出期望的部分，为正时运气好
+func (r HandResult) Luck() float64 { return float64(r.Net) - r.EVNet }
+
+// Session 一名玩家连续打的一段牌局，两手牌之间相隔不超过 SessionGap
+type Session struct {
+	Start time.Time    `json:"start"`  // 第一手牌开始的时间
+	End   time.Time    `json:"end"`    // 最后一手牌开始的时间
+	Hands []HandResult `json:"hands"`  // 按顺序排列的每手牌的输赢
+	Net   int          `json:"net"`    // 实际输赢
+	EVNet float64      `json:"ev_net"` // 全下期望输赢
+}
+
+// Luck 这段牌局实际输赢超出全下期望的部分
+func (s Session) Luck() float64 { return float64(s.Net) - s.EVNet }
+
+// Point 输赢曲线上的一个点，除了 HandLuck 都是从牌局开始累计的
+type Point struct {
+	Hand     int     `json:"hand"`      // 第几手牌，从 1 开始
+	HandId   int     `json:"hand_id"`   // 手牌编号
+	Net      int     `json:"net"`       // 实际输赢
+	EVNet    float64 `json:"ev_net"`    // 全下期望输赢
+	Luck     float64 `json:"luck"`      // 实际输赢超出期望的部分
+	HandLuck float64 `json:"hand_luck"` // 这一手牌的运气
+}
+
+// Graph 每手牌之后的累计输赢，用于画出实际和期望的输赢曲线
+func (s Session) Graph() []Point {
+	points := make([]Point, len(s.Hands))
+	var p Point
+	for i, r := range s.Hands {
+		p.Hand, p.HandId = i+1, r.HandId
+		p.HandLuck = r.Luck()
+		p.Net += r.Net
+		p.EVNet += r.EVNet
+		p.Luck = float64(p.Net) - p.EVNet
+		points[i] = p
+	}
+	return points
+}
+
+// add 记入一手牌的输赢
+func (s *Session) add(r HandResult) {
+	s.Hands = append(s.Hands, r)
+	s.End = r.Time
+	s.Net += r.Net
+	s.EVNet += r.EVNet
+}
+
+// Sessions 获取玩家按时间顺序的所有牌局，最后一个可能还在进行
+func (t *Tracker) Sessions(name string) []Session {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	sessions := make([]Session, len(t.sessions[name]))
+	for i, s := range t.sessions[name] {
+		sessions[i] = *s
+		sessions[i].Hands = slices.Clone(s.Hands)
+	}
+	return sessions
+}
+
+// Session 获取玩家最近的一段牌局，没有记录时返回 false
+func (t *Tracker) Session(name string) (Session, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	sessions := t.sessions[name]
+	if len(sessions) == 0 {
+		return Session{}, false
+	}
+	s := *sessions[len(sessions)-1]
+	s.Hands = slices.Clone(s.Hands)
+	return s, true
+}
+
+// session 获取玩家在 at 时进行的牌局，与上一手牌相隔超过 SessionGap 时开始新的牌局
+func (t *Tracker) session(name string, at time.Time) *Session {
+	sessions := t.sessions[name]
+	if n := len(sessions); n > 0 && at.Sub(sessions[n-1].End) <= SessionGap {
+		return sessions[n-1]
+	}
+	s := &Session{Start: at, End: at, Hands: make([]HandResult, 0)}
+	t.sessions[name] = append(sessions, s)
+	return s
+}
@@ -0,0 +1,392 @@
+// Package stats 从牌局的事件日志中统计每名玩家的打法，供机器人建模对手和 HUD 显示使用。
+//
+// Tracker 作为事件监听者挂在牌局上，与牌桌上的机器人共用同一份统计
+package stats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Stats 一名玩家的累计统计，比例都由计数计算
+type Stats struct {
+	Hands           int     `json:"hands"`             // 拿到底牌的手数
+	VPIP            int     `json:"vpip"`              // 翻牌前主动投入筹码的手数
+	PFR             int     `json:"pfr"`               // 翻牌前加注的手数
+	ThreeBet        int     `json:"three_bet"`         // 翻牌前再加注的手数
+	ThreeBetChances int     `json:"three_bet_chances"` // 翻牌前面对一次加注的手数
+	CBet            int     `json:"cbet"`              // 持续下注的次数
+	CBetChances     int     `json:"cbet_chances"`      // 作为翻牌前最后的加注者在翻牌圈可以首先下注的次数
+	FoldToCBet      int     `json:"fold_to_cbet"`      // 面对持续下注弃牌的次数
+	FacedCBet       int     `json:"faced_cbet"`        // 面对持续下注的次数
+	Bets            int     `json:"bets"`              // 翻牌后下注和加注的次数
+	Calls           int     `json:"calls"`             // 翻牌后跟注的次数
+	SawFlop         int     `json:"saw_flop"`          // 看到翻牌的手数
+	Showdown        int     `json:"showdown"`          // 摊牌的手数
+	WonShowdown     int     `json:"won_showdown"`      // 摊牌时赢得筹码的手数
+	Net             int     `json:"net"`               // 累计输赢的筹码
+	NetBB           float64 `json:"net_bb"`            // 按每手牌的大盲注折算的累计输赢
+	EVNet           float64 `json:"ev_net"`            // 全下时按胜率分配奖池的累计输赢
+	EVNetBB         float64 `json:"ev_net_bb"`         // 按每手牌的大盲注折算的全下期望输赢
+}
+
+// VPIPRate 翻牌前主动入池的百分比
+func (s Stats) VPIPRate() float64 { return percent(s.VPIP, s.Hands) }
+
+// PFRRate 翻牌前加注的百分比
+func (s Stats) PFRRate() float64 { return percent(s.PFR, s.Hands) }
+
+// ThreeBetRate 面对加注时再加注的百分比
+func (s Stats) ThreeBetRate() float64 { return percent(s.ThreeBet, s.ThreeBetChances) }
+
+// CBetRate 持续下注的百分比
+func (s Stats) CBetRate() float64 { return percent(s.CBet, s.CBetChances) }
+
+// FoldToCBetRate 面对持续下注时弃牌的百分比
+func (s Stats) FoldToCBetRate() float64 { return percent(s.FoldToCBet, s.FacedCBet) }
+
+// WTSDRate 看到翻牌后摊牌的百分比
+func (s Stats) WTSDRate() float64 { return percent(s.Showdown, s.SawFlop) }
+
+// WSDRate 摊牌时赢得筹码的百分比
+func (s Stats) WSDRate() float64 { return percent(s.WonShowdown, s.Showdown) }
+
+// Aggression 翻牌后的激进系数，即下注和加注的次数除以跟注的次数，没有跟注时为下注和加注的次数
+func (s Stats) Aggression() float64 {
+	if s.Calls == 0 {
+		return float64(s.Bets)
+	}
+	return float64(s.Bets) / float64(s.Calls)
+}
+
+// WinRate 每 100 手牌赢得的大盲注数
+func (s Stats) WinRate() float64 { return per100(s.NetBB, s.Hands) }
+
+// EVWinRate 按全下期望输赢计算的每 100 手牌赢得的大盲注数
+func (s Stats) EVWinRate() float64 { return per100(s.EVNetBB, s.Hands) }
+
+// Luck 实际输赢超出全下期望的部分，为正时运气好
+func (s Stats) Luck() float64 { return float64(s.Net) - s.EVNet }
+
+// HUD 在座位旁显示和个人主页展示的统计
+type HUD struct {
+	Hands      int     `json:"hands"`        // 手数
+	VPIP       float64 `json:"vpip"`         // 翻牌前主动入池的百分比
+	PFR        float64 `json:"pfr"`          // 翻牌前加注的百分比
+	ThreeBet   float64 `json:"three_bet"`    // 再加注的百分比
+	AF         float64 `json:"af"`           // 翻牌后的激进系数
+	WTSD       float64 `json:"wtsd"`         // 看到翻牌后摊牌的百分比
+	WSD        float64 `json:"wsd"`          // 摊牌时赢得筹码的百分比
+	CBet       float64 `json:"cbet"`         // 持续下注的百分比
+	FoldToCBet float64 `json:"fold_to_cbet"` // 面对持续下注弃牌的百分比
+	WinRate    float64 `json:"win_rate"`     // 每 100 手牌赢得的大盲注数
+	EVWinRate  float64 `json:"ev_win_rate"`  // 全下期望的每 100 手牌赢得的大盲注数
+	Net        int     `json:"net"`          // 累计输赢的筹码
+	EVNet      float64 `json:"ev_net"`       // 全下期望的累计输赢
+	Luck       float64 `json:"luck"`         // 实际输赢超出全下期望的部分
+}
+
+// HUD 计算显示用的比例
+func (s Stats) HUD() HUD {
+	return HUD{
+		Hands:      s.Hands,
+		VPIP:       s.VPIPRate(),
+		PFR:        s.PFRRate(),
+		ThreeBet:   s.ThreeBetRate(),
+		AF:         s.Aggression(),
+		WTSD:       s.WTSDRate(),
+		WSD:        s.WSDRate(),
+		CBet:       s.CBetRate(),
+		FoldToCBet: s.FoldToCBetRate(),
+		WinRate:    s.WinRate(),
+		EVWinRate:  s.EVWinRate(),
+		Net:        s.Net,
+		EVNet:      s.EVNet,
+		Luck:       s.Luck(),
+	}
+}
+
+// per100 计算每 100 手牌的数值，没有手牌时为 0
+func per100(v float64, hands int) float64 {
+	if hands == 0 {
+		return 0
+	}
+	return v / float64(hands) * 100
+}
+
+// percent 计算百分比，分母为 0 时为 0
+func percent(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d) * 100
+}
+
+// hand 正在统计的一手牌
+type hand struct {
+	id        int                   // 手牌编号
+	at        time.Time             // 开始的时间
+	names     map[int]string        // 拿到底牌的座位上的玩家名称
+	stage     poker.GameStage       // 当前的阶段
+	folded    map[int]bool          // 已经弃牌的座位
+	vpip      map[int]bool          // 翻牌前已经主动入池的座位
+	pfr       map[int]bool          // 翻牌前已经加注的座位
+	faced     map[int]bool          // 翻牌前已经面对过一次加注的座位
+	raises    int                   // 翻牌前加注的次数，不包括盲注
+	aggressor int                   // 翻牌前最后加注的座位，没有时为 -1
+	flopBet   bool                  // 翻牌圈是否已经有人下注
+	cbet      bool                  // 持续下注之后还没有人加注
+	responded map[int]bool          // 已经对持续下注做出反应的座位
+	net       map[int]int           // 每个座位的输赢
+	won       map[int]int           // 每个座位从奖池中分得的筹码
+	holes     map[int][2]poker.Card // 每个座位的底牌
+	board     []poker.Card          // 已经发出的公共牌
+	decided   int                   // 最后一个动作时已经发出的公共牌数量
+	pots      []pot                 // 分配的奖池
+}
+
+// Tracker 监听牌局事件，按玩家名称累计统计，可以在多个 goroutine 中同时读取
+type Tracker struct {
+	mu       sync.RWMutex
+	players  map[string]*Stats
+	sessions map[string][]*Session // 每名玩家按时间顺序的牌局，最后一个可能还在进行
+	hands    map[*poker.Game]*hand // 每张牌桌正在统计的一手牌，多张牌桌可以同时进行
+}
+
+// NewTracker 创建统计
+func NewTracker() *Tracker {
+	return &Tracker{
+		players:  make(map[string]*Stats),
+		sessions: make(map[string][]*Session),
+		hands:    make(map[*poker.Game]*hand),
+	}
+}
+
+// Stats 获取玩家的统计，没有记录时为零值
+func (t *Tracker) Stats(name string) Stats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if s := t.players[name]; s != nil {
+		return *s
+	}
+	return Stats{}
+}
+
+// All 获取所有玩家的统计，key 为玩家名称
+func (t *Tracker) All() map[string]Stats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	all := make(map[string]Stats, len(t.players))
+	for name, s := range t.players {
+		all[name] = *s
+	}
+	return all
+}
+
+// OnEvent 按事件更新正在统计的一手牌，一手牌的计数在发生时立即记入
+func (t *Tracker) OnEvent(g *poker.Game, e poker.Event) {
+	t.record(g, e, time.Now())
+}
+
+// record 按事件更新统计，at 为事件发生的时间
+func (t *Tracker) record(g *poker.Game, e poker.Event, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if e, ok := e.(poker.HandStarted); ok {
+		t.hands[g] = &hand{
+			id:        e.HandId,
+			at:        at,
+			names:     make(map[int]string),
+			stage:     poker.GameStagePreflop,
+			folded:    make(map[int]bool),
+			vpip:      make(map[int]bool),
+			pfr:       make(map[int]bool),
+			faced:     make(map[int]bool),
+			aggressor: -1,
+			responded: make(map[int]bool),
+			net:       make(map[int]int),
+			won:       make(map[int]int),
+			holes:     make(map[int][2]poker.Card),
+		}
+		return
+	}
+	h := t.hands[g]
+	if h == nil {
+		return
+	}
+
+	switch e := e.(type) {
+	case poker.BlindPosted:
+		h.net[e.Seat] -= e.Amount
+
+	case poker.CardsDealt:
+		for _, d := range e.Hands {
+			name := g.Table.SeatAt(d.Seat).Player.Name
+			h.names[d.Seat] = name
+			h.holes[d.Seat] = d.Cards
+			t.player(name).Hands++
+		}
+
+	case poker.PlayerActed:
+		s := t.player(h.names[e.Seat])
+		h.net[e.Seat] -= e.Amount
+		h.decided = len(h.board)
+		if e.Type == poker.ActionFold {
+			h.folded[e.Seat] = true
+		}
+		if h.stage == poker.GameStagePreflop {
+			h.preflop(s, e)
+		} else {
+			h.postflop(s, e)
+		}
+
+	case poker.StreetDealt:
+		h.stage = e.Stage
+		h.board = append(h.board, e.Cards...)
+		if e.Stage == poker.GameStageFlop {
+			for seat, name := range h.names {
+				if !h.folded[seat] {
+					t.player(name).SawFlop++
+				}
+			}
+		}
+
+	case poker.BetReturned:
+		h.net[e.Seat] += e.Amount
+
+	case poker.PotAwarded:
+		p := pot{}
+		for _, w := range e.Winners {
+			h.net[w.Seat] += w.Amount
+			h.won[w.Seat] += w.Amount
+			p.amount += w.Amount
+		}
+		for _, seat := range e.Seats {
+			if _, ok := h.holes[seat]; ok && !h.folded[seat] {
+				p.seats = append(p.seats, seat)
+			}
+		}
+		h.pots = append(h.pots, p)
+
+	case poker.HandEnded:
+		t.end(g, h, e.Showdown)
+		delete(t.hands, g)
+	}
+}
+
+// end 一手牌结束时记入摊牌和输赢。
+// 全下后不再有动作而发完公共牌时，按全下时的胜率分配奖池计算期望输赢
+func (t *Tracker) end(g *poker.Game, h *hand, showdown bool) {
+	bigBlind := g.Table.MinBet * 2
+	var ev map[int]float64
+	if showdown && h.decided < len(h.board) {
+		live := make(map[int][2]poker.Card)
+		for seat, cards := range h.holes {
+			if !h.folded[seat] {
+				live[seat] = cards
+			}
+		}
+		ev = allInEV(h.board[:h.decided], live, h.pots, int64(h.id))
+	}
+
+	for seat, name := range h.names {
+		s := t.player(name)
+		if showdown && !h.folded[seat] {
+			s.Showdown++
+			if h.won[seat] > 0 {
+				s.WonShowdown++
+			}
+		}
+
+		expected := float64(h.net[seat])
+		if ev != nil {
+			expected += ev[seat] - float64(h.won[seat])
+		}
+		s.Net += h.net[seat]
+		s.EVNet += expected
+		if bigBlind > 0 {
+			s.NetBB += float64(h.net[seat]) / float64(bigBlind)
+			s.EVNetBB += expected / float64(bigBlind)
+		}
+
+		t.session(name, h.at).add(HandResult{
+			HandId:   h.id,
+			Time:     h.at,
+			Position: g.Table.Position(g.Table.SeatAt(seat)),
+			BigBlind: bigBlind,
+			AllIn:    ev != nil && !h.folded[seat],
+			Net:      h.net[seat],
+			EVNet:    expected,
+		})
+	}
+}
+
+// preflop 统计翻牌前的动作
+func (h *hand) preflop(s *Stats, e poker.PlayerActed) {
+	raise := e.Type == poker.ActionBet || e.Type == poker.ActionRaise
+	if (raise || e.Type == poker.ActionCall) && !h.vpip[e.Seat] {
+		h.vpip[e.Seat] = true
+		s.VPIP++
+	}
+	if h.raises == 1 && !h.faced[e.Seat] {
+		h.faced[e.Seat] = true
+		s.ThreeBetChances++
+		if raise {
+			s.ThreeBet++
+		}
+	}
+	if raise {
+		if !h.pfr[e.Seat] {
+			h.pfr[e.Seat] = true
+			s.PFR++
+		}
+		h.raises++
+		h.aggressor = e.Seat
+	}
+}
+
+// postflop 统计翻牌后的动作，持续下注只统计翻牌圈
+func (h *hand) postflop(s *Stats, e poker.PlayerActed) {
+	raise := e.Type == poker.ActionBet || e.Type == poker.ActionRaise
+	switch {
+	case raise:
+		s.Bets++
+	case e.Type == poker.ActionCall:
+		s.Calls++
+	}
+	if h.stage != poker.GameStageFlop {
+		return
+	}
+
+	switch {
+	case !h.flopBet:
+		if e.Seat == h.aggressor {
+			s.CBetChances++
+			if raise {
+				s.CBet++
+				h.cbet = true
+			}
+		}
+		h.flopBet = raise
+	case h.cbet && e.Seat != h.aggressor && !h.responded[e.Seat]:
+		h.responded[e.Seat] = true
+		s.FacedCBet++
+		if e.Type == poker.ActionFold {
+			s.FoldToCBet++
+		}
+		h.cbet = !raise
+	}
+}
+
+// player 获取玩家的统计，没有时创建
+func (t *Tracker) player(name string) *Stats {
+	s := t.players[name]
+	if s == nil {
+		s = &Stats{}
+		t.players[name] = s
+	}
+	return s
+}
@@ -0,0 +1,205 @@
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/lllllan02/pocker/history"
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	g := poker.NewGame()
+	tracker := NewTracker()
+	g.Listeners = append(g.Listeners, tracker)
+	var records bytes.Buffer
+	recorder := history.NewRecorder("test", io.Discard)
+	recorder.JSONWriter = &records
+	g.Observers = append(g.Observers, recorder)
+	s := g.Table.Seats
+	for i := 0; i < 3; i++ {
+		require.NoError(t, g.TakeSeat(s.Player.Id, fmt.Sprintf("p%d", i+1), 1000, true))
+		s = s.Next()
+	}
+
+	// p1 庄家加注，p2 小盲跟注，p3 大盲再加注，p1 跟注，p2 弃牌；翻牌圈 p3 持续下注，p1 弃牌
+	require.NoError(t, g.StartHand())
+	require.NoError(t, g.Raise(30))
+	require.NoError(t, g.Call())
+	require.NoError(t, g.Raise(90))
+	require.NoError(t, g.Call())
+	require.NoError(t, g.Fold())
+	require.Equal(t, poker.GameStageFlop, g.Stage)
+	require.NoError(t, g.Raise(100))
+	require.NoError(t, g.Fold())
+
+	assert.Equal(t, Stats{Hands: 1, VPIP: 1, PFR: 1, FacedCBet: 1, FoldToCBet: 1, SawFlop: 1, Net: -90, NetBB: -9, EVNet: -90, EVNetBB: -9}, tracker.Stats("p1"))
+	assert.Equal(t, Stats{Hands: 1, VPIP: 1, ThreeBetChances: 1, Net: -30, NetBB: -3, EVNet: -30, EVNetBB: -3}, tracker.Stats("p2"))
+	assert.Equal(t, Stats{Hands: 1, VPIP: 1, PFR: 1, ThreeBet: 1, ThreeBetChances: 1, CBet: 1, CBetChances: 1, Bets: 1, SawFlop: 1, Net: 120, NetBB: 12, EVNet: 120, EVNetBB: 12}, tracker.Stats("p3"))
+	assert.Equal(t, 100.0, tracker.Stats("p1").FoldToCBetRate())
+	assert.Equal(t, 100.0, tracker.Stats("p3").CBetRate())
+	assert.Equal(t, 0.0, tracker.Stats("p2").ThreeBetRate())
+
+	// 第二手牌所有人跟注后过牌到摊牌
+	require.NoError(t, g.StartHand())
+	for g.IsPlayerStage() {
+		if err := g.Check(); err != nil {
+			require.NoError(t, g.Call())
+		}
+	}
+
+	won, net := 0, 0
+	for name, st := range tracker.All() {
+		assert.Equal(t, 2, st.Hands, name)
+		assert.Equal(t, 2, st.SawFlop+boolInt(name == "p2"), name)
+		assert.Equal(t, 1, st.Showdown, name)
+		won += st.WonShowdown
+		net += st.Net
+	}
+	assert.GreaterOrEqual(t, won, 1)
+	assert.Equal(t, 0, net)
+
+	// 第三手牌翻牌前全部全下，期望输赢之和仍为 0
+	require.NoError(t, g.StartHand())
+	p := g.CurrentSeat.Player
+	require.NoError(t, g.Raise(p.Chips+g.BettingRound.Bets[p]))
+	for g.IsPlayerStage() {
+		require.NoError(t, g.Call())
+	}
+	ev := 0.0
+	for _, st := range tracker.All() {
+		ev += st.EVNet
+	}
+	assert.InDelta(t, 0, ev, 1e-6)
+
+	// 三手牌都在同一段牌局中，输赢曲线的终点与累计的统计一致
+	for name, st := range tracker.All() {
+		session, ok := tracker.Session(name)
+		require.True(t, ok)
+		require.Len(t, session.Hands, 3)
+		assert.True(t, session.Hands[2].AllIn)
+		last := session.Graph()[2]
+		assert.Equal(t, st.Net, last.Net)
+		assert.InDelta(t, st.EVNet, last.EVNet, 1e-9)
+		assert.InDelta(t, st.Luck(), last.Luck, 1e-9)
+	}
+
+	// 从手牌记录重现得到相同的统计
+	loaded := NewTracker()
+	n, err := loaded.Load(&records)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, tracker.All(), loaded.All())
+	assert.Equal(t, 0.0, tracker.Stats("nobody").VPIPRate())
+}
+
+func TestTrackerTables(t *testing.T) {
+	tracker := NewTracker()
+	games := make([]*poker.Game, 2)
+	for i := range games {
+		g := poker.NewGame()
+		g.Listeners = append(g.Listeners, tracker)
+		s := g.Table.Seats
+		for j := 0; j < 3; j++ {
+			require.NoError(t, g.TakeSeat(s.Player.Id, fmt.Sprintf("t%dp%d", i+1, j+1), 1000, true))
+			s = s.Next()
+		}
+		games[i] = g
+	}
+
+	// 两张牌桌同时进行，动作交替发生，每张牌桌的统计互不影响
+	a, b := games[0], games[1]
+	require.NoError(t, a.StartHand())
+	require.NoError(t, a.Raise(30))
+	require.NoError(t, b.StartHand())
+	require.NoError(t, b.Fold())
+	require.NoError(t, a.Call())
+	require.NoError(t, b.Raise(20))
+	require.NoError(t, a.Fold())
+	require.NoError(t, b.Fold())
+	require.Equal(t, poker.GameStageFlop, a.Stage)
+	require.NoError(t, a.Check())
+	require.NoError(t, a.Raise(40))
+	require.NoError(t, a.Fold())
+
+	assert.Equal(t, Stats{Hands: 1, VPIP: 1, PFR: 1, SawFlop: 1, CBet: 1, CBetChances: 1, Bets: 1, Net: 40, NetBB: 4, EVNet: 40, EVNetBB: 4}, tracker.Stats("t1p1"))
+	assert.Equal(t, Stats{Hands: 1, VPIP: 1, SawFlop: 1, FacedCBet: 1, FoldToCBet: 1, ThreeBetChances: 1, Net: -30, NetBB: -3, EVNet: -30, EVNetBB: -3}, tracker.Stats("t1p2"))
+	assert.Equal(t, Stats{Hands: 1, ThreeBetChances: 1, Net: -10, NetBB: -1, EVNet: -10, EVNetBB: -1}, tracker.Stats("t1p3"))
+	assert.Equal(t, Stats{Hands: 1}, tracker.Stats("t2p1"))
+	assert.Equal(t, Stats{Hands: 1, VPIP: 1, PFR: 1, Net: 10, NetBB: 1, EVNet: 10, EVNetBB: 1}, tracker.Stats("t2p2"))
+	assert.Equal(t, Stats{Hands: 1, ThreeBetChances: 1, Net: -10, NetBB: -1, EVNet: -10, EVNetBB: -1}, tracker.Stats("t2p3"))
+}
+
+func TestAllInEV(t *testing.T) {
+	card := func(r poker.CardRank, s poker.CardSuit) poker.Card { return poker.Card{Rank: r, Suit: s} }
+	board := []poker.Card{
+		card(poker.Two, poker.Clubs), card(poker.Seven, poker.Diamonds),
+		card(poker.Nine, poker.Clubs), card(poker.Queen, poker.Diamonds),
+	}
+	live := map[int][2]poker.Card{
+		0: {card(poker.Ace, poker.Spades), card(poker.Ace, poker.Hearts)},
+		1: {card(poker.King, poker.Spades), card(poker.King, poker.Hearts)},
+		2: {card(poker.Seven, poker.Spades), card(poker.Seven, poker.Hearts)},
+	}
+	pots := []pot{{amount: 300, seats: []int{0, 1, 2}}, {amount: 100, seats: []int{0, 1}}}
+
+	// 河牌还剩 42 张，AA 和 KK 各有两张牌能赢过三条 7，边池只在 AA 和 KK 之间争夺
+	ev := allInEV(board, live, pots, 1)
+	assert.InDelta(t, 300*2.0/42+100*40.0/42, ev[0], 1e-9)
+	assert.InDelta(t, 300*2.0/42+100*2.0/42, ev[1], 1e-9)
+	assert.InDelta(t, 300*38.0/42, ev[2], 1e-9)
+
+	// 从翻牌前开始全下时使用模拟，结果可以重现
+	ev = allInEV(nil, live, pots, 7)
+	assert.Equal(t, ev, allInEV(nil, live, pots, 7))
+	assert.InDelta(t, 400, ev[0]+ev[1]+ev[2], 1e-9)
+	assert.Greater(t, ev[0], ev[1])
+}
+
+func TestSessions(t *testing.T) {
+	tracker := NewTracker()
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	results := []HandResult{
+		{HandId: 1, Time: start, Net: -10, EVNet: -10},
+		{HandId: 2, Time: start.Add(time.Minute), AllIn: true, Net: -100, EVNet: 60},
+		{HandId: 3, Time: start.Add(2 * time.Minute), Net: 30, EVNet: 30},
+		{HandId: 4, Time: start.Add(2*time.Minute + SessionGap + time.Second), Net: 5, EVNet: 5},
+	}
+	for _, r := range results {
+		tracker.session("p1", r.Time).add(r)
+	}
+
+	sessions := tracker.Sessions("p1")
+	require.Len(t, sessions, 2)
+	assert.Equal(t, start, sessions[0].Start)
+	assert.Equal(t, start.Add(2*time.Minute), sessions[0].End)
+	assert.Equal(t, -80, sessions[0].Net)
+	assert.Equal(t, -160.0, sessions[0].Luck())
+	assert.Equal(t, []Point{
+		{Hand: 1, HandId: 1, Net: -10, EVNet: -10},
+		{Hand: 2, HandId: 2, Net: -110, EVNet: 50, Luck: -160, HandLuck: -160},
+		{Hand: 3, HandId: 3, Net: -80, EVNet: 80, Luck: -160},
+	}, sessions[0].Graph())
+
+	// 返回的牌局是副本
+	sessions[1].Hands[0].Net = 100
+	latest, ok := tracker.Session("p1")
+	require.True(t, ok)
+	assert.Equal(t, 5, latest.Hands[0].Net)
+
+	_, ok = tracker.Session("p2")
+	assert.False(t, ok)
+}
+
+// boolInt 将布尔值转换为 0 或 1
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package tournament
+
+import (
+	"sort"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Knockout 淘汰一名玩家获得的赏金
+type Knockout struct {
+	Name   string `json:"name"`    // 获得赏金的玩家
+	Victim string `json:"victim"`  // 被淘汰的玩家
+	Prize  int    `json:"prize"`   // 立即支付的现金赏金
+	Bounty int    `json:"bounty"`  // 渐进式赏金中加到自己身上的赏金
+	HandId int    `json:"hand_id"` // 手牌编号
+}
+
+// collectBounties 将本手牌被淘汰的玩家身上的赏金分给赢得其筹码的玩家。
+// 被淘汰的玩家输掉了开始时的全部筹码，赏金按其在主池和各个边池中的下注比例分配，
+// 同一个奖池的多名赢家按分得的筹码比例分配。渐进式赏金只立即支付一半，另一半加到赢家自己身上
+func (t *Tournament) collectBounties(tb *Table, busted []*poker.Player, result *poker.HandResult) {
+	if result == nil {
+		return
+	}
+
+	for _, victim := range busted {
+		e := t.Ledger[victim.Name]
+		if e == nil || e.Bounty == 0 {
+			continue
+		}
+
+		// 被淘汰的玩家在每一层奖池中的下注占其全部筹码的比例
+		stack := tb.stacks[victim]
+		shares := make(map[*poker.Player]float64)
+		level := 0
+		for _, pot := range result.Pots {
+			bet := min(stack, pot.MaxBet) - min(stack, level)
+			level = pot.MaxBet
+			if bet <= 0 {
+				continue
+			}
+
+			won := 0
+			for _, w := range pot.Winners {
+				if w.Player != victim {
+					won += w.ChipsWon
+				}
+			}
+			for _, w := range pot.Winners {
+				if w.Player != victim && won > 0 {
+					shares[w.Player] += float64(bet) * float64(w.ChipsWon) / float64(won)
+				}
+			}
+		}
+
+		for _, k := range splitBounty(e.Bounty, shares) {
+			winner := t.Ledger[k.Name]
+			if winner == nil {
+				continue
+			}
+			if t.config.Progressive {
+				k.Bounty = k.Prize / 2
+				k.Prize -= k.Bounty
+			}
+			k.Victim, k.HandId = victim.Name, tb.Game.HandId
+			winner.Bounties += k.Prize
+			winner.Bounty += k.Bounty
+			t.Knockouts = append(t.Knockouts, k)
+		}
+		e.Bounty = 0
+	}
+}
+
+// collectOwnBounty 比赛结束时，剩余的玩家取回自己身上的赏金
+func (t *Tournament) collectOwnBounty(name string) {
+	if e := t.Ledger[name]; e != nil {
+		e.Bounties += e.Bounty
+		e.Bounty = 0
+	}
+}
+
+// splitBounty 按比例拆分赏金，份额最多的玩家在前，取整后剩余的部分归第一名玩家
+func splitBounty(bounty int, shares map[*poker.Player]float64) []Knockout {
+	players := make([]*poker.Player, 0, len(shares))
+	total := 0.0
+	for p, share := range shares {
+		players = append(players, p)
+		total += share
+	}
+	if total == 0 {
+		return nil
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if shares[players[i]] != shares[players[j]] {
+			return shares[players[i]] > shares[players[j]]
+		}
+		return players[i].Name < players[j].Name
+	})
+
+	knockouts := make([]Knockout, len(players))
+	paid := 0
+	for i, p := range players {
+		knockouts[i] = Knockout{Name: p.Name, Prize: int(float64(bounty) * shares[p] / total)}
+		paid += knockouts[i].Prize
+	}
+	knockouts[0].Prize += bounty - paid
+	return knockouts
+}
@@ -0,0 +1,39 @@
+package tournament
+
+import (
+	"testing"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTournamentProgressiveBounties(t *testing.T) {
+	tour, err := New(Config{StartingStack: 100, BuyIn: 10, Bounty: 100, Progressive: true}, []string{"a", "b", "c", "d"})
+	require.NoError(t, err)
+	tb := tour.Tables[0]
+	_, a := tour.Player("a")
+	_, b := tour.Player("b")
+	_, c := tour.Player("c")
+	a.Chips, b.Chips, c.Chips = 100, 300, 1000
+
+	// b 全下后输给了两个人：a 赢得主池，c 赢得边池，
+	// b 在主池中下注 100、在边池中下注 200，赏金按 1:2 分配
+	tb.OnHandStart(tb.Game)
+	a.Chips, b.Chips, c.Chips = 300, 0, 1100
+	tb.OnHandEnd(tb.Game, &poker.HandResult{Pots: []poker.PotResult{
+		{SidePot: poker.SidePot{MaxBet: 100}, Winners: []poker.PlayerHand{{Player: a, ChipsWon: 300}}},
+		{SidePot: poker.SidePot{MaxBet: 300}, Winners: []poker.PlayerHand{{Player: c, ChipsWon: 400}}},
+	}})
+
+	// 渐进式赏金只立即支付一半，另一半加到淘汰者自己身上
+	assert.Equal(t, []Knockout{
+		{Name: "c", Victim: "b", Prize: 34, Bounty: 33, HandId: tb.Game.HandId},
+		{Name: "a", Victim: "b", Prize: 17, Bounty: 16, HandId: tb.Game.HandId},
+	}, tour.Knockouts)
+	assert.Equal(t, 0, tour.Ledger["b"].Bounty)
+	assert.Equal(t, 116, tour.Ledger["a"].Bounty)
+	assert.Equal(t, 17, tour.Ledger["a"].Bounties)
+	assert.Equal(t, 133, tour.Ledger["c"].Bounty)
+	assert.Equal(t, 34, tour.Ledger["c"].Bounties)
+}
@@ -0,0 +1,163 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrNoDeal         = errors.New("there is no deal on the table")             // 没有正在表决的协议
+	ErrDealPending    = errors.New("another deal is already being voted on")    // 已有正在表决的协议
+	ErrHandInProgress = errors.New("a deal can only be made between hands")     // 只能在手牌之间协议分奖金
+	ErrDealRejected   = errors.New("the deal has been rejected")                // 有玩家拒绝了协议
+	ErrNotInDeal      = errors.New("the player is not taking part in the deal") // 玩家不在协议之中
+)
+
+// DealKind 协议分奖金的方式
+type DealKind string
+
+const (
+	DealChipChop DealKind = "chip_chop" // 按筹码比例分配：每人先得到剩余的最低奖金，其余奖金按筹码比例分配
+	DealICM      DealKind = "icm"       // 按独立筹码模型计算的奖金期望分配
+)
+
+// DealShare 协议中一名玩家的份额
+type DealShare struct {
+	Name     string  `json:"name"`     // 玩家名称
+	Chips    int     `json:"chips"`    // 提出协议时的筹码
+	Equity   float64 `json:"equity"`   // 按所选方式计算的奖金期望
+	Prize    int     `json:"prize"`    // 取整后的奖金
+	Accepted bool    `json:"accepted"` // 是否已经同意
+}
+
+// Deal 剩余玩家协议分配剩余奖金。
+// 所有玩家同意后比赛结束，按筹码从多到少确定名次并记录协议的奖金
+type Deal struct {
+	Kind   DealKind    `json:"kind"`   // 分配方式
+	Shares []DealShare `json:"shares"` // 各名玩家的份额，按筹码从多到少排列
+}
+
+// Accepted 是否所有玩家都已同意
+func (d *Deal) Accepted() bool {
+	for _, s := range d.Shares {
+		if !s.Accepted {
+			return false
+		}
+	}
+	return true
+}
+
+// ProposeDeal 为所有剩余玩家提出协议，只能在所有牌桌的手牌之间提出
+func (t *Tournament) ProposeDeal(kind DealKind) (*Deal, error) {
+	if t.Done() {
+		return nil, ErrFinished
+	}
+	if t.Deal != nil {
+		return nil, ErrDealPending
+	}
+	for _, tb := range t.Tables {
+		if tb.Game.IsPlayerStage() {
+			return nil, ErrHandInProgress
+		}
+	}
+
+	shares := make([]DealShare, 0)
+	for _, tb := range t.Tables {
+		for _, p := range tb.players() {
+			shares = append(shares, DealShare{Name: p.Name, Chips: p.Chips})
+		}
+	}
+	sort.SliceStable(shares, func(i, j int) bool {
+		if shares[i].Chips != shares[j].Chips {
+			return shares[i].Chips > shares[j].Chips
+		}
+		return shares[i].Name < shares[j].Name
+	})
+
+	// 剩余玩家争夺第一名到第 n 名的奖金
+	prizes := make([]int, len(shares))
+	copy(prizes, t.Payouts)
+
+	stacks := make([]int, len(shares))
+	for i, s := range shares {
+		stacks[i] = s.Chips
+	}
+
+	var equity []float64
+	switch kind {
+	case DealChipChop:
+		equity = chipChop(stacks, prizes)
+	case DealICM:
+		var err error
+		if equity, err = ICM(stacks, prizes); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown deal kind %q", kind)
+	}
+
+	// 取整后剩余的奖金归筹码最多的玩家
+	paid := 0
+	for i := range shares {
+		shares[i].Equity = equity[i]
+		shares[i].Prize = int(equity[i])
+		paid += shares[i].Prize
+	}
+	shares[0].Prize += sum(prizes) - paid
+
+	t.Deal = &Deal{Kind: kind, Shares: shares}
+	return t.Deal, nil
+}
+
+// Vote 记录玩家对协议的表决。
+// 任何玩家拒绝时协议作废并返回 ErrDealRejected，所有玩家同意时按协议结束比赛
+func (t *Tournament) Vote(name string, accept bool) error {
+	d := t.Deal
+	if d == nil {
+		return ErrNoDeal
+	}
+
+	i := 0
+	for i < len(d.Shares) && d.Shares[i].Name != name {
+		i++
+	}
+	if i == len(d.Shares) {
+		return ErrNotInDeal
+	}
+
+	if !accept {
+		t.Deal = nil
+		return ErrDealRejected
+	}
+	d.Shares[i].Accepted = true
+	if !d.Accepted() {
+		return nil
+	}
+
+	// 按筹码从少到多依次记录名次，每名玩家取回自己身上的赏金
+	for i := len(d.Shares) - 1; i >= 0; i-- {
+		s := d.Shares[i]
+		t.collectOwnBounty(s.Name)
+		r := Result{Name: s.Name, Position: i + 1, Prize: s.Prize}
+		if tb, _ := t.Player(s.Name); tb != nil {
+			r.Table, r.HandId = tb.Id, tb.Game.HandId
+		}
+		t.Results = append(t.Results, r)
+	}
+	t.Deal = nil
+	return nil
+}
+
+// chipChop 按筹码比例计算奖金：每人先得到剩余的最低奖金，其余奖金按筹码比例分配
+func chipChop(stacks []int, prizes []int) []float64 {
+	lowest := prizes[len(prizes)-1]
+	extra := float64(sum(prizes) - lowest*len(prizes))
+	total := float64(sum(stacks))
+
+	equity := make([]float64, len(stacks))
+	for i, s := range stacks {
+		equity[i] = float64(lowest) + extra*float64(s)/total
+	}
+	return equity
+}
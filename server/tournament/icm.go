@@ -0,0 +1,133 @@
+package tournament
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ICM 计算设置
+const (
+	maxICMStates = 1 << 20 // 精确计算时最多保存的状态数，超过时改用蒙特卡洛模拟
+	icmTrials    = 200000  // 蒙特卡洛模拟的次数
+)
+
+// ICM 按独立筹码模型（Malmuth-Harville）计算每名玩家的奖金期望，顺序与 stacks 相同。
+// 玩家获得每个名次的概率与其在剩余玩家中的筹码占比成正比。
+// 按已经占据前几名的玩家集合记忆概率，状态数过多时改用固定种子的蒙特卡洛模拟近似计算
+func ICM(stacks []int, payouts []int) ([]float64, error) {
+	if err := validateICM(stacks, payouts); err != nil {
+		return nil, err
+	}
+
+	paid := min(len(payouts), len(stacks))
+	if len(stacks) <= 64 && icmStates(len(stacks), paid) <= maxICMStates {
+		return icmExact(stacks, payouts[:paid]), nil
+	}
+	return ICMApprox(stacks, payouts, icmTrials, rand.New(rand.NewSource(1)))
+}
+
+// ICMApprox 用蒙特卡洛模拟近似计算独立筹码模型下每名玩家的奖金期望。
+// 每次模拟按筹码占比依次抽取第一名、第二名……直到所有有奖金的名次都已确定
+func ICMApprox(stacks []int, payouts []int, trials int, r *rand.Rand) ([]float64, error) {
+	if err := validateICM(stacks, payouts); err != nil {
+		return nil, err
+	}
+	if trials <= 0 {
+		return nil, fmt.Errorf("the number of trials must be positive, got %d", trials)
+	}
+
+	total := sum(stacks)
+	paid := min(len(payouts), len(stacks))
+	equity := make([]float64, len(stacks))
+	left := make([]bool, len(stacks))
+	for n := 0; n < trials; n++ {
+		for i := range left {
+			left[i] = true
+		}
+
+		chips := total
+		for place := 0; place < paid; place++ {
+			x := r.Intn(chips)
+			for i, s := range stacks {
+				if !left[i] {
+					continue
+				}
+				if x < s {
+					equity[i] += float64(payouts[place])
+					left[i] = false
+					chips -= s
+					break
+				}
+				x -= s
+			}
+		}
+	}
+
+	for i := range equity {
+		equity[i] /= float64(trials)
+	}
+	return equity, nil
+}
+
+// icmExact 精确计算奖金期望。
+// 逐个名次展开，probs 记录每个已占据前几名的玩家集合出现的概率，集合相同的不同顺序合并为同一个状态
+func icmExact(stacks []int, payouts []int) []float64 {
+	total := sum(stacks)
+	equity := make([]float64, len(stacks))
+	probs := map[uint64]float64{0: 1}
+	for place, prize := range payouts {
+		next := make(map[uint64]float64, len(probs)*(len(stacks)-place))
+		for mask, prob := range probs {
+			chips := total
+			for i, s := range stacks {
+				if mask&(1<<i) != 0 {
+					chips -= s
+				}
+			}
+
+			for i, s := range stacks {
+				if mask&(1<<i) != 0 {
+					continue
+				}
+				p := prob * float64(s) / float64(chips)
+				equity[i] += p * float64(prize)
+				if place < len(payouts)-1 {
+					next[mask|1<<i] += p
+				}
+			}
+		}
+		probs = next
+	}
+	return equity
+}
+
+// icmStates 估算精确计算需要的状态数，即不超过 paid-1 名玩家的集合数
+func icmStates(players, paid int) int {
+	states, c := 0, 1
+	for k := 0; k < paid; k++ {
+		states += c
+		if states > maxICMStates {
+			return states
+		}
+		c = c * (players - k) / (k + 1)
+	}
+	return states
+}
+
+// validateICM 检查筹码和奖金是否合法
+func validateICM(stacks []int, payouts []int) error {
+	if len(stacks) == 0 {
+		return fmt.Errorf("there are no stacks")
+	}
+	for i, s := range stacks {
+		if s <= 0 {
+			return fmt.Errorf("stack %d must be positive, got %d", i+1, s)
+		}
+	}
+	for i, p := range payouts {
+		if p < 0 {
+			return fmt.Errorf("the payout for place %d cannot be negative, got %d", i+1, p)
+		}
+	}
+	return nil
+}
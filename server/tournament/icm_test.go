@@ -0,0 +1,62 @@
+package tournament
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICM(t *testing.T) {
+	// 筹码相同时平分奖金
+	equity, err := ICM([]int{100, 100}, []int{70, 30})
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{50, 50}, equity, 1e-9)
+
+	// 第一名的概率为 50/100，第二名的概率按剩余筹码计算
+	equity, err = ICM([]int{50, 30, 20}, []int{50, 30, 20})
+	require.NoError(t, err)
+	second := 30.0/100*50.0/70 + 20.0/100*50.0/80
+	assert.InDelta(t, 0.5*50+second*30+(1-0.5-second)*20, equity[0], 1e-9)
+	assert.InDelta(t, 100, equity[0]+equity[1]+equity[2], 1e-9)
+
+	// 名次多于玩家时只分配前几名的奖金
+	equity, err = ICM([]int{300, 100}, []int{60, 30, 10})
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{52.5, 37.5}, equity, 1e-9)
+
+	_, err = ICM([]int{100, 0}, []int{100})
+	assert.Error(t, err)
+}
+
+func TestICMManyPlayers(t *testing.T) {
+	stacks := []int{9000, 7500, 6000, 5200, 4100, 3300, 2700, 2000, 1500, 1000, 700, 500}
+	payouts := []int{300, 200, 140, 100, 80, 60, 50, 40, 30}
+	equity, err := ICM(stacks, payouts)
+	require.NoError(t, err)
+
+	// 奖金期望之和等于奖金总额，筹码越多期望越高
+	total := 0.0
+	for i, e := range equity {
+		total += e
+		if i > 0 {
+			assert.Less(t, e, equity[i-1])
+		}
+	}
+	assert.InDelta(t, 1000, total, 1e-6)
+
+	// 精确计算与蒙特卡洛模拟的结果相近
+	approx, err := ICMApprox(stacks, payouts, 100000, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, equity, approx, 2)
+
+	// 状态数过多时也能很快得到结果
+	many := make([]int, 60)
+	for i := range many {
+		many[i] = 1000 + i*100
+	}
+	equity, err = ICM(many, payouts)
+	require.NoError(t, err)
+	assert.Less(t, equity[0], equity[59])
+}
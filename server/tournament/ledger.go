@@ -0,0 +1,233 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+var (
+	ErrRebuyClosed        = errors.New("the rebuy period is over")                      // 重购时间已过
+	ErrAddOnClosed        = errors.New("add-ons are only available at the first break") // 只能在第一次休息时加购
+	ErrRegistrationClosed = errors.New("late registration is closed")                   // 迟到报名时间已过
+	ErrNotRegistered      = errors.New("the player has not registered")                 // 玩家没有报名
+	ErrNotSeated          = errors.New("the player is not seated at any table")         // 玩家不在任何牌桌上
+)
+
+// Entry 一名参赛者的买入记录。
+// poker.Player 只记录当前的筹码，比赛需要自己记录每名参赛者支付的费用和获得的筹码
+type Entry struct {
+	Name      string `json:"name"`       // 玩家名称
+	ReEntries int    `json:"re_entries"` // 重新参赛的次数
+	Rebuys    int    `json:"rebuys"`     // 重购的次数
+	AddOns    int    `json:"add_ons"`    // 加购的次数
+	Paid      int    `json:"paid"`       // 支付的总费用，全部计入奖池，不包括赏金
+	Chips     int    `json:"chips"`      // 获得的总筹码
+	Bounty    int    `json:"bounty"`     // 当前身上的赏金
+	Bounties  int    `json:"bounties"`   // 获得的现金赏金
+}
+
+// Register 迟到报名，玩家带着起始筹码坐到人数最少的牌桌上
+func (t *Tournament) Register(name string) error {
+	if t.Done() {
+		return ErrFinished
+	}
+	if t.Deal != nil {
+		return ErrDealPending
+	}
+	if _, ok := t.Ledger[name]; ok {
+		return fmt.Errorf("duplicate player name %q", name)
+	}
+	t.advanceLevel()
+	if t.Level >= t.config.LateRegLevels {
+		return ErrRegistrationClosed
+	}
+
+	if err := t.seatNew(name, t.config.StartingStack); err != nil {
+		return err
+	}
+	t.Ledger[name] = &Entry{Name: name, Paid: t.config.BuyIn, Chips: t.config.StartingStack, Bounty: t.config.Bounty}
+	t.entrants++
+	t.PrizePool += t.config.BuyIn
+	return t.buyIn()
+}
+
+// ReEnter 被淘汰的玩家在迟到报名时间内重新参赛，支付买入后带着起始筹码重新入座
+func (t *Tournament) ReEnter(name string) error {
+	e, ok := t.Ledger[name]
+	if !ok {
+		return ErrNotRegistered
+	}
+	if t.Done() {
+		return ErrFinished
+	}
+	if t.Deal != nil {
+		return ErrDealPending
+	}
+	if _, p := t.Player(name); p != nil {
+		return fmt.Errorf("%s has not been eliminated", name)
+	}
+	t.advanceLevel()
+	if t.Level >= t.config.LateRegLevels {
+		return ErrRegistrationClosed
+	}
+	if e.ReEntries >= t.config.ReEntries {
+		return fmt.Errorf("%s has already re-entered %d times", name, e.ReEntries)
+	}
+
+	// 仍坐在原座位上的玩家先离座
+	if tb, p := t.seated(name); p != nil {
+		if err := tb.Game.LeaveSeat(p.Id); err != nil {
+			return err
+		}
+	}
+	if err := t.seatNew(name, t.config.StartingStack); err != nil {
+		return err
+	}
+	e.ReEntries++
+	e.Bounty = t.config.Bounty
+	e.Paid += t.config.BuyIn
+	e.Chips += t.config.StartingStack
+	t.PrizePool += t.config.BuyIn
+	t.revive(name)
+	return t.buyIn()
+}
+
+// Rebuy 在重购时间内，筹码不超过重购门槛的玩家购买筹码，并撤销输光筹码的玩家的淘汰记录。
+// 输光筹码后已经离座的玩家与重新参赛一样坐到人数最少的牌桌上
+func (t *Tournament) Rebuy(name string) error {
+	if t.Done() {
+		return ErrFinished
+	}
+	e, ok := t.Ledger[name]
+	if !ok {
+		return ErrNotRegistered
+	}
+	if t.Deal != nil {
+		return ErrDealPending
+	}
+	t.advanceLevel()
+	if t.Level >= t.config.RebuyLevels {
+		return ErrRebuyClosed
+	}
+
+	tb, p := t.seated(name)
+	busted := p == nil || p.Chips == 0
+	switch {
+	case p == nil:
+		if err := t.seatNew(name, t.config.RebuyChips); err != nil {
+			return err
+		}
+	case p.Chips > t.config.RebuyThreshold:
+		return fmt.Errorf("%s has %d chips, more than the rebuy threshold of %d", name, p.Chips, t.config.RebuyThreshold)
+	default:
+		if err := tb.Game.AddChips(p.Id, t.config.RebuyChips); err != nil {
+			return err
+		}
+	}
+	// 输光筹码后重购时重新放上赏金
+	if busted {
+		e.Bounty = t.config.Bounty
+	}
+	e.Rebuys++
+	e.Paid += t.config.RebuyCost
+	e.Chips += t.config.RebuyChips
+	t.PrizePool += t.config.RebuyCost
+	t.revive(name)
+	return t.buyIn()
+}
+
+// AddOn 在第一次休息时，仍持有筹码的玩家可以加购一次
+func (t *Tournament) AddOn(name string) error {
+	if t.Done() {
+		return ErrFinished
+	}
+	e, ok := t.Ledger[name]
+	if !ok {
+		return ErrNotRegistered
+	}
+	if t.Deal != nil {
+		return ErrDealPending
+	}
+	tb, p := t.Player(name)
+	if p == nil {
+		return ErrNotSeated
+	}
+	t.advanceLevel()
+	if t.config.AddOnChips == 0 || !t.CurrentLevel().Break || t.Level != t.firstBreak() {
+		return ErrAddOnClosed
+	}
+	if e.AddOns > 0 {
+		return fmt.Errorf("%s has already taken the add-on", name)
+	}
+
+	if err := tb.Game.AddChips(p.Id, t.config.AddOnChips); err != nil {
+		return err
+	}
+	e.AddOns++
+	e.Paid += t.config.AddOnCost
+	e.Chips += t.config.AddOnChips
+	t.PrizePool += t.config.AddOnCost
+	return t.buyIn()
+}
+
+// buyIn 奖池或参赛人数变化后重新计算奖金和已淘汰玩家的名次
+func (t *Tournament) buyIn() error {
+	if err := t.updatePayouts(); err != nil {
+		return err
+	}
+	t.renumber()
+	return nil
+}
+
+// seatNew 让迟到报名、重新参赛或离座后重购的玩家带着筹码坐到人数最少的牌桌上，所有牌桌都坐满时新开一张牌桌
+func (t *Tournament) seatNew(name string, chips int) error {
+	tb := t.smallestTable(nil)
+	if tb == nil {
+		tb = t.openTable()
+		for _, l := range t.Listeners {
+			l.OnTableOpened(tb)
+		}
+	}
+
+	seat := tb.freeSeat()
+	if seat.Player.Status != poker.PlayerVacated {
+		if err := tb.Game.LeaveSeat(seat.Player.Id); err != nil {
+			return err
+		}
+	}
+	return tb.Game.TakeSeat(seat.Player.Id, name, chips, true)
+}
+
+// seated 获取玩家所在的牌桌和座位上的玩家，包括刚输光筹码、尚未离座的玩家
+func (t *Tournament) seated(name string) (*Table, *poker.Player) {
+	for _, tb := range t.Tables {
+		for _, p := range tb.Game.Table.Seats.GetActivePlayers() {
+			if p.Name == name {
+				return tb, p
+			}
+		}
+	}
+	return nil, nil
+}
+
+// revive 撤销重购或重新参赛的玩家的淘汰记录
+func (t *Tournament) revive(name string) {
+	for i, r := range t.Results {
+		if r.Name == name {
+			t.Results = append(t.Results[:i], t.Results[i+1:]...)
+			return
+		}
+	}
+}
+
+// firstBreak 获取盲注结构中第一次休息的位置，没有休息时返回 -1
+func (t *Tournament) firstBreak() int {
+	for i, l := range t.Schedule {
+		if l.Break {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,152 @@
+package tournament
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTournamentLedger(t *testing.T) {
+	now := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	tour, err := New(Config{
+		StartingStack: 100,
+		BuyIn:         10,
+		Schedule: Schedule{
+			{SmallBlind: 5, Duration: 10 * time.Minute},
+			{Break: true, Duration: 5 * time.Minute},
+			{SmallBlind: 10, Duration: 10 * time.Minute},
+		},
+		TableSize:      3,
+		RebuyLevels:    1,
+		RebuyThreshold: 50,
+		AddOnChips:     200,
+		AddOnCost:      20,
+		ReEntries:      1,
+		LateRegLevels:  1,
+	}, []string{"p1", "p2", "p3"})
+	require.NoError(t, err)
+	tour.Now = func() time.Time { return now }
+	tour.Start()
+
+	// 重购需要筹码不超过门槛
+	assert.Error(t, tour.Rebuy("p1"))
+	_, p1 := tour.Player("p1")
+	tb, p2 := tour.Player("p2")
+	_, p3 := tour.Player("p3")
+	p1.Chips, p3.Chips = 50, 150
+	require.NoError(t, tour.Rebuy("p1"))
+	assert.Equal(t, 150, p1.Chips)
+	assert.Equal(t, 40, tour.PrizePool)
+
+	// p2 被淘汰，之后报名的玩家排在 p2 前面
+	tb.OnHandStart(tb.Game)
+	p2.Chips, p3.Chips = 0, 250
+	tb.OnHandEnd(tb.Game, nil)
+	assert.Equal(t, []Result{{Name: "p2", Table: 1, Position: 3, Prize: 0}}, tour.Results)
+
+	// 迟到报名的玩家坐到被淘汰玩家的座位上
+	require.NoError(t, tour.Register("p4"))
+	assert.Error(t, tour.Register("p4"))
+	require.Len(t, tour.Tables, 1)
+	assert.Equal(t, 4, tour.Results[0].Position)
+	assert.Equal(t, []int{50}, tour.Payouts)
+
+	// p2 重新参赛后撤销淘汰记录，牌桌已满时新开一张牌桌
+	require.NoError(t, tour.ReEnter("p2"))
+	assert.Empty(t, tour.Results)
+	assert.Len(t, tour.Tables, 2)
+	assert.Equal(t, 60, tour.PrizePool)
+	_, p2 = tour.Player("p2")
+	require.NotNil(t, p2)
+	assert.Equal(t, 100, p2.Chips)
+
+	// 第一个级别结束后不能再报名或重购，休息时每人可以加购一次
+	now = now.Add(10 * time.Minute)
+	assert.ErrorIs(t, tour.Register("p5"), ErrRegistrationClosed)
+	assert.ErrorIs(t, tour.Rebuy("p2"), ErrRebuyClosed)
+	require.NoError(t, tour.AddOn("p2"))
+	assert.Error(t, tour.AddOn("p2"))
+	assert.Equal(t, 300, p2.Chips)
+	assert.Equal(t, 80, tour.PrizePool)
+
+	now = now.Add(5 * time.Minute)
+	assert.ErrorIs(t, tour.AddOn("p3"), ErrAddOnClosed)
+
+	// 账目中的筹码与牌桌上的筹码一致，费用之和等于奖池
+	chips, paid := 0, 0
+	for _, e := range tour.Ledger {
+		chips += e.Chips
+		paid += e.Paid
+	}
+	assert.Equal(t, tour.PrizePool, paid)
+	total := 0
+	for _, tb := range tour.Tables {
+		for _, p := range tb.players() {
+			total += p.Chips
+		}
+	}
+	assert.Equal(t, chips, total)
+	assert.Equal(t, Entry{Name: "p2", ReEntries: 1, AddOns: 1, Paid: 40, Chips: 400}, *tour.Ledger["p2"])
+}
+
+func TestTournamentRebuyAfterNextHand(t *testing.T) {
+	tour, err := New(Config{
+		StartingStack: 100,
+		BuyIn:         10,
+		Schedule:      Schedule{{SmallBlind: 5, Hands: 10}, {SmallBlind: 10}},
+		TableSize:     3,
+		RebuyLevels:   1,
+		RebuyChips:    80,
+	}, []string{"p1", "p2", "p3"})
+	require.NoError(t, err)
+	tb, p2 := tour.Player("p2")
+	_, p3 := tour.Player("p3")
+
+	// p2 被淘汰，下一手牌开始时离座
+	tb.OnHandStart(tb.Game)
+	p2.Chips, p3.Chips = 0, 200
+	tb.OnHandEnd(tb.Game, nil)
+	require.Len(t, tour.Results, 1)
+	require.NoError(t, tour.StartHand(tb.Id))
+	_, p := tour.seated("p2")
+	require.Nil(t, p)
+
+	// 重购时间内仍可重购，重新入座并撤销淘汰记录
+	require.NoError(t, tour.Rebuy("p2"))
+	_, p2 = tour.Player("p2")
+	require.NotNil(t, p2)
+	assert.Equal(t, 80, p2.Chips)
+	assert.Empty(t, tour.Results)
+	assert.Equal(t, 40, tour.PrizePool)
+	assert.Equal(t, Entry{Name: "p2", Rebuys: 1, Paid: 20, Chips: 180}, *tour.Ledger["p2"])
+}
+
+func TestTournamentRebuyAfterFinish(t *testing.T) {
+	tour, err := New(Config{
+		StartingStack: 100,
+		BuyIn:         10,
+		Schedule: Schedule{
+			{SmallBlind: 5, Hands: 10},
+			{Break: true, Duration: 5 * time.Minute},
+			{SmallBlind: 10},
+		},
+		RebuyLevels: 2,
+		AddOnChips:  100,
+		AddOnCost:   10,
+	}, []string{"p1", "p2"})
+	require.NoError(t, err)
+	tb, p1 := tour.Player("p1")
+	_, p2 := tour.Player("p2")
+
+	// p2 被淘汰后比赛结束，不能再重购或加购
+	tb.OnHandStart(tb.Game)
+	p1.Chips, p2.Chips = 200, 0
+	tb.OnHandEnd(tb.Game, nil)
+	require.True(t, tour.Done())
+	assert.ErrorIs(t, tour.Rebuy("p2"), ErrFinished)
+	assert.ErrorIs(t, tour.AddOn("p1"), ErrFinished)
+	assert.Equal(t, 20, tour.PrizePool)
+	assert.Len(t, tour.Results, 2)
+}
@@ -0,0 +1,121 @@
+package tournament
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Level 盲注级别。
+// 级别按时间或手牌数结束，两者都设置时先达到的为准，两者都为 0 时一直持续下去
+type Level struct {
+	SmallBlind int           `json:"small_blind"` // 小盲注，大盲注为小盲注的两倍
+	Ante       int           `json:"ante"`        // 前注
+	Duration   time.Duration `json:"duration"`    // 级别持续的时间
+	Hands      int           `json:"hands"`       // 级别持续的手牌数
+	Break      bool          `json:"break"`       // 是否为休息时间，休息只能按时间结束
+}
+
+// String 返回级别的简短描述
+func (l Level) String() string {
+	if l.Break {
+		return fmt.Sprintf("break (%s)", l.Duration)
+	}
+	if l.Ante > 0 {
+		return fmt.Sprintf("%d/%d ante %d", l.SmallBlind, l.SmallBlind*2, l.Ante)
+	}
+	return fmt.Sprintf("%d/%d", l.SmallBlind, l.SmallBlind*2)
+}
+
+// levelJSON 级别的 JSON 格式，时长写成 "10m" 这样便于手工编辑的字符串
+type levelJSON struct {
+	SmallBlind int    `json:"small_blind,omitempty"`
+	Ante       int    `json:"ante,omitempty"`
+	Duration   string `json:"duration,omitempty"`
+	Hands      int    `json:"hands,omitempty"`
+	Break      bool   `json:"break,omitempty"`
+}
+
+// MarshalJSON 将级别序列化为 JSON，时长使用字符串表示
+func (l Level) MarshalJSON() ([]byte, error) {
+	v := levelJSON{SmallBlind: l.SmallBlind, Ante: l.Ante, Hands: l.Hands, Break: l.Break}
+	if l.Duration > 0 {
+		v.Duration = l.Duration.String()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON 从 JSON 中反序列化级别
+func (l *Level) UnmarshalJSON(b []byte) error {
+	var v levelJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	var d time.Duration
+	if v.Duration != "" {
+		var err error
+		if d, err = time.ParseDuration(v.Duration); err != nil {
+			return err
+		}
+	}
+	*l = Level{SmallBlind: v.SmallBlind, Ante: v.Ante, Duration: d, Hands: v.Hands, Break: v.Break}
+	return nil
+}
+
+// Schedule 盲注结构，按顺序排列的级别，最后一个级别会一直持续到比赛结束
+type Schedule []Level
+
+// DefaultSchedule 每周坐满即玩比赛使用的盲注结构，每个级别十分钟，每小时休息五分钟
+var DefaultSchedule = Schedule{
+	{SmallBlind: 10, Duration: 10 * time.Minute},
+	{SmallBlind: 15, Duration: 10 * time.Minute},
+	{SmallBlind: 25, Duration: 10 * time.Minute},
+	{SmallBlind: 50, Duration: 10 * time.Minute},
+	{SmallBlind: 75, Ante: 10, Duration: 10 * time.Minute},
+	{SmallBlind: 100, Ante: 15, Duration: 10 * time.Minute},
+	{Break: true, Duration: 5 * time.Minute},
+	{SmallBlind: 150, Ante: 20, Duration: 10 * time.Minute},
+	{SmallBlind: 200, Ante: 25, Duration: 10 * time.Minute},
+	{SmallBlind: 300, Ante: 40, Duration: 10 * time.Minute},
+	{SmallBlind: 400, Ante: 50, Duration: 10 * time.Minute},
+	{SmallBlind: 600, Ante: 75, Duration: 10 * time.Minute},
+	{SmallBlind: 800, Ante: 100, Duration: 10 * time.Minute},
+	{Break: true, Duration: 5 * time.Minute},
+	{SmallBlind: 1000, Ante: 150, Duration: 10 * time.Minute},
+}
+
+// Validate 检查盲注结构是否合法
+func (s Schedule) Validate() error {
+	if len(s) == 0 {
+		return fmt.Errorf("the schedule has no levels")
+	}
+
+	blinds := 0
+	for i, l := range s {
+		switch {
+		case l.Duration < 0 || l.Hands < 0:
+			return fmt.Errorf("level %d: the duration cannot be negative", i+1)
+		case l.Break && l.Duration == 0:
+			return fmt.Errorf("level %d: a break must have a duration", i+1)
+		case l.Break && i == len(s)-1:
+			return fmt.Errorf("level %d: the schedule cannot end with a break", i+1)
+		case l.Break:
+			continue
+		case l.SmallBlind <= 0:
+			return fmt.Errorf("level %d: the small blind must be positive, got %d", i+1, l.SmallBlind)
+		case l.Ante < 0:
+			return fmt.Errorf("level %d: the ante cannot be negative, got %d", i+1, l.Ante)
+		}
+		blinds++
+	}
+	if blinds == 0 {
+		return fmt.Errorf("the schedule has no blind levels")
+	}
+	return nil
+}
+
+// expired 级别是否已经结束
+func (l Level) expired(elapsed time.Duration, hands int) bool {
+	return (l.Duration > 0 && elapsed >= l.Duration) || (!l.Break && l.Hands > 0 && hands >= l.Hands)
+}
@@ -0,0 +1,51 @@
+package tournament
+
+import "fmt"
+
+// payoutStructures 按参赛人数划分的奖金比例，第一名在前，单位为百分比
+var payoutStructures = []struct {
+	entrants int   // 适用的最大参赛人数
+	percents []int // 各名次的奖金比例
+}{
+	{4, []int{100}},
+	{6, []int{65, 35}},
+	{10, []int{50, 30, 20}},
+	{20, []int{40, 25, 18, 10, 7}},
+	{0, []int{30, 20, 14, 10, 8, 6, 5, 4, 3}},
+}
+
+// PayoutPercents 获取参赛人数对应的奖金比例，第一名在前
+func PayoutPercents(entrants int) []int {
+	for _, s := range payoutStructures {
+		if entrants <= s.entrants {
+			return s.percents
+		}
+	}
+	return payoutStructures[len(payoutStructures)-1].percents
+}
+
+// PayoutTable 按比例将奖池分配给各名次，第一名在前。
+// 按比例取整后剩余的筹码归第一名
+func PayoutTable(prizePool int, percents []int) ([]int, error) {
+	total := 0
+	for _, p := range percents {
+		if p < 0 {
+			return nil, fmt.Errorf("payout percents cannot be negative, got %d", p)
+		}
+		total += p
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("payout percents must add up to 100, got %d", total)
+	}
+
+	payouts := make([]int, len(percents))
+	paid := 0
+	for i, p := range percents {
+		payouts[i] = prizePool * p / 100
+		paid += payouts[i]
+	}
+	if len(payouts) > 0 {
+		payouts[0] += prizePool - paid
+	}
+	return payouts, nil
+}
@@ -0,0 +1,137 @@
+package tournament
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// StructureOptions 生成盲注结构的参数
+type StructureOptions struct {
+	StartingStack int           // 每名玩家的起始筹码
+	Players       int           // 参赛人数
+	Duration      time.Duration // 比赛的目标时长，不包括休息时间
+	LevelLength   time.Duration // 每个级别的时长
+	AnteLevel     int           // 从第几个级别开始收取前注，为 0 时从三分之一处开始，为负数时不收前注
+	BreakEvery    int           // 每隔几个级别休息一次，为 0 时不休息
+	BreakLength   time.Duration // 每次休息的时长，为 0 时休息五分钟
+}
+
+// GenerateSchedule 按起始筹码、参赛人数和比赛时长生成盲注结构。
+//
+// 第一个级别的大盲注约为起始筹码的 1%，之后按固定倍数增长，
+// 到目标时长时大盲注约为总筹码的 1/25，此时剩余玩家的筹码大约只够支付十几个大盲注。
+// 盲注和前注都按筹码面值取整到大约两位有效数字，前注约为大盲注的 1/10
+func GenerateSchedule(opts StructureOptions) (Schedule, error) {
+	switch {
+	case opts.StartingStack <= 0:
+		return nil, fmt.Errorf("the starting stack must be positive, got %d", opts.StartingStack)
+	case opts.Players < 2:
+		return nil, fmt.Errorf("a tournament needs at least 2 players, got %d", opts.Players)
+	case opts.LevelLength <= 0:
+		return nil, fmt.Errorf("the level length must be positive, got %s", opts.LevelLength)
+	case opts.Duration < opts.LevelLength:
+		return nil, fmt.Errorf("the duration %s is shorter than a level", opts.Duration)
+	}
+
+	levels := int(opts.Duration / opts.LevelLength)
+	first := max(roundChips(float64(opts.StartingStack)/200), 1)
+	last := max(float64(opts.StartingStack*opts.Players)/50, float64(first))
+	growth := 1.0
+	if levels > 1 {
+		growth = math.Pow(last/float64(first), 1/float64(levels-1))
+	}
+
+	anteLevel := opts.AnteLevel
+	if anteLevel == 0 {
+		anteLevel = levels/3 + 1
+	}
+	breakLength := opts.BreakLength
+	if breakLength == 0 {
+		breakLength = 5 * time.Minute
+	}
+
+	schedule := make(Schedule, 0, levels)
+	smallBlind := 0
+	for i := 0; i < levels; i++ {
+		// 取整后至少比上一个级别高一个面值
+		sb := roundChips(float64(first) * math.Pow(growth, float64(i)))
+		if sb <= smallBlind {
+			sb = smallBlind + chipStep(smallBlind)
+		}
+		smallBlind = sb
+
+		level := Level{SmallBlind: sb, Duration: opts.LevelLength}
+		if anteLevel > 0 && i+1 >= anteLevel {
+			level.Ante = max(roundChips(float64(sb)/5), 1)
+		}
+		schedule = append(schedule, level)
+
+		if opts.BreakEvery > 0 && (i+1)%opts.BreakEvery == 0 && i < levels-1 {
+			schedule = append(schedule, Level{Break: true, Duration: breakLength})
+		}
+	}
+	return schedule, schedule.Validate()
+}
+
+// roundChips 按筹码面值取整，大约保留两位有效数字
+func roundChips(v float64) int {
+	if v < 1 {
+		return 0
+	}
+	step := float64(chipStep(int(v)))
+	return int(math.Round(v/step) * step)
+}
+
+// chipStep 获取筹码数取整时使用的面值。
+// 25 以下取整到 1，100 以下取整到 5；
+// 更大的数在每个数量级内，2.5 倍以下取整到数量级的 1/10，其余取整到 1/4，
+// 例如 100 到 249 取整到 10，250 到 999 取整到 25，1000 到 2499 取整到 100
+func chipStep(v int) int {
+	switch {
+	case v < 25:
+		return 1
+	case v < 100:
+		return 5
+	}
+	d := 100
+	for v >= d*10 {
+		d *= 10
+	}
+	if v*2 < d*5 {
+		return d / 10
+	}
+	return d / 4
+}
+
+// Save 将盲注结构保存为 JSON 文件，便于比赛主管编辑后再次读取
+func (s Schedule) Save(name string) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, b, 0o644)
+}
+
+// LoadSchedule 从 JSON 文件读取盲注结构
+func LoadSchedule(name string) (Schedule, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Schedule
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,61 @@
+package tournament
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSchedule(t *testing.T) {
+	schedule, err := GenerateSchedule(StructureOptions{
+		StartingStack: 5000,
+		Players:       9,
+		Duration:      3 * time.Hour,
+		LevelLength:   15 * time.Minute,
+		BreakEvery:    4,
+	})
+	require.NoError(t, err)
+
+	// 12 个级别，每 4 个级别休息一次，最后一个级别之后不休息
+	require.Len(t, schedule, 14)
+	assert.True(t, schedule[4].Break)
+	assert.True(t, schedule[9].Break)
+	assert.Equal(t, 5*time.Minute, schedule[4].Duration)
+
+	blinds := make(Schedule, 0)
+	for _, l := range schedule {
+		if !l.Break {
+			blinds = append(blinds, l)
+		}
+	}
+	assert.Equal(t, 25, blinds[0].SmallBlind)
+	assert.Equal(t, 0, blinds[3].Ante)
+	assert.Positive(t, blinds[4].Ante)
+
+	// 盲注逐级递增并按面值取整，最后的大盲注约为总筹码的 1/25
+	for i, l := range blinds {
+		assert.Zero(t, l.SmallBlind%chipStep(l.SmallBlind), l.String())
+		if i > 0 {
+			assert.Greater(t, l.SmallBlind, blinds[i-1].SmallBlind)
+		}
+	}
+	assert.InDelta(t, 45000/25, blinds[11].SmallBlind*2, 200)
+
+	// 保存后可以读取编辑过的盲注结构
+	name := filepath.Join(t.TempDir(), "schedule.json")
+	require.NoError(t, schedule.Save(name))
+	loaded, err := LoadSchedule(name)
+	require.NoError(t, err)
+	assert.Equal(t, schedule, loaded)
+
+	// 取整大约保留两位有效数字
+	for v, want := range map[float64]int{7.4: 7, 18: 18, 62: 60, 137: 140, 612: 600, 1040: 1000, 1160: 1200, 3620: 3500, 11400: 11000, 27600: 27500} {
+		assert.Equal(t, want, roundChips(v), v)
+	}
+
+	_, err = GenerateSchedule(StructureOptions{StartingStack: 1000, Players: 1, Duration: time.Hour, LevelLength: time.Minute})
+	assert.Error(t, err)
+}
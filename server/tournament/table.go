@@ -0,0 +1,192 @@
+package tournament
+
+import (
+	"fmt"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+// Table 比赛中的一张牌桌
+type Table struct {
+	Id   int         // 牌桌编号，从 1 开始
+	Game *poker.Game // 牌桌上的牌局
+
+	tournament *Tournament           // 所属的比赛
+	hands      int                   // 当前级别已经进行的手牌数
+	stacks     map[*poker.Player]int // 本手牌开始时每名玩家的筹码
+}
+
+// Move 一名玩家从一张牌桌换到另一张牌桌
+type Move struct {
+	Name     string // 玩家名称
+	From     *Table // 原来的牌桌
+	FromSeat string // 原来的座位，即原牌桌上的玩家标识
+	To       *Table // 新的牌桌
+	ToSeat   string // 新的座位，即新牌桌上的玩家标识
+}
+
+// Seat 玩家在比赛中的牌桌和座位
+type Seat struct {
+	Table    *Table // 所在的牌桌
+	PlayerId string // 座位，即牌桌上的玩家标识
+}
+
+// Listener 比赛的监听者，可用于将客户端转到新的牌桌
+type Listener interface {
+	OnPlayerMoved(m Move)    // 玩家换桌
+	OnTableOpened(tb *Table) // 迟到报名或重新参赛的玩家坐不下时新开了一张牌桌
+}
+
+// OnHandStart 记录每名玩家在本手牌开始时的筹码，用于确定同时被淘汰的玩家的名次
+func (tb *Table) OnHandStart(g *poker.Game) {
+	tb.stacks = make(map[*poker.Player]int)
+	for _, p := range g.Table.Seats.GetActivePlayers() {
+		tb.stacks[p] = p.Chips
+	}
+}
+
+// OnAction 比赛无需处理
+func (tb *Table) OnAction(g *poker.Game, a poker.Action) {}
+
+// OnStage 比赛无需处理
+func (tb *Table) OnStage(g *poker.Game, stage poker.GameStage) {}
+
+// OnHandEnd 记录本手牌中被淘汰的玩家
+func (tb *Table) OnHandEnd(g *poker.Game, result *poker.HandResult) {
+	tb.tournament.handEnded(tb, result)
+}
+
+// players 获取牌桌上仍持有筹码的玩家
+func (tb *Table) players() []*poker.Player {
+	players := make([]*poker.Player, 0)
+	for _, p := range tb.Game.Table.Seats.GetActivePlayers() {
+		if p.Chips > 0 {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+// clearBusted 让已被淘汰的玩家离座，空出座位
+func (tb *Table) clearBusted() error {
+	s := tb.Game.Table.Seats
+	for i := 0; i < s.Len(); i++ {
+		if p := s.Player; p.Status != poker.PlayerVacated && p.Chips == 0 {
+			if err := tb.Game.LeaveSeat(p.Id); err != nil {
+				return err
+			}
+		}
+		s = s.Next()
+	}
+	return nil
+}
+
+// freeSeat 获取换桌玩家的座位。
+// 从当前大盲注的下一个座位开始寻找空座位，使换来的玩家尽快下大盲注，没有空座位时返回 nil
+func (tb *Table) freeSeat() *poker.Seat {
+	g := tb.Game
+	s := g.Table.Seats
+	if g.Table.BigBlind != nil {
+		s = g.Table.BigBlind.Next()
+	}
+
+	for i := 0; i < s.Len(); i++ {
+		p := s.Player
+		if p.Status == poker.PlayerVacated {
+			return s
+		}
+		// 一手牌结束后，已被淘汰的玩家可以让出座位
+		if p.Chips == 0 && !g.IsPlayerStage() {
+			return s
+		}
+		s = s.Next()
+	}
+	return nil
+}
+
+// balance 牌桌之间的人数相差两人及以上时，将这张牌桌上下一手牌的大盲注玩家移到人数最少的牌桌上
+func (t *Tournament) balance(tb *Table) error {
+	for {
+		smallest := t.smallestTable(tb)
+		if smallest == nil || len(tb.players())-len(smallest.players()) < 2 {
+			return nil
+		}
+
+		_, _, bigBlind := tb.Game.NextBlinds()
+		if err := t.move(bigBlind.Player, tb, smallest); err != nil {
+			return err
+		}
+	}
+}
+
+// breakTable 拆散牌桌，按下一手牌的大盲注顺序依次将玩家移到人数最少的牌桌上
+func (t *Tournament) breakTable(tb *Table) error {
+	for len(tb.players()) > 0 {
+		to := t.smallestTable(tb)
+		if to == nil {
+			return fmt.Errorf("there is no table left to move the players of table %d to", tb.Id)
+		}
+
+		_, _, bigBlind := tb.Game.NextBlinds()
+		if err := t.move(bigBlind.Player, tb, to); err != nil {
+			return err
+		}
+	}
+
+	for i, other := range t.Tables {
+		if other == tb {
+			t.Tables = append(t.Tables[:i], t.Tables[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// smallestTable 获取除 tb 之外人数最少且有空座位的牌桌，人数相同时选择编号较小的
+func (t *Tournament) smallestTable(tb *Table) *Table {
+	var smallest *Table
+	for _, other := range t.Tables {
+		if other == tb || other.freeSeat() == nil {
+			continue
+		}
+		if smallest == nil || len(other.players()) < len(smallest.players()) {
+			smallest = other
+		}
+	}
+	return smallest
+}
+
+// move 将玩家带着筹码移到另一张牌桌的空座位上，并通知监听者
+func (t *Tournament) move(p *poker.Player, from, to *Table) error {
+	seat := to.freeSeat()
+	if seat == nil {
+		return fmt.Errorf("table %d has no free seat", to.Id)
+	}
+
+	m := Move{Name: p.Name, From: from, FromSeat: p.Id, To: to, ToSeat: seat.Player.Id}
+	chips, isHuman := p.Chips, p.IsHuman
+	if err := from.Game.LeaveSeat(p.Id); err != nil {
+		return err
+	}
+	if seat.Player.Status != poker.PlayerVacated {
+		if err := to.Game.LeaveSeat(seat.Player.Id); err != nil {
+			return err
+		}
+	}
+	if err := to.Game.TakeSeat(seat.Player.Id, m.Name, chips, isHuman); err != nil {
+		return err
+	}
+	// 机器人跟着玩家一起换桌
+	if bot := from.Game.Bots[m.Name]; bot != nil {
+		delete(from.Game.Bots, m.Name)
+		if to.Game.Bots == nil {
+			to.Game.Bots = make(map[string]poker.Bot)
+		}
+		to.Game.Bots[m.Name] = bot
+	}
+
+	for _, l := range t.Listeners {
+		l.OnPlayerMoved(m)
+	}
+	return nil
+}
@@ -0,0 +1,465 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+)
+
+var (
+	ErrOnBreak     = errors.New("the tournament is on a break")               // 休息时间内不能开始新的一手牌
+	ErrFinished    = errors.New("the tournament has finished")                // 比赛已经结束
+	ErrTableClosed = errors.New("the table has been broken up and is closed") // 牌桌已经拆散
+	ErrWaiting     = errors.New("the table is waiting for more players")      // 牌桌人数不足，等待其他牌桌移来玩家
+)
+
+// defaultTableSize 默认每张牌桌的座位数
+const defaultTableSize = 9
+
+// Config 比赛设置
+type Config struct {
+	StartingStack int        // 每名玩家的起始筹码
+	BuyIn         int        // 每名玩家的买入，全部计入奖池
+	Schedule      Schedule   // 盲注结构，为空时使用 DefaultSchedule
+	Payouts       []int      // 各名次的奖金比例，第一名在前，为空时按参赛人数选择
+	TableSize     int        // 每张牌桌的座位数，为 0 时使用 9 人桌
+	Rand          *rand.Rand // 用于随机安排座位和为每张牌桌洗牌，为 nil 时使用随机种子
+
+	RebuyLevels    int // 前几个级别内可以重购，为 0 时不能重购
+	RebuyThreshold int // 筹码不超过此数时可以重购，为 0 时只有输光筹码后才能重购
+	RebuyChips     int // 重购获得的筹码，为 0 时与起始筹码相同
+	RebuyCost      int // 重购的费用，为 0 时与买入相同
+	AddOnChips     int // 第一次休息时加购获得的筹码，为 0 时不能加购
+	AddOnCost      int // 加购的费用，为 0 时与买入相同
+	ReEntries      int // 被淘汰后最多可以重新参赛的次数
+	LateRegLevels  int // 前几个级别内可以迟到报名和重新参赛，为 0 时不能迟到报名
+
+	Bounty      int  // 每次买入、重新参赛或输光后重购时放在玩家身上的赏金，在买入之外单独支付，为 0 时没有赏金
+	Progressive bool // 渐进式赏金：淘汰玩家时只立即支付一半赏金，另一半加到淘汰者自己身上
+}
+
+// Result 一名玩家的最终名次
+type Result struct {
+	Name     string `json:"name"`     // 玩家名称
+	Table    int    `json:"table"`    // 被淘汰时所在的牌桌编号
+	Position int    `json:"position"` // 名次，同一手牌中起始筹码相同的玩家并列
+	Prize    int    `json:"prize"`    // 奖金
+	HandId   int    `json:"hand_id"`  // 被淘汰的手牌编号，冠军为比赛的最后一手牌
+}
+
+// Tournament 锦标赛。
+// 在多张 poker.Game 牌桌之上管理盲注级别、休息时间、座位平衡、拆桌和玩家的淘汰顺序，
+// 只剩一名玩家持有筹码时比赛结束，并按名次分配奖池
+type Tournament struct {
+	Tables    []*Table          // 仍在进行的牌桌，按编号排列
+	TableSize int               // 每张牌桌的座位数
+	Schedule  Schedule          // 盲注结构
+	PrizePool int               // 奖池
+	Payouts   []int             // 各名次的奖金，第一名在前
+	Results   []Result          // 按淘汰顺序记录的名次，冠军在最后
+	Level     int               // 当前级别在盲注结构中的位置
+	Now       func() time.Time  // 获取当前时间，用于计算级别的持续时间
+	Listeners []Listener        // 玩家换桌和新开牌桌的监听者
+	Deal      *Deal             // 正在表决的分奖金协议
+	Ledger    map[string]*Entry // 每名参赛者的买入记录
+	Knockouts []Knockout        // 按顺序记录的淘汰赏金
+
+	config     Config     // 比赛设置
+	rand       *rand.Rand // 用于随机安排座位和生成每张牌桌的洗牌种子
+	entrants   int        // 参赛人数
+	levelStart time.Time  // 当前级别的开始时间
+}
+
+// New 创建比赛，随机安排玩家的牌桌和座位，并带入起始筹码。
+// 玩家名称在比赛中唯一标识一名玩家
+func New(cfg Config, names []string) (*Tournament, error) {
+	if len(names) < 2 {
+		return nil, fmt.Errorf("a tournament needs at least 2 players, got %d", len(names))
+	}
+	if cfg.StartingStack <= 0 {
+		return nil, fmt.Errorf("the starting stack must be positive, got %d", cfg.StartingStack)
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate player name %q", name)
+		}
+		seen[name] = true
+	}
+
+	tableSize := cfg.TableSize
+	if tableSize == 0 {
+		tableSize = defaultTableSize
+	}
+	if tableSize < 2 || tableSize > 10 {
+		return nil, fmt.Errorf("a table must have between 2 and 10 seats, got %d", tableSize)
+	}
+
+	schedule := cfg.Schedule
+	if len(schedule) == 0 {
+		schedule = DefaultSchedule
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 重购和加购的筹码和费用默认与买入相同
+	if cfg.RebuyChips == 0 {
+		cfg.RebuyChips = cfg.StartingStack
+	}
+	if cfg.RebuyCost == 0 {
+		cfg.RebuyCost = cfg.BuyIn
+	}
+	if cfg.AddOnCost == 0 {
+		cfg.AddOnCost = cfg.BuyIn
+	}
+	if cfg.Rand == nil {
+		cfg.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	t := &Tournament{
+		TableSize: tableSize,
+		Schedule:  schedule,
+		PrizePool: cfg.BuyIn * len(names),
+		Results:   make([]Result, 0, len(names)),
+		Now:       time.Now,
+		Ledger:    make(map[string]*Entry, len(names)),
+		config:    cfg,
+		rand:      cfg.Rand,
+		entrants:  len(names),
+	}
+	for _, name := range names {
+		t.Ledger[name] = &Entry{Name: name, Paid: cfg.BuyIn, Chips: cfg.StartingStack, Bounty: cfg.Bounty}
+	}
+	if err := t.updatePayouts(); err != nil {
+		return nil, err
+	}
+	if err := t.seatPlayers(names); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// seatPlayers 创建所需数量的牌桌，打乱玩家顺序后轮流分配到各张牌桌的随机座位上
+func (t *Tournament) seatPlayers(names []string) error {
+	players := append([]string(nil), names...)
+	t.rand.Shuffle(len(players), func(i, j int) { players[i], players[j] = players[j], players[i] })
+
+	n := (len(players) + t.TableSize - 1) / t.TableSize
+	for i := 0; i < n; i++ {
+		t.openTable()
+	}
+
+	seats := make([][]int, n)
+	for i := range seats {
+		seats[i] = t.rand.Perm(t.TableSize)
+	}
+	for i, name := range players {
+		tb := t.Tables[i%n]
+		s := tb.Game.Table.SeatAt(seats[i%n][i/n])
+		if err := tb.Game.TakeSeat(s.Player.Id, name, t.config.StartingStack, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openTable 新开一张牌桌，编号接在现有牌桌之后
+func (t *Tournament) openTable() *Table {
+	id := 1
+	if len(t.Tables) > 0 {
+		id = t.Tables[len(t.Tables)-1].Id + 1
+	}
+
+	tb := &Table{Id: id, Game: poker.NewGameWithSeats(t.TableSize), tournament: t}
+
+	// 每张牌桌用自己的随机数生成器洗牌，相同的 Config.Rand 得到相同的牌序
+	r := rand.New(rand.NewSource(t.rand.Int63()))
+	tb.Game.DeckSource = func() *poker.Deck { return poker.NewDeckWithRand(r) }
+	tb.Game.Observers = append(tb.Game.Observers, tb)
+	t.Tables = append(t.Tables, tb)
+	return tb
+}
+
+// updatePayouts 按当前的奖池和参赛人数重新计算各名次的奖金
+func (t *Tournament) updatePayouts() error {
+	percents := t.config.Payouts
+	if len(percents) == 0 {
+		percents = PayoutPercents(t.entrants)
+	}
+	// 获奖名次多于参赛人数时，多出名次的奖金归第一名
+	if len(percents) > t.entrants {
+		percents = append([]int(nil), percents[:t.entrants]...)
+		percents[0] += 100 - sum(percents)
+	}
+
+	payouts, err := PayoutTable(t.PrizePool, percents)
+	if err != nil {
+		return err
+	}
+	t.Payouts = payouts
+	return nil
+}
+
+// Start 开始比赛，从第一个级别开始计时
+func (t *Tournament) Start() {
+	t.Level = 0
+	t.levelStart = t.Now()
+	for _, tb := range t.Tables {
+		tb.hands = 0
+	}
+}
+
+// CurrentLevel 获取当前级别
+func (t *Tournament) CurrentLevel() Level {
+	return t.Schedule[t.Level]
+}
+
+// Done 比赛是否已经结束
+func (t *Tournament) Done() bool {
+	return len(t.Results) == t.entrants
+}
+
+// Table 根据编号获取仍在进行的牌桌
+func (t *Tournament) Table(id int) *Table {
+	for _, tb := range t.Tables {
+		if tb.Id == id {
+			return tb
+		}
+	}
+	return nil
+}
+
+// Player 获取玩家所在的牌桌和座位上的玩家，玩家已被淘汰时返回 nil
+func (t *Tournament) Player(name string) (*Table, *poker.Player) {
+	for _, tb := range t.Tables {
+		for _, p := range tb.Game.Table.Seats.GetActivePlayers() {
+			if p.Name == name && p.Chips > 0 {
+				return tb, p
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Seats 获取所有仍持有筹码的玩家所在的牌桌和座位，key 为玩家名称
+func (t *Tournament) Seats() map[string]Seat {
+	seats := make(map[string]Seat)
+	for _, tb := range t.Tables {
+		for _, p := range tb.players() {
+			seats[p.Name] = Seat{Table: tb, PlayerId: p.Id}
+		}
+	}
+	return seats
+}
+
+// StartHand 在指定的牌桌上开始新的一手牌。
+//
+// 有正在表决的分奖金协议时返回 ErrDealPending。
+// 开始之前先推进盲注级别，休息时间内返回 ErrOnBreak；
+// 然后让已被淘汰的玩家离座，剩余玩家坐得下更少的牌桌时拆散这张牌桌并返回 ErrTableClosed，
+// 否则将这张牌桌多出的玩家移到人数最少的牌桌上，平衡后人数不足两人时返回 ErrWaiting
+func (t *Tournament) StartHand(id int) error {
+	if t.Done() {
+		return ErrFinished
+	}
+	tb := t.Table(id)
+	if tb == nil {
+		return ErrTableClosed
+	}
+	if t.Deal != nil {
+		return ErrDealPending
+	}
+	if t.levelStart.IsZero() {
+		t.Start()
+	}
+
+	t.advanceLevel()
+	level := t.CurrentLevel()
+	if level.Break {
+		return ErrOnBreak
+	}
+
+	if err := tb.clearBusted(); err != nil {
+		return err
+	}
+	if len(t.Tables) > 1 && t.remaining() <= (len(t.Tables)-1)*t.TableSize {
+		if err := t.breakTable(tb); err != nil {
+			return err
+		}
+		return ErrTableClosed
+	}
+	if err := t.balance(tb); err != nil {
+		return err
+	}
+	if len(tb.players()) < 2 {
+		return ErrWaiting
+	}
+
+	g := tb.Game
+	if g.Table.MinBet != level.SmallBlind || g.Table.Ante != level.Ante {
+		if err := g.SetBlinds(level.SmallBlind, level.Ante); err != nil {
+			return err
+		}
+	}
+	return g.StartHand()
+}
+
+// BreakRemaining 获取当前休息时间的剩余时长，不在休息时返回 0
+func (t *Tournament) BreakRemaining() time.Duration {
+	level := t.CurrentLevel()
+	if !level.Break {
+		return 0
+	}
+	return max(level.Duration-t.Now().Sub(t.levelStart), 0)
+}
+
+// Standings 获取已经确定的名次，按名次排列
+func (t *Tournament) Standings() []Result {
+	results := append([]Result(nil), t.Results...)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Position < results[j].Position })
+	return results
+}
+
+// advanceLevel 推进到当前应处的级别，最后一个级别不会结束，比赛尚未开始时不做处理。
+// 按手牌数结束的级别以进行手牌最多的牌桌为准
+func (t *Tournament) advanceLevel() {
+	for !t.levelStart.IsZero() && t.Level < len(t.Schedule)-1 {
+		hands := 0
+		for _, tb := range t.Tables {
+			hands = max(hands, tb.hands)
+		}
+
+		level := t.CurrentLevel()
+		if !level.expired(t.Now().Sub(t.levelStart), hands) {
+			return
+		}
+
+		// 按时间结束的级别从上一级别的结束时刻开始计时，其余从当前时刻开始
+		if level.Duration > 0 && t.Now().Sub(t.levelStart) >= level.Duration {
+			t.levelStart = t.levelStart.Add(level.Duration)
+		} else {
+			t.levelStart = t.Now()
+		}
+		for _, tb := range t.Tables {
+			tb.hands = 0
+		}
+		t.Level++
+	}
+}
+
+// remaining 获取所有牌桌上仍持有筹码的玩家数
+func (t *Tournament) remaining() int {
+	n := 0
+	for _, tb := range t.Tables {
+		n += len(tb.players())
+	}
+	return n
+}
+
+// handEnded 记录牌桌上本手牌被淘汰的玩家的名次，并分配他们身上的赏金。
+// 同一手牌中被淘汰的多名玩家，开始时筹码多的名次靠前，筹码相同的并列并平分对应名次的奖金
+func (t *Tournament) handEnded(tb *Table, result *poker.HandResult) {
+	tb.hands++
+
+	busted := make([]*poker.Player, 0)
+	for p := range tb.stacks {
+		if p.Chips == 0 {
+			busted = append(busted, p)
+		}
+	}
+	sort.SliceStable(busted, func(i, j int) bool {
+		if tb.stacks[busted[i]] != tb.stacks[busted[j]] {
+			return tb.stacks[busted[i]] < tb.stacks[busted[j]]
+		}
+		return busted[i].Name < busted[j].Name
+	})
+	t.collectBounties(tb, busted, result)
+
+	// 按开始时的筹码从少到多分组，每组从最差的名次开始占据连续的名次
+	remaining := t.remaining()
+	worst := remaining + len(busted)
+	for i := 0; i < len(busted); {
+		j := i
+		for j < len(busted) && tb.stacks[busted[j]] == tb.stacks[busted[i]] {
+			j++
+		}
+		t.addResults(tb, busted[i:j], worst-(j-i)+1)
+		worst -= j - i
+		i = j
+	}
+
+	if remaining == 1 {
+		for _, winner := range t.Tables {
+			if players := winner.players(); len(players) == 1 {
+				t.addResults(winner, players, 1)
+				t.collectOwnBounty(players[0].Name)
+			}
+		}
+	}
+}
+
+// addResults 记录并列在 position 名的玩家
+func (t *Tournament) addResults(tb *Table, players []*poker.Player, position int) {
+	prizes := t.prizes(position, len(players))
+	for i, p := range players {
+		t.Results = append(t.Results, Result{
+			Name:     p.Name,
+			Table:    tb.Id,
+			Position: position,
+			Prize:    prizes[i],
+			HandId:   tb.Game.HandId,
+		})
+	}
+}
+
+// prizes 计算并列在 position 名的 n 名玩家的奖金，平分他们所占名次的奖金，无法整除的部分归第一名玩家
+func (t *Tournament) prizes(position, n int) []int {
+	prize := 0
+	for i := position; i < position+n; i++ {
+		if i <= len(t.Payouts) {
+			prize += t.Payouts[i-1]
+		}
+	}
+
+	prizes := make([]int, n)
+	for i := range prizes {
+		prizes[i] = prize / n
+	}
+	prizes[0] += prize % n
+	return prizes
+}
+
+// renumber 剩余玩家或奖池变化后，按淘汰顺序重新计算已淘汰玩家的名次和奖金
+func (t *Tournament) renumber() {
+	position := t.remaining() + 1
+	for j := len(t.Results); j > 0; {
+		// 名次相同的相邻记录为同一手牌中并列的玩家
+		i := j - 1
+		for i > 0 && t.Results[i-1].Position == t.Results[j-1].Position {
+			i--
+		}
+
+		prizes := t.prizes(position, j-i)
+		for k := i; k < j; k++ {
+			t.Results[k].Position = position
+			t.Results[k].Prize = prizes[k-i]
+		}
+		position += j - i
+		j = i
+	}
+}
+
+// sum 计算整数之和
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,263 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/lllllan02/pocker/poker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// moveRecorder 记录所有换桌
+type moveRecorder []Move
+
+func (r *moveRecorder) OnPlayerMoved(m Move)    { *r = append(*r, m) }
+func (r *moveRecorder) OnTableOpened(tb *Table) {}
+
+// playHand 用随机动作打完一手牌，经常全下以便尽快淘汰玩家
+func playHand(t *testing.T, g *poker.Game, r *rand.Rand) {
+	for g.IsPlayerStage() {
+		p, b := g.CurrentSeat.Player, g.BettingRound
+		allIn := b.Bets[p] + p.Chips
+		switch n := r.Intn(10); {
+		case n < 3 && allIn > b.CallAmount:
+			require.NoError(t, g.Raise(allIn))
+		case n < 6 && p.CanFold(b):
+			require.NoError(t, g.Fold())
+		case p.CanCheck(b):
+			require.NoError(t, g.Check())
+		default:
+			require.NoError(t, g.Call())
+		}
+	}
+}
+
+func TestTournamentPlaysToAWinner(t *testing.T) {
+	tour, err := New(Config{
+		StartingStack: 200,
+		BuyIn:         10,
+		Schedule: Schedule{
+			{SmallBlind: 10, Hands: 2},
+			{SmallBlind: 20, Ante: 5, Hands: 2},
+			{SmallBlind: 40, Ante: 10},
+		},
+		Rand: rand.New(rand.NewSource(1)),
+	}, []string{"p1", "p2", "p3", "p4", "p5"})
+	require.NoError(t, err)
+	require.Len(t, tour.Tables, 1)
+	assert.Equal(t, []int{33, 17}, tour.Payouts)
+
+	// 每手牌第一个行动的玩家全下，其他玩家跟注
+	g := tour.Tables[0].Game
+	for hands := 0; !tour.Done(); hands++ {
+		require.Less(t, hands, 1000)
+		require.NoError(t, tour.StartHand(1))
+
+		// 盲注和前注让玩家全下时，这手牌可能不需要任何行动就结束了
+		if g.IsPlayerStage() {
+			require.NoError(t, g.Raise(g.BettingRound.Bets[g.CurrentSeat.Player]+g.CurrentSeat.Player.Chips))
+		}
+		for g.IsPlayerStage() {
+			require.NoError(t, g.Call())
+		}
+	}
+	assert.ErrorIs(t, tour.StartHand(1), ErrFinished)
+
+	// 每名玩家都有名次，冠军持有所有筹码，奖金总和等于奖池
+	standings := tour.Standings()
+	require.Len(t, standings, 5)
+	assert.Equal(t, 1, standings[0].Position)
+	_, winner := tour.Player(standings[0].Name)
+	require.NotNil(t, winner)
+	assert.Equal(t, 1000, winner.Chips)
+	prizes := 0
+	for i, r := range standings {
+		assert.LessOrEqual(t, r.Position, i+1)
+		prizes += r.Prize
+	}
+	assert.Equal(t, tour.PrizePool, prizes)
+}
+
+func TestTournamentLevelsAndBreaks(t *testing.T) {
+	now := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	tour, err := New(Config{
+		StartingStack: 1000,
+		Schedule: Schedule{
+			{SmallBlind: 10, Duration: 10 * time.Minute},
+			{Break: true, Duration: 5 * time.Minute},
+			{SmallBlind: 25, Ante: 5, Duration: 10 * time.Minute},
+		},
+	}, []string{"p1", "p2"})
+	require.NoError(t, err)
+	tour.Now = func() time.Time { return now }
+	g := tour.Tables[0].Game
+
+	require.NoError(t, tour.StartHand(1))
+	assert.Equal(t, 10, g.Table.MinBet)
+	require.NoError(t, g.Fold())
+
+	// 未到时间时保持在当前级别
+	now = now.Add(5 * time.Minute)
+	require.NoError(t, tour.StartHand(1))
+	assert.Equal(t, 0, tour.Level)
+	require.NoError(t, g.Fold())
+
+	// 第一个级别结束后进入休息时间
+	now = now.Add(7 * time.Minute)
+	assert.ErrorIs(t, tour.StartHand(1), ErrOnBreak)
+	assert.Equal(t, 3*time.Minute, tour.BreakRemaining())
+
+	// 休息结束后使用下一个级别的盲注和前注
+	now = now.Add(3 * time.Minute)
+	require.NoError(t, tour.StartHand(1))
+	assert.Equal(t, 25, g.Table.MinBet)
+	assert.Equal(t, 5, g.Table.Ante)
+}
+
+func TestTournamentSimultaneousBustOuts(t *testing.T) {
+	tour, err := New(Config{StartingStack: 100, BuyIn: 10, Payouts: []int{50, 30, 20}}, []string{"p1", "p2", "p3"})
+	require.NoError(t, err)
+	tb := tour.Tables[0]
+	g := tb.Game
+	players := g.Table.Seats.GetActivePlayers()
+	players[0].Chips = 300
+	players[2].Chips = 50
+
+	// 同一手牌中被淘汰的玩家，开始时筹码多的名次靠前
+	tb.OnHandStart(g)
+	players[0].Chips, players[1].Chips, players[2].Chips = 450, 0, 0
+	tb.OnHandEnd(g, nil)
+
+	require.True(t, tour.Done())
+	assert.Equal(t, []Result{
+		{Name: players[2].Name, Table: 1, Position: 3, Prize: 6},
+		{Name: players[1].Name, Table: 1, Position: 2, Prize: 9},
+		{Name: players[0].Name, Table: 1, Position: 1, Prize: 15},
+	}, tour.Results)
+}
+
+func TestTournamentMultiTable(t *testing.T) {
+	names := make([]string, 20)
+	for i := range names {
+		names[i] = fmt.Sprintf("p%d", i+1)
+	}
+
+	r := rand.New(rand.NewSource(7))
+	tour, err := New(Config{
+		StartingStack: 300,
+		BuyIn:         10,
+		Schedule:      Schedule{{SmallBlind: 10, Hands: 10}, {SmallBlind: 25, Ante: 5}},
+		TableSize:     6,
+		Rand:          r,
+	}, names)
+	require.NoError(t, err)
+	moves := &moveRecorder{}
+	tour.Listeners = append(tour.Listeners, moves)
+
+	// 20 名玩家随机分到 4 张 6 人桌，每桌 5 人
+	require.Len(t, tour.Tables, 4)
+	for _, tb := range tour.Tables {
+		assert.Len(t, tb.players(), 5)
+	}
+	seats := tour.Seats()
+	require.Len(t, seats, 20)
+	for _, name := range names {
+		tb, p := tour.Player(name)
+		assert.Equal(t, Seat{Table: tb, PlayerId: p.Id}, seats[name])
+	}
+
+	for round := 0; !tour.Done(); round++ {
+		require.Less(t, round, 5000)
+		for _, tb := range append([]*Table(nil), tour.Tables...) {
+			remaining := tour.remaining()
+			err := tour.StartHand(tb.Id)
+			if errors.Is(err, ErrTableClosed) || errors.Is(err, ErrWaiting) || errors.Is(err, ErrFinished) {
+				continue
+			}
+			require.NoError(t, err)
+
+			// 开始一手牌时，这张牌桌不比其他牌桌多出两人及以上，剩余玩家坐得下时已经合并为一张决赛桌
+			for _, other := range tour.Tables {
+				assert.Less(t, len(tb.players())-len(other.players()), 2)
+			}
+			if remaining <= 6 {
+				assert.Len(t, tour.Tables, 1)
+			}
+			playHand(t, tb.Game, r)
+		}
+
+		// 筹码总数不变
+		chips := 0
+		for _, tb := range tour.Tables {
+			for _, p := range tb.players() {
+				chips += p.Chips
+			}
+		}
+		require.Equal(t, 20*300, chips)
+	}
+
+	assert.NotEmpty(t, *moves)
+	require.Len(t, tour.Results, 20)
+	seen := make(map[string]bool)
+	for _, r := range tour.Results {
+		seen[r.Name] = true
+	}
+	assert.Len(t, seen, 20)
+	assert.Equal(t, 1, tour.Results[19].Position)
+}
+
+func TestTournamentDeal(t *testing.T) {
+	tour, err := New(Config{StartingStack: 100, BuyIn: 100, Payouts: []int{50, 30, 20}}, []string{"p1", "p2", "p3"})
+	require.NoError(t, err)
+	_, p1 := tour.Player("p1")
+	_, p2 := tour.Player("p2")
+	_, p3 := tour.Player("p3")
+	p1.Chips, p2.Chips, p3.Chips = 150, 100, 50
+
+	// 按筹码比例分配：每人先得到 60，剩余的 120 按筹码比例分配
+	deal, err := tour.ProposeDeal(DealChipChop)
+	require.NoError(t, err)
+	assert.Equal(t, []DealShare{
+		{Name: "p1", Chips: 150, Equity: 120, Prize: 120},
+		{Name: "p2", Chips: 100, Equity: 100, Prize: 100},
+		{Name: "p3", Chips: 50, Equity: 80, Prize: 80},
+	}, deal.Shares)
+	_, err = tour.ProposeDeal(DealICM)
+	assert.ErrorIs(t, err, ErrDealPending)
+	assert.ErrorIs(t, tour.StartHand(1), ErrDealPending)
+	// 表决期间不能改变奖池和筹码
+	assert.ErrorIs(t, tour.Register("p4"), ErrDealPending)
+	assert.ErrorIs(t, tour.ReEnter("p3"), ErrDealPending)
+	assert.ErrorIs(t, tour.Rebuy("p3"), ErrDealPending)
+	assert.ErrorIs(t, tour.AddOn("p3"), ErrDealPending)
+
+	// 有玩家拒绝时协议作废
+	require.NoError(t, tour.Vote("p1", true))
+	assert.ErrorIs(t, tour.Vote("p2", false), ErrDealRejected)
+	assert.Nil(t, tour.Deal)
+
+	// 所有玩家同意 ICM 协议后比赛结束
+	deal, err = tour.ProposeDeal(DealICM)
+	require.NoError(t, err)
+	prizes := 0
+	for _, s := range deal.Shares {
+		prizes += s.Prize
+	}
+	assert.Equal(t, tour.PrizePool, prizes)
+	assert.ErrorIs(t, tour.Vote("p4", true), ErrNotInDeal)
+	for _, name := range []string{"p3", "p2", "p1"} {
+		require.NoError(t, tour.Vote(name, true))
+	}
+
+	require.True(t, tour.Done())
+	standings := tour.Standings()
+	for i, s := range deal.Shares {
+		assert.Equal(t, s.Name, standings[i].Name)
+		assert.Equal(t, i+1, standings[i].Position)
+		assert.Equal(t, s.Prize, standings[i].Prize)
+	}
+}